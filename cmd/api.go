@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/auth"
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
 	"github.com/StefanShivarov/gollab-backend/internal/common"
 	"github.com/StefanShivarov/gollab-backend/internal/config"
 	"github.com/StefanShivarov/gollab-backend/internal/db"
+	"github.com/StefanShivarov/gollab-backend/internal/idempotency"
+	"github.com/StefanShivarov/gollab-backend/internal/mail"
+	"github.com/StefanShivarov/gollab-backend/internal/notifications"
 	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/privacy"
+	"github.com/StefanShivarov/gollab-backend/internal/realtime"
+	"github.com/StefanShivarov/gollab-backend/internal/reports"
+	"github.com/StefanShivarov/gollab-backend/internal/webhooks"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
 )
@@ -18,6 +32,9 @@ type Application struct {
 	Config    config.Config
 	DB        *gorm.DB
 	Validator *validator.Validate
+	Mailer    mail.Mailer
+
+	workers []func(ctx context.Context)
 }
 
 func NewApplication(cfg config.Config) (*Application, error) {
@@ -30,17 +47,164 @@ func NewApplication(cfg config.Config) (*Application, error) {
 		Config:    cfg,
 		DB:        gormDB,
 		Validator: validator.New(),
+		Mailer:    mail.NewMailer(cfg),
 	}, nil
 }
 
 func (app *Application) mountRoutes(r chi.Router) {
-	userService := org.NewUserService(org.NewUserRepository(app.DB), app.Validator)
+	userService := org.NewUserService(org.NewUserRepository(app.DB), app.Validator, app.passwordPolicy())
 	userHandler := org.NewUserHandler(userService)
-	teamHandler := org.NewTeamHandler(org.NewTeamService(org.NewTeamRepository(app.DB), userService, app.Validator))
+	notificationService := notifications.NewNotificationService(
+		notifications.NewNotificationRepository(app.DB),
+		app.Mailer,
+		app.Validator,
+		app.Config.AppBaseURL,
+		app.unsubscribeSecret(),
+	)
+	notificationHandler := notifications.NewNotificationHandler(notificationService)
+	teamService := org.NewTeamService(org.NewTeamRepository(app.DB), userService, notificationService, app.Validator)
+	teamHandler := org.NewTeamHandler(teamService)
+	passwordResetHandler := org.NewPasswordResetHandler(org.NewPasswordResetService(
+		org.NewPasswordResetRepository(app.DB),
+		userService,
+		app.Mailer,
+		app.Validator,
+		app.Config.PasswordResetTTL,
+		app.Config.AppBaseURL,
+	))
+	tokenService := auth.NewTokenService(auth.NewTokenRepository(app.DB), app.Validator)
+	tokenHandler := auth.NewTokenHandler(tokenService)
+	twoFactorService := auth.NewTwoFactorService(
+		auth.NewTwoFactorRepository(app.DB),
+		userService,
+		teamService,
+		app.Validator,
+		app.Config.TOTPIssuer,
+	)
+	twoFactorHandler := auth.NewTwoFactorHandler(twoFactorService)
+	lockoutService := auth.NewLockoutService(auth.NewLoginThrottleRepository(app.DB), userService, auth.LockoutPolicy{
+		MaxAccountFailures: app.Config.LoginMaxAccountFailures,
+		MaxIPFailures:      app.Config.LoginMaxIPFailures,
+		FailureWindow:      app.Config.LoginFailureWindow,
+		LockoutDuration:    app.Config.LoginLockoutDuration,
+		DelayBase:          app.Config.LoginDelayBase,
+		DelayMax:           app.Config.LoginDelayMax,
+	})
+	userService.Guard = lockoutService
+	lockoutHandler := auth.NewLockoutHandler(lockoutService)
+	sessionService := auth.NewSessionService(
+		auth.NewSessionRepository(app.DB),
+		userService,
+		twoFactorService,
+		lockoutService,
+		app.Validator,
+		app.Config.SessionTTL,
+		app.Config.LoginChallengeTTL,
+	)
+	sessionHandler := auth.NewSessionHandler(sessionService)
+	privacyService := privacy.NewPrivacyService(privacy.NewDataRequestRepository(app.DB), userService)
+	privacyHandler := privacy.NewPrivacyHandler(privacyService)
+	boardService := backlog.NewBoardService(backlog.NewBoardRepository(app.DB), teamService, app.Validator)
+	eventService := realtime.NewEventService(realtime.NewEventRepository(app.DB), app.Config.BoardEventRetention)
+	presenceService := realtime.NewPresenceService(
+		realtime.NewPresenceRepository(app.DB),
+		userService,
+		sessionService.Authenticate,
+		app.Config.PresenceHeartbeatInterval,
+		app.websocketOrigins(),
+	)
+	boardHandler := backlog.NewBoardHandler(boardService, eventService, presenceService)
+	itemService := backlog.NewItemService(backlog.NewItemRepository(app.DB), boardService, userService, notificationService, app.Validator)
+	commentService := backlog.NewCommentService(backlog.NewCommentRepository(app.DB), itemService, app.Validator)
+	linkService := backlog.NewLinkService(backlog.NewLinkRepository(app.DB), itemService, app.Validator)
+	itemHandler := backlog.NewItemHandler(itemService, commentService, linkService)
+	sprintHandler := backlog.NewSprintHandler(backlog.NewSprintService(backlog.NewSprintRepository(app.DB), boardService, itemService, app.Validator))
+	reportHandler := reports.NewReportHandler(reports.NewReportService(reports.NewReportRepository(app.DB)))
+	auditHandler := audit.NewAuditHandler(audit.NewAuditService(audit.NewEventRepository(app.DB)))
+	webhookService := webhooks.NewWebhookService(webhooks.NewWebhookRepository(app.DB), teamService, app.Validator, webhooks.DeliveryPolicy{
+		MaxAttempts: app.Config.WebhookMaxAttempts,
+		BackoffBase: app.Config.WebhookBackoffBase,
+		BackoffMax:  app.Config.WebhookBackoffMax,
+		Timeout:     app.Config.WebhookTimeout,
+	})
+	webhookHandler := webhooks.NewWebhookHandler(webhookService)
+
+	idempotencyService := idempotency.NewIdempotencyService(idempotency.NewKeyRepository(app.DB), app.Config.IdempotencyKeyTTL)
+
+	app.addWorker("erasure worker", app.Config.ErasureWorkerInterval, privacyService.ProcessErasures)
+	app.addWorker("idempotency cleanup", app.Config.IdempotencyCleanupInterval, idempotencyService.DeleteExpired)
+	app.addWorker("webhook worker", app.Config.WebhookWorkerInterval, webhookService.ProcessOutbox)
+	app.addWorker("board event cleanup", app.Config.BoardEventCleanupInterval, eventService.DeleteExpired)
+	app.addWorker("presence refresh", app.Config.PresenceHeartbeatInterval, presenceService.RefreshPresence)
+	app.addWorker("notification emails", app.Config.NotificationEmailInterval, notificationService.SendEmails)
+	app.addWorker("rank respacing", app.Config.RankRespaceInterval, itemService.RespaceRanks)
+	app.addWorker("due date reminders", app.Config.DueReminderInterval, func() error {
+		return itemService.NotifyDueSoon(app.Config.DueSoonWindow)
+	})
+
+	listener := realtime.NewListener(db.DSN(app.Config))
+	listener.Handle(realtime.BoardEventsChannel, eventService.HandleNotification)
+	listener.Handle(realtime.PresenceChannel, presenceService.HandleNotification)
+	app.workers = append(app.workers, listener.Run)
+
+	r.Use(middleware.RequestID)
+	r.Use(auth.Middleware(sessionService, tokenService))
+	r.Use(idempotency.Middleware(idempotencyService, "/auth", "/password"))
 
 	common.HealthRoute(r, app.DB)
 	org.UserRoutes(r, userHandler)
+	org.PasswordResetRoutes(r, passwordResetHandler)
+	auth.TokenRoutes(r, tokenHandler)
+	auth.SessionRoutes(r, sessionHandler)
+	auth.TwoFactorRoutes(r, twoFactorHandler)
+	auth.LockoutRoutes(r, lockoutHandler)
 	org.TeamRoutes(r, teamHandler)
+	backlog.BoardRoutes(r, boardHandler, itemHandler, sprintHandler)
+	backlog.ItemRoutes(r, itemHandler)
+	backlog.SprintRoutes(r, sprintHandler)
+	reports.ReportRoutes(r, reportHandler)
+	privacy.PrivacyRoutes(r, privacyHandler)
+	audit.AuditRoutes(r, auditHandler)
+	webhooks.WebhookRoutes(r, webhookHandler)
+	notifications.NotificationRoutes(r, notificationHandler)
+}
+
+func (app *Application) addWorker(name string, interval time.Duration, job func() error) {
+	app.workers = append(app.workers, func(ctx context.Context) {
+		common.RunPeriodically(ctx, name, interval, job)
+	})
+}
+
+// websocketOrigins allows the frontend to open WebSockets to the API on another origin.
+func (app *Application) websocketOrigins() []string {
+	u, err := url.Parse(app.Config.AppBaseURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	return []string{u.Host}
+}
+
+// unsubscribeSecret falls back to a random secret, which breaks the links sent before a restart.
+func (app *Application) unsubscribeSecret() string {
+	if app.Config.UnsubscribeSecret != "" {
+		return app.Config.UnsubscribeSecret
+	}
+	log.Println("UNSUBSCRIBE_SECRET is not set, unsubscribe links won't survive a restart")
+	secret, err := common.NewRandomToken()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return secret
+}
+
+func (app *Application) passwordPolicy() org.PasswordPolicy {
+	return org.PasswordPolicy{
+		MinLength:     app.Config.PasswordMinLength,
+		RequireUpper:  app.Config.PasswordRequireUpper,
+		RequireLower:  app.Config.PasswordRequireLower,
+		RequireDigit:  app.Config.PasswordRequireDigit,
+		RequireSymbol: app.Config.PasswordRequireSymbol,
+	}
 }
 
 func (app *Application) Routes() http.Handler {
@@ -50,6 +214,11 @@ func (app *Application) Routes() http.Handler {
 }
 
 func (app *Application) Run(addr string) {
+	handler := app.Routes()
+	for _, worker := range app.workers {
+		go worker(context.Background())
+	}
+
 	fmt.Printf("Starting server on %s\n", addr)
-	log.Fatal(http.ListenAndServe(addr, app.Routes()))
+	log.Fatal(http.ListenAndServe(addr, handler))
 }
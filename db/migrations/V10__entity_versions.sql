@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE teams ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE memberships ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE boards ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE tags ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE items ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE comments ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE password_reset_tokens ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE personal_access_tokens ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE sessions ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE login_challenges ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE two_factor_secrets ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE recovery_codes ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE two_factor_requirements ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE data_requests ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE item_activities ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,17 @@
+CREATE TABLE "idempotency_keys" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    scope VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    response_headers JSONB NOT NULL DEFAULT '{}',
+    response_body BYTEA,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    CONSTRAINT idx_idempotency_scope_key UNIQUE (scope, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
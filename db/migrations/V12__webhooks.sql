@@ -0,0 +1,47 @@
+CREATE TABLE "outbox_messages" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    team_id UUID NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages(created_at) WHERE dispatched_at IS NULL;
+
+CREATE TABLE "webhooks" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    events JSONB NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by_id UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_webhooks_team_id ON webhooks(team_id);
+
+CREATE TABLE "webhook_deliveries" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_attempt_at TIMESTAMP,
+    response_status INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
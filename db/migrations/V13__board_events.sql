@@ -0,0 +1,20 @@
+ALTER TABLE outbox_messages
+    ADD COLUMN board_id UUID,
+    ADD COLUMN seq BIGSERIAL;
+
+CREATE UNIQUE INDEX idx_outbox_messages_seq ON outbox_messages(seq);
+CREATE INDEX idx_outbox_messages_board_seq ON outbox_messages(board_id, seq) WHERE board_id IS NOT NULL;
+
+-- NOTIFY is only delivered on commit, so listeners never see rolled back changes.
+CREATE FUNCTION notify_board_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('board_events', NEW.board_id::text || ':' || NEW.seq);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_messages_notify_board
+    AFTER INSERT ON outbox_messages
+    FOR EACH ROW
+    WHEN (NEW.board_id IS NOT NULL)
+    EXECUTE FUNCTION notify_board_event();
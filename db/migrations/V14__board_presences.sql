@@ -0,0 +1,11 @@
+CREATE UNLOGGED TABLE "board_presences" (
+    id UUID PRIMARY KEY,
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    item_id UUID,
+    seen_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_board_presences_board_id ON board_presences(board_id);
+CREATE INDEX idx_board_presences_seen_at ON board_presences(seen_at);
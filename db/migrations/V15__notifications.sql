@@ -0,0 +1,27 @@
+CREATE TABLE "notifications" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL CHECK (type IN ('item_assigned', 'mentioned', 'team_added', 'item_due_soon')),
+    title VARCHAR(255) NOT NULL,
+    actor_id UUID,
+    team_id UUID,
+    board_id UUID,
+    item_id UUID,
+    comment_id UUID,
+    dedup_key VARCHAR(255),
+    read_at TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+CREATE UNIQUE INDEX idx_notifications_dedup_key ON notifications(user_id, dedup_key);
+
+CREATE TABLE "notification_preferences" (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL CHECK (type IN ('item_assigned', 'mentioned', 'team_added', 'item_due_soon')),
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
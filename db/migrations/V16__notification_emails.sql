@@ -0,0 +1,9 @@
+ALTER TABLE "notifications" ADD COLUMN email_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_notifications_email_pending ON notifications(user_id) WHERE email_pending;
+
+CREATE TABLE "notification_email_settings" (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    delivery VARCHAR(20) NOT NULL CHECK (delivery IN ('immediate', 'hourly', 'daily', 'off')),
+    last_digest_at TIMESTAMP
+);
@@ -0,0 +1,15 @@
+CREATE TABLE "mentions" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id UUID REFERENCES items(id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    handle VARCHAR(50) NOT NULL,
+    CHECK ((item_id IS NULL) <> (comment_id IS NULL))
+);
+
+CREATE INDEX idx_mentions_user_id ON mentions(user_id);
+CREATE INDEX idx_mentions_item_id ON mentions(item_id);
+CREATE INDEX idx_mentions_comment_id ON mentions(comment_id);
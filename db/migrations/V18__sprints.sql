@@ -0,0 +1,35 @@
+CREATE TABLE "sprints" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    board_id UUID NOT NULL REFERENCES boards(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    goal TEXT,
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP NOT NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'planned' CHECK (state IN ('planned', 'active', 'closed')),
+    started_at TIMESTAMP,
+    closed_at TIMESTAMP,
+    CHECK (end_date > start_date)
+);
+
+CREATE INDEX idx_sprints_board_id ON sprints(board_id);
+-- A board can't have two active sprints, even if two starts race.
+CREATE UNIQUE INDEX idx_sprints_active ON sprints(board_id) WHERE state = 'active';
+
+ALTER TABLE "items" ADD COLUMN sprint_id UUID REFERENCES sprints(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_items_sprint_id ON items(sprint_id);
+
+CREATE TABLE "sprint_reports" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    sprint_id UUID NOT NULL UNIQUE REFERENCES sprints(id) ON DELETE CASCADE,
+    completed_items INT NOT NULL,
+    incomplete_items INT NOT NULL,
+    carried_over_to UUID REFERENCES sprints(id) ON DELETE SET NULL,
+    items JSONB NOT NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE boards ADD COLUMN estimate_unit VARCHAR(10) NOT NULL DEFAULT 'points'
+    CHECK (estimate_unit IN ('points', 'hours'));
+
+ALTER TABLE items ADD COLUMN estimate DOUBLE PRECISION CHECK (estimate >= 0);
@@ -0,0 +1,7 @@
+ALTER TABLE items ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'task'
+    CHECK (type IN ('epic', 'story', 'task', 'bug', 'subtask'));
+
+-- Deleting a parent leaves its children at the top level.
+ALTER TABLE items ADD COLUMN parent_id UUID REFERENCES items(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX idx_items_parent_id ON items(parent_id);
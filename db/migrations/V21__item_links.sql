@@ -0,0 +1,13 @@
+CREATE TABLE "item_links" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version INT NOT NULL DEFAULT 1,
+    source_id UUID NOT NULL REFERENCES items(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES items(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('blocks', 'relates_to', 'duplicates')),
+    CHECK (source_id <> target_id)
+);
+
+CREATE UNIQUE INDEX idx_item_link ON item_links(source_id, target_id, type);
+CREATE INDEX idx_item_links_target_id ON item_links(target_id);
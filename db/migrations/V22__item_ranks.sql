@@ -0,0 +1,5 @@
+-- Ranks are compared byte by byte, so the column mustn't use a locale collation.
+-- Existing items start out unranked and get ranked by the respacing job.
+ALTER TABLE "items" ADD COLUMN rank TEXT COLLATE "C" NOT NULL DEFAULT '';
+
+CREATE INDEX idx_items_board_status_rank ON items(board_id, status, rank);
@@ -0,0 +1 @@
+ALTER TABLE boards ADD COLUMN wip_limits JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,33 @@
+-- seq is taken on insert but NOTIFY fires on commit, so two transactions on a board can publish
+-- their events out of seq order. board_seq comes from a per-board counter row instead, which
+-- stays locked until the transaction ends, so a board's events commit in board_seq order.
+CREATE TABLE board_event_seqs (
+    board_id UUID PRIMARY KEY,
+    seq      BIGINT NOT NULL
+);
+
+ALTER TABLE outbox_messages ADD COLUMN board_seq BIGINT;
+
+-- Existing events keep their ids, so clients resuming with Last-Event-ID still line up.
+UPDATE outbox_messages SET board_seq = seq WHERE board_id IS NOT NULL;
+
+INSERT INTO board_event_seqs (board_id, seq)
+SELECT board_id, MAX(board_seq) FROM outbox_messages WHERE board_id IS NOT NULL GROUP BY board_id;
+
+DROP INDEX idx_outbox_messages_board_seq;
+CREATE UNIQUE INDEX idx_outbox_messages_board_seq ON outbox_messages(board_id, board_seq) WHERE board_id IS NOT NULL;
+
+CREATE FUNCTION assign_board_seq() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO board_event_seqs (board_id, seq) VALUES (NEW.board_id, 1)
+    ON CONFLICT (board_id) DO UPDATE SET seq = board_event_seqs.seq + 1
+    RETURNING seq INTO NEW.board_seq;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_messages_board_seq
+    BEFORE INSERT ON outbox_messages
+    FOR EACH ROW
+    WHEN (NEW.board_id IS NOT NULL)
+    EXECUTE FUNCTION assign_board_seq();
@@ -0,0 +1,9 @@
+CREATE TABLE "login_throttles" (
+    subject VARCHAR(100) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_throttles_locked_until ON login_throttles(locked_until);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP;
+
+CREATE INDEX idx_users_deactivated_at ON users(deactivated_at);
@@ -0,0 +1,13 @@
+CREATE TABLE "data_requests" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL,
+    requested_by_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('export', 'erasure')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed')),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_data_requests_user_id ON data_requests(user_id);
+CREATE INDEX idx_data_requests_pending ON data_requests(created_at) WHERE status = 'pending';
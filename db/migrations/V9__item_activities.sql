@@ -0,0 +1,12 @@
+CREATE TABLE "item_activities" (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    actor_id UUID,
+    field VARCHAR(30) NOT NULL,
+    old_value JSONB NOT NULL,
+    new_value JSONB NOT NULL
+);
+
+CREATE INDEX idx_item_activities_item_id ON item_activities(item_id, created_at);
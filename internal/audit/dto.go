@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   *uuid.UUID
+	From       *time.Time
+	To         *time.Time
+}
+
+type EventResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	ActorID    *uuid.UUID      `json:"actorId"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   uuid.UUID       `json:"targetId"`
+	Changes    json.RawMessage `json:"changes"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"requestId"`
+}
+
+func ToEventResponse(e *Event) *EventResponse {
+	return &EventResponse{
+		ID:         e.ID,
+		CreatedAt:  e.CreatedAt,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Changes:    json.RawMessage(e.Changes),
+		IP:         e.IP,
+		RequestID:  e.RequestID,
+	}
+}
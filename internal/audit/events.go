@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Actor identifies who caused a change and where the request came from.
+// Background jobs leave IP and RequestID empty.
+type Actor struct {
+	UserID    *uuid.UUID
+	IP        string
+	RequestID string
+}
+
+func ActorFromRequest(r *http.Request) Actor {
+	actor := Actor{
+		IP:        common.ClientIP(r),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	if p, ok := common.PrincipalFromContext(r.Context()); ok {
+		actor.UserID = &p.UserID
+	}
+	return actor
+}
+
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Redacted stands in for the values of personal fields in the log.
+const Redacted = "[redacted]"
+
+// Redacter is implemented by snapshots holding personal data, such as names and emails. The log
+// is append-only, so erasing a user couldn't remove it later. Events only record that such a
+// field changed, never its values.
+type Redacter interface {
+	RedactedFields() []string
+}
+
+// NewEvent builds an event whose changes hold the fields that differ between the before and after
+// snapshots. Either snapshot may be nil for creations and deletions. Snapshots should be response
+// DTOs, so secrets such as password hashes never end up in the log.
+func NewEvent(actor Actor, action, targetType string, targetID uuid.UUID, before, after any) (*Event, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+	redact(changes, before, after)
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ActorID:    actor.UserID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    string(encoded),
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+	}, nil
+}
+
+func Diff(before, after any) (map[string]Change, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, value := range b {
+		if !reflect.DeepEqual(value, a[key]) {
+			changes[key] = Change{Before: value, After: a[key]}
+		}
+	}
+	for key, value := range a {
+		if _, ok := b[key]; !ok {
+			changes[key] = Change{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func redact(changes map[string]Change, snapshots ...any) {
+	for _, s := range snapshots {
+		r, ok := s.(Redacter)
+		if !ok || s == nil || reflect.ValueOf(s).Kind() == reflect.Pointer && reflect.ValueOf(s).IsNil() {
+			continue
+		}
+		for _, field := range r.RedactedFields() {
+			change, ok := changes[field]
+			if !ok {
+				continue
+			}
+			if change.Before != nil {
+				change.Before = Redacted
+			}
+			if change.After != nil {
+				change.After = Redacted
+			}
+			changes[field] = change
+		}
+	}
+}
+
+func toFields(v any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Record writes the event with the given transaction, so it's only kept if the change it describes is.
+func Record(tx *gorm.DB, event *Event) error {
+	if event == nil {
+		return nil
+	}
+	return tx.Create(event).Error
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+)
+
+type AuditHandler struct {
+	Service *AuditService
+}
+
+func NewAuditHandler(service *AuditService) *AuditHandler {
+	return &AuditHandler{Service: service}
+}
+
+func parseFilter(r *http.Request) (EventFilter, error) {
+	q := r.URL.Query()
+	filter := EventFilter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("targetType"),
+	}
+
+	if v := q.Get("actorId"); v != "" {
+		id, err := common.ParseUUID(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.ActorID = &id
+	}
+
+	if v := q.Get("targetId"); v != "" {
+		id, err := common.ParseUUID(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.TargetID = &id
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, common.BadRequest("Parameter " + param + " must be an RFC 3339 timestamp!")
+			}
+			*dst = &t
+		}
+	}
+
+	return filter, nil
+}
+
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if size <= 0 {
+		size = 10
+	}
+
+	resp, err := h.Service.List(filter, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"gollab-audit-events.csv\"")
+	w.WriteHeader(http.StatusOK)
+	_ = h.Service.ExportCSV(w, filter)
+}
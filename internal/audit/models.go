@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	UserCreated         = "user.created"
+	UserUpdated         = "user.updated"
+	UserPasswordChanged = "user.password_changed"
+	UserPasswordReset   = "user.password_reset"
+	UserDeactivated     = "user.deactivated"
+	UserReactivated     = "user.reactivated"
+	UserPurged          = "user.purged"
+	UserErased          = "user.erased"
+	TeamCreated         = "team.created"
+	TeamUpdated         = "team.updated"
+	TeamDeleted         = "team.deleted"
+	MemberAdded         = "team.member_added"
+	MemberRemoved       = "team.member_removed"
+	BoardCreated        = "board.created"
+	BoardUpdated        = "board.updated"
+	BoardDeleted        = "board.deleted"
+	ItemCreated         = "item.created"
+	ItemUpdated         = "item.updated"
+	ItemDeleted         = "item.deleted"
+	ItemLinked          = "item.linked"
+	ItemUnlinked        = "item.unlinked"
+	SprintCreated       = "sprint.created"
+	SprintStarted       = "sprint.started"
+	SprintCompleted     = "sprint.completed"
+)
+
+const (
+	TargetUser   = "user"
+	TargetTeam   = "team"
+	TargetBoard  = "board"
+	TargetItem   = "item"
+	TargetSprint = "sprint"
+)
+
+// Event is an append-only record of a change. The table rejects updates and deletes,
+// so it has no updated_at and doesn't reference the rows it describes.
+type Event struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CreatedAt  time.Time  `gorm:"not null;index"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index"`
+	Action     string     `gorm:"type:varchar(50);not null;index"`
+	TargetType string     `gorm:"type:varchar(30);not null"`
+	TargetID   uuid.UUID  `gorm:"type:uuid;not null"`
+	Changes    string     `gorm:"type:jsonb;not null;default:'{}'"`
+	IP         string     `gorm:"type:varchar(45)"`
+	RequestID  string     `gorm:"type:varchar(100)"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+func (e *Event) BeforeCreate(*gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,14 @@
+package audit
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func AuditRoutes(r chi.Router, handler *AuditHandler) {
+	r.Route("/audit/events", func(r chi.Router) {
+		r.Use(common.RequireAdmin)
+		r.Get("/", handler.List)
+		r.Get("/export", handler.Export)
+	})
+}
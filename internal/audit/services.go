@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+)
+
+// exportBatchSize is the number of events an export loads at a time.
+const exportBatchSize = 500
+
+type AuditService struct {
+	Repo EventRepository
+}
+
+func NewAuditService(repo EventRepository) *AuditService {
+	return &AuditService{Repo: repo}
+}
+
+func (s *AuditService) List(filter EventFilter, page, size int) (*common.PaginatedResponse[EventResponse], error) {
+	offset := (page - 1) * size
+	events, total, err := s.Repo.List(filter, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]EventResponse, 0, len(events))
+	for _, e := range events {
+		res = append(res, *ToEventResponse(&e))
+	}
+
+	return &common.PaginatedResponse[EventResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+func (s *AuditService) ExportCSV(w io.Writer, filter EventFilter) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"id", "created_at", "actor_id", "action", "target_type", "target_id", "changes", "ip", "request_id",
+	}); err != nil {
+		return err
+	}
+
+	var last *Event
+	for {
+		events, err := s.Repo.ListAfter(filter, last, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			actorID := ""
+			if e.ActorID != nil {
+				actorID = e.ActorID.String()
+			}
+			if err := cw.Write([]string{
+				e.ID.String(),
+				e.CreatedAt.UTC().Format(time.RFC3339),
+				actorID,
+				e.Action,
+				e.TargetType,
+				e.TargetID.String(),
+				e.Changes,
+				e.IP,
+				e.RequestID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < exportBatchSize {
+			break
+		}
+		last = &events[len(events)-1]
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type eventRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *eventRepositoryMock) List(filter EventFilter, offset, limit int) ([]Event, int, error) {
+	args := m.Called(filter, offset, limit)
+	return args.Get(0).([]Event), args.Int(1), args.Error(2)
+}
+
+// ListAfter pages through the events the mock was set up with, which are in export order.
+func (m *eventRepositoryMock) ListAfter(filter EventFilter, after *Event, limit int) ([]Event, error) {
+	args := m.Called(filter)
+	events := args.Get(0).([]Event)
+	start := 0
+	if after != nil {
+		start = slices.IndexFunc(events, func(e Event) bool { return e.ID == after.ID }) + 1
+	}
+	return events[start:min(start+limit, len(events))], args.Error(1)
+}
+
+type snapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(&snapshot{Name: "Old"}, &snapshot{Name: "New", Description: "Added"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Change{
+		"name":        {Before: "Old", After: "New"},
+		"description": {After: "Added"},
+	}, changes)
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	changes, err := Diff(&snapshot{Name: "Same"}, &snapshot{Name: "Same"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+type personSnapshot struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func (*personSnapshot) RedactedFields() []string {
+	return []string{"email"}
+}
+
+func TestNewEvent_RedactsPersonalFields(t *testing.T) {
+	before := &personSnapshot{Email: "old@test.com", Role: "standard"}
+	after := &personSnapshot{Email: "new@test.com", Role: "admin"}
+
+	event, err := NewEvent(Actor{}, UserUpdated, TargetUser, uuid.New(), before, after)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"email": {"before": "[redacted]", "after": "[redacted]"},
+		"role": {"before": "standard", "after": "admin"}
+	}`, event.Changes)
+	assert.NotContains(t, event.Changes, "test.com")
+
+	event, err = NewEvent(Actor{}, UserCreated, TargetUser, uuid.New(), nil, after)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"email": {"before": null, "after": "[redacted]"},
+		"role": {"before": null, "after": "admin"}
+	}`, event.Changes)
+}
+
+func TestNewEvent_Deletion(t *testing.T) {
+	actorID, targetID := uuid.New(), uuid.New()
+	actor := Actor{UserID: &actorID, IP: "10.0.0.1", RequestID: "req-1"}
+
+	event, err := NewEvent(actor, TeamDeleted, TargetTeam, targetID, &snapshot{Name: "Team"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &actorID, event.ActorID)
+	assert.Equal(t, TeamDeleted, event.Action)
+	assert.Equal(t, targetID, event.TargetID)
+	assert.Equal(t, "10.0.0.1", event.IP)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.JSONEq(t, `{"name":{"before":"Team","after":null}}`, event.Changes)
+}
+
+func TestAuditService_List(t *testing.T) {
+	repo := &eventRepositoryMock{}
+	service := NewAuditService(repo)
+	filter := EventFilter{Action: MemberRemoved}
+	repo.On("List", filter, 10, 10).Return([]Event{{Action: MemberRemoved, Changes: `{}`}}, 11, nil)
+
+	resp, err := service.List(filter, 2, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 11, resp.Total)
+	assert.Equal(t, MemberRemoved, resp.Items[0].Action)
+	assert.Equal(t, json.RawMessage(`{}`), resp.Items[0].Changes)
+}
+
+func TestAuditService_ExportCSV(t *testing.T) {
+	repo := &eventRepositoryMock{}
+	service := NewAuditService(repo)
+	actorID := uuid.New()
+	events := []Event{
+		{ID: uuid.New(), CreatedAt: time.Now(), ActorID: &actorID, Action: TeamDeleted, TargetType: TargetTeam, Changes: `{}`},
+		{ID: uuid.New(), CreatedAt: time.Now(), Action: UserCreated, TargetType: TargetUser, Changes: `{}`},
+	}
+	repo.On("ListAfter", EventFilter{}).Return(events, nil)
+	var buf bytes.Buffer
+
+	err := service.ExportCSV(&buf, EventFilter{})
+
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "action", rows[0][3])
+	assert.Equal(t, actorID.String(), rows[1][2])
+	assert.Equal(t, TeamDeleted, rows[1][3])
+	assert.Empty(t, rows[2][2])
+}
+
+func TestAuditService_ExportCSV_SeveralBatches(t *testing.T) {
+	repo := &eventRepositoryMock{}
+	service := NewAuditService(repo)
+	start := time.Now()
+	events := make([]Event, 2*exportBatchSize+1)
+	for i := range events {
+		events[i] = Event{ID: uuid.New(), CreatedAt: start.Add(time.Duration(i) * time.Second), Action: TeamUpdated, Changes: `{}`}
+	}
+	repo.On("ListAfter", EventFilter{}).Return(events, nil)
+	var buf bytes.Buffer
+
+	err := service.ExportCSV(&buf, EventFilter{})
+
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, len(events)+1)
+	for i, e := range events {
+		assert.Equal(t, e.ID.String(), rows[i+1][0])
+	}
+	repo.AssertNumberOfCalls(t, "ListAfter", 3)
+}
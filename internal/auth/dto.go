@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateTokenRequest struct {
+	Name      string     `json:"name" validate:"required,min=2,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=users:read users:write teams:read teams:write items:read items:write admin"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type TokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func ToTokenResponse(token *PersonalAccessToken) *TokenResponse {
+	return &TokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     strings.Fields(token.Scopes),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+type CreatedTokenResponse struct {
+	TokenResponse
+	Token string `json:"token"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+}
+
+type LoginResponse struct {
+	Token                  string     `json:"token,omitempty"`
+	ExpiresAt              *time.Time `json:"expiresAt,omitempty"`
+	ChallengeToken         string     `json:"challengeToken,omitempty"`
+	ChallengeExpiresAt     *time.Time `json:"challengeExpiresAt,omitempty"`
+	TwoFactorRequired      bool       `json:"twoFactorRequired"`
+	TwoFactorSetupRequired bool       `json:"twoFactorSetupRequired"`
+	RecoveryCodes          []string   `json:"recoveryCodes,omitempty"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	Codes []string `json:"codes"`
+}
+
+type CreateTwoFactorRequirementRequest struct {
+	Target RequirementTarget `json:"target" validate:"required,oneof=admins team"`
+	TeamID *uuid.UUID        `json:"teamId" validate:"required_if=Target team,excluded_if=Target admins"`
+}
+
+type TwoFactorRequirementResponse struct {
+	ID     uuid.UUID         `json:"id"`
+	Target RequirementTarget `json:"target"`
+	TeamID *uuid.UUID        `json:"teamId"`
+}
+
+func ToTwoFactorRequirementResponse(req *TwoFactorRequirement) *TwoFactorRequirementResponse {
+	return &TwoFactorRequirementResponse{
+		ID:     req.ID,
+		Target: req.Target,
+		TeamID: req.TeamID,
+	}
+}
+
+type LockoutResponse struct {
+	UserID      uuid.UUID `json:"userId"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
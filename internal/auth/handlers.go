@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+type TokenHandler struct {
+	Service *TokenService
+}
+
+func NewTokenHandler(service *TokenService) *TokenHandler {
+	return &TokenHandler{Service: service}
+}
+
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.Create(principal, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.PrincipalFromContext(r.Context())
+
+	resp, err := h.Service.List(principal.UserID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "tokenId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	if err := h.Service.Revoke(principal.UserID, id); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type SessionHandler struct {
+	Service *SessionService
+}
+
+func NewSessionHandler(service *SessionService) *SessionHandler {
+	return &SessionHandler{Service: service}
+}
+
+func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.Login(req, common.ClientIP(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SessionHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req ChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.SetupTwoFactor(req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SessionHandler) CompleteTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.CompleteTwoFactor(req, common.ClientIP(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.Logout(bearerToken(r)); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type TwoFactorHandler struct {
+	Service *TwoFactorService
+}
+
+func NewTwoFactorHandler(service *TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{Service: service}
+}
+
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.PrincipalFromContext(r.Context())
+
+	resp, err := h.Service.Enroll(principal.UserID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.Verify(principal.UserID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	var req DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	if err := h.Service.Disable(principal.UserID, common.ClientIP(r), req); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.RegenerateRecoveryCodes(principal.UserID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *TwoFactorHandler) ListRequirements(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.Service.ListRequirements()
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *TwoFactorHandler) CreateRequirement(w http.ResponseWriter, r *http.Request) {
+	var req CreateTwoFactorRequirementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.CreateRequirement(req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *TwoFactorHandler) DeleteRequirement(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "requirementId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.DeleteRequirement(id); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type LockoutHandler struct {
+	Service *LockoutService
+}
+
+func NewLockoutHandler(service *LockoutService) *LockoutHandler {
+	return &LockoutHandler{Service: service}
+}
+
+func (h *LockoutHandler) List(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.Service.ListLocked()
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *LockoutHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.Unlock(id); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
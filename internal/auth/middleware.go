@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+)
+
+// Middleware resolves the Bearer token, if any, into a common.Principal on the request context.
+// Both session tokens and personal access tokens are accepted.
+func Middleware(sessions *SessionService, tokens *TokenService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			raw, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				common.WriteError(w, common.Unauthorized("Invalid authorization header!"))
+				return
+			}
+
+			var principal *common.Principal
+			var err error
+			switch {
+			case strings.HasPrefix(raw, SessionTokenPrefix):
+				principal, err = sessions.Authenticate(raw)
+			case strings.HasPrefix(raw, TokenPrefix):
+				principal, err = tokens.Authenticate(raw)
+			default:
+				err = common.Unauthorized("Invalid authorization header!")
+			}
+			if err != nil {
+				common.WriteError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(common.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	raw, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return raw
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/google/uuid"
+)
+
+type PersonalAccessToken struct {
+	common.BaseEntity
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	User       org.User  `gorm:"foreignKey:UserID"`
+	Name       string    `gorm:"type:varchar(100);not null"`
+	Prefix     string    `gorm:"type:varchar(12);not null"`
+	TokenHash  string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Scopes     string    `gorm:"type:varchar(255);not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+type Session struct {
+	common.BaseEntity
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	User      org.User  `gorm:"foreignKey:UserID"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+type LoginChallenge struct {
+	common.BaseEntity
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	User      org.User  `gorm:"foreignKey:UserID"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+type TwoFactorSecret struct {
+	common.BaseEntity
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	User         org.User  `gorm:"foreignKey:UserID"`
+	Secret       string    `gorm:"type:varchar(64);not null"`
+	EnabledAt    *time.Time
+	LastUsedStep int64 `gorm:"not null;default:0"`
+}
+
+type RecoveryCode struct {
+	common.BaseEntity
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	User     org.User  `gorm:"foreignKey:UserID"`
+	CodeHash string    `gorm:"type:varchar(64);not null"`
+	UsedAt   *time.Time
+}
+
+type RequirementTarget string
+
+const (
+	AdminsTarget RequirementTarget = "admins"
+	TeamTarget   RequirementTarget = "team"
+)
+
+type TwoFactorRequirement struct {
+	common.BaseEntity
+	Target RequirementTarget `gorm:"type:varchar(20);not null;check:target IN ('admins', 'team')"`
+	TeamID *uuid.UUID        `gorm:"type:uuid;uniqueIndex"`
+	Team   *org.Team         `gorm:"foreignKey:TeamID"`
+}
+
+type LoginThrottle struct {
+	Subject       string    `gorm:"type:varchar(100);primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time
+}
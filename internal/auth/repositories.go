@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepository interface {
+	Create(token *PersonalAccessToken) error
+	GetByTokenHash(hash string) (*PersonalAccessToken, error)
+	ListByUserID(userID uuid.UUID) ([]PersonalAccessToken, error)
+	DeleteByIDAndUserID(id, userID uuid.UUID) (bool, error)
+	UpdateLastUsed(id uuid.UUID, at time.Time) error
+}
+
+type tokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{DB: db}
+}
+
+func (r *tokenRepository) Create(token *PersonalAccessToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *tokenRepository) GetByTokenHash(hash string) (*PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	if err := r.DB.Preload("User").First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) ListByUserID(userID uuid.UUID) ([]PersonalAccessToken, error) {
+	var tokens []PersonalAccessToken
+	err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *tokenRepository) DeleteByIDAndUserID(id, userID uuid.UUID) (bool, error) {
+	res := r.DB.Delete(&PersonalAccessToken{}, "id = ? AND user_id = ?", id, userID)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *tokenRepository) UpdateLastUsed(id uuid.UUID, at time.Time) error {
+	return r.DB.Model(&PersonalAccessToken{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+type SessionRepository interface {
+	Create(session *Session) error
+	GetByTokenHash(hash string) (*Session, error)
+	DeleteByTokenHash(hash string) error
+	CreateChallenge(challenge *LoginChallenge) error
+	GetChallengeByTokenHash(hash string) (*LoginChallenge, error)
+	DeleteChallengeByID(id uuid.UUID) (bool, error)
+}
+
+type sessionRepository struct {
+	DB *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{DB: db}
+}
+
+func (r *sessionRepository) Create(session *Session) error {
+	return r.DB.Create(session).Error
+}
+
+func (r *sessionRepository) GetByTokenHash(hash string) (*Session, error) {
+	var session Session
+	if err := r.DB.Preload("User").First(&session, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) DeleteByTokenHash(hash string) error {
+	return r.DB.Delete(&Session{}, "token_hash = ?", hash).Error
+}
+
+func (r *sessionRepository) CreateChallenge(challenge *LoginChallenge) error {
+	return r.DB.Create(challenge).Error
+}
+
+func (r *sessionRepository) GetChallengeByTokenHash(hash string) (*LoginChallenge, error) {
+	var challenge LoginChallenge
+	if err := r.DB.First(&challenge, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *sessionRepository) DeleteChallengeByID(id uuid.UUID) (bool, error) {
+	res := r.DB.Delete(&LoginChallenge{}, "id = ?", id)
+	return res.RowsAffected > 0, res.Error
+}
+
+type TwoFactorRepository interface {
+	GetSecret(userID uuid.UUID) (*TwoFactorSecret, error)
+	SaveSecret(secret *TwoFactorSecret) error
+	Enable(secret *TwoFactorSecret, codes []RecoveryCode) error
+	Disable(userID uuid.UUID) error
+	ReplaceRecoveryCodes(userID uuid.UUID, codes []RecoveryCode) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	UpdateLastUsedStep(id uuid.UUID, step int64) (bool, error)
+	IsRequired(userID uuid.UUID, isAdmin bool) (bool, error)
+	ListRequirements() ([]TwoFactorRequirement, error)
+	CreateRequirement(req *TwoFactorRequirement) error
+	DeleteRequirementByID(id uuid.UUID) (bool, error)
+}
+
+type twoFactorRepository struct {
+	DB *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{DB: db}
+}
+
+func (r *twoFactorRepository) GetSecret(userID uuid.UUID) (*TwoFactorSecret, error) {
+	var secret TwoFactorSecret
+	if err := r.DB.First(&secret, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (r *twoFactorRepository) SaveSecret(secret *TwoFactorSecret) error {
+	return r.DB.Save(secret).Error
+}
+
+func (r *twoFactorRepository) Enable(secret *TwoFactorSecret, codes []RecoveryCode) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(secret).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, secret.UserID, codes)
+	})
+}
+
+func (r *twoFactorRepository) Disable(userID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&TwoFactorSecret{}, "user_id = ?", userID).Error
+	})
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(userID uuid.UUID, codes []RecoveryCode) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, codes []RecoveryCode) error {
+	if err := tx.Delete(&RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+	return tx.Create(&codes).Error
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	res := r.DB.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+// UpdateLastUsedStep only moves the step forward, so a code can't be replayed on another replica.
+func (r *twoFactorRepository) UpdateLastUsedStep(id uuid.UUID, step int64) (bool, error) {
+	res := r.DB.Model(&TwoFactorSecret{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *twoFactorRepository) IsRequired(userID uuid.UUID, isAdmin bool) (bool, error) {
+	var count int64
+	err := r.DB.Model(&TwoFactorRequirement{}).
+		Where("(target = ? AND ?) OR team_id IN (?)",
+			AdminsTarget, isAdmin,
+			r.DB.Table("memberships").Select("team_id").Where("user_id = ?", userID),
+		).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *twoFactorRepository) ListRequirements() ([]TwoFactorRequirement, error) {
+	var reqs []TwoFactorRequirement
+	err := r.DB.Order("created_at").Find(&reqs).Error
+	return reqs, err
+}
+
+func (r *twoFactorRepository) CreateRequirement(req *TwoFactorRequirement) error {
+	return r.DB.Create(req).Error
+}
+
+func (r *twoFactorRepository) DeleteRequirementByID(id uuid.UUID) (bool, error) {
+	res := r.DB.Delete(&TwoFactorRequirement{}, "id = ?", id)
+	return res.RowsAffected > 0, res.Error
+}
+
+type LoginThrottleRepository interface {
+	Get(subject string) (*LoginThrottle, error)
+	Update(subject string, apply func(t *LoginThrottle)) error
+	Delete(subject string) error
+	ListLocked(subjectPrefix string, now time.Time) ([]LoginThrottle, error)
+}
+
+type loginThrottleRepository struct {
+	DB *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{DB: db}
+}
+
+func (r *loginThrottleRepository) Get(subject string) (*LoginThrottle, error) {
+	var t LoginThrottle
+	if err := r.DB.First(&t, "subject = ?", subject).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Update applies changes under a row lock, so concurrent failures on different replicas are all counted.
+func (r *loginThrottleRepository) Update(subject string, apply func(t *LoginThrottle)) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&LoginThrottle{Subject: subject}).Error; err != nil {
+			return err
+		}
+
+		var t LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&t, "subject = ?", subject).Error; err != nil {
+			return err
+		}
+
+		apply(&t)
+		return tx.Save(&t).Error
+	})
+}
+
+func (r *loginThrottleRepository) Delete(subject string) error {
+	return r.DB.Delete(&LoginThrottle{}, "subject = ?", subject).Error
+}
+
+func (r *loginThrottleRepository) ListLocked(subjectPrefix string, now time.Time) ([]LoginThrottle, error) {
+	var throttles []LoginThrottle
+	err := r.DB.
+		Where("subject LIKE ? AND locked_until > ?", subjectPrefix+"%", now).
+		Order("locked_until").
+		Find(&throttles).Error
+	return throttles, err
+}
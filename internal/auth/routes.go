@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func TokenRoutes(r chi.Router, handler *TokenHandler) {
+	r.Route("/auth/tokens", func(r chi.Router) {
+		r.Use(common.RequireAuth)
+		r.Post("/", handler.Create)
+		r.Get("/", handler.List)
+		r.Delete("/{tokenId}", handler.Revoke)
+	})
+}
+
+func SessionRoutes(r chi.Router, handler *SessionHandler) {
+	r.Route("/auth/login", func(r chi.Router) {
+		r.Post("/", handler.Login)
+		r.Post("/2fa", handler.CompleteTwoFactor)
+		r.Post("/2fa/setup", handler.SetupTwoFactor)
+	})
+	r.With(common.RequireAuth).Post("/auth/logout", handler.Logout)
+}
+
+func TwoFactorRoutes(r chi.Router, handler *TwoFactorHandler) {
+	r.Route("/auth/2fa", func(r chi.Router) {
+		r.Use(common.RequireAuth)
+		r.Post("/enroll", handler.Enroll)
+		r.Post("/verify", handler.Verify)
+		r.Post("/disable", handler.Disable)
+		r.Post("/recovery-codes", handler.RegenerateRecoveryCodes)
+
+		r.Route("/requirements", func(r chi.Router) {
+			r.Use(common.RequireAdmin)
+			r.Get("/", handler.ListRequirements)
+			r.Post("/", handler.CreateRequirement)
+			r.Delete("/{requirementId}", handler.DeleteRequirement)
+		})
+	})
+}
+
+func LockoutRoutes(r chi.Router, handler *LockoutHandler) {
+	r.Route("/auth/lockouts", func(r chi.Router) {
+		r.Use(common.RequireAdmin)
+		r.Get("/", handler.List)
+		r.Delete("/{userId}", handler.Unlock)
+	})
+}
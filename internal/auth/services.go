@@ -0,0 +1,777 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const TokenPrefix = "glp_"
+
+type TokenService struct {
+	Repo      TokenRepository
+	Validator *validator.Validate
+}
+
+func NewTokenService(repo TokenRepository, validator *validator.Validate) *TokenService {
+	return &TokenService{
+		Repo:      repo,
+		Validator: validator,
+	}
+}
+
+// Create issues a token for the signed-in user. Only interactive sessions can create tokens,
+// since they are the ones that went through the second factor, and a token mustn't be able
+// to mint others with more scopes.
+func (s *TokenService) Create(p *common.Principal, req CreateTokenRequest) (*CreatedTokenResponse, error) {
+	if p.SessionID == nil {
+		return nil, common.Forbidden("Access tokens can only be created from a signed-in session!")
+	}
+
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if slices.Contains(req.Scopes, common.ScopeAdmin) && !p.IsAdmin {
+		return nil, common.Forbidden("Only admins can create tokens with the admin scope!")
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return nil, common.BadRequest("Token expiry must be in the future!")
+	}
+
+	random, err := common.NewRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := TokenPrefix + random
+
+	token := &PersonalAccessToken{
+		UserID:    p.UserID,
+		Name:      req.Name,
+		Prefix:    raw[:len(TokenPrefix)+8],
+		TokenHash: common.HashToken(raw),
+		Scopes:    strings.Join(slices.Compact(slices.Sorted(slices.Values(req.Scopes))), " "),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.Repo.Create(token); err != nil {
+		return nil, err
+	}
+
+	return &CreatedTokenResponse{
+		TokenResponse: *ToTokenResponse(token),
+		Token:         raw,
+	}, nil
+}
+
+func (s *TokenService) List(userID uuid.UUID) ([]TokenResponse, error) {
+	tokens, err := s.Repo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]TokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		res = append(res, *ToTokenResponse(&t))
+	}
+	return res, nil
+}
+
+func (s *TokenService) Revoke(userID, tokenID uuid.UUID) error {
+	deleted, err := s.Repo.DeleteByIDAndUserID(tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return common.NotFound(fmt.Sprintf("Token with id %s was not found!", tokenID))
+	}
+	return nil
+}
+
+func (s *TokenService) Authenticate(raw string) (*common.Principal, error) {
+	token, err := s.Repo.GetByTokenHash(common.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.Unauthorized("Invalid access token!")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, common.Unauthorized("Access token has expired!")
+	}
+
+	if !token.User.IsActive() {
+		return nil, common.Unauthorized("Account is deactivated!")
+	}
+
+	if err := s.Repo.UpdateLastUsed(token.ID, now); err != nil {
+		return nil, err
+	}
+
+	return &common.Principal{
+		UserID:  token.UserID,
+		IsAdmin: token.User.Role == org.Admin,
+		Scopes:  strings.Fields(token.Scopes),
+	}, nil
+}
+
+const (
+	SessionTokenPrefix = "gls_"
+	recoveryCodeCount  = 10
+)
+
+type TwoFactorService struct {
+	Repo        TwoFactorRepository
+	UserService *org.UserService
+	TeamService *org.TeamService
+	Validator   *validator.Validate
+	Issuer      string
+}
+
+func NewTwoFactorService(
+	repo TwoFactorRepository,
+	userService *org.UserService,
+	teamService *org.TeamService,
+	validator *validator.Validate,
+	issuer string,
+) *TwoFactorService {
+	return &TwoFactorService{
+		Repo:        repo,
+		UserService: userService,
+		TeamService: teamService,
+		Validator:   validator,
+		Issuer:      issuer,
+	}
+}
+
+func (s *TwoFactorService) findSecret(userID uuid.UUID) (*TwoFactorSecret, error) {
+	secret, err := s.Repo.GetSecret(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (s *TwoFactorService) IsEnabled(userID uuid.UUID) (bool, error) {
+	secret, err := s.findSecret(userID)
+	if err != nil {
+		return false, err
+	}
+	return secret != nil && secret.EnabledAt != nil, nil
+}
+
+func (s *TwoFactorService) IsRequired(user *org.User) (bool, error) {
+	return s.Repo.IsRequired(user.ID, user.Role == org.Admin)
+}
+
+func (s *TwoFactorService) Enroll(userID uuid.UUID) (*TwoFactorEnrollmentResponse, error) {
+	user, err := s.UserService.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.findSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret != nil && secret.EnabledAt != nil {
+		return nil, common.Conflict("Two-factor authentication is already enabled!")
+	}
+
+	raw, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-enrolling before verification replaces the pending secret.
+	if secret == nil {
+		secret = &TwoFactorSecret{UserID: userID}
+	}
+	secret.Secret = raw
+	if err := s.Repo.SaveSecret(secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollmentResponse{
+		Secret:     raw,
+		OtpauthURI: totpURI(s.Issuer, user.Email, raw),
+	}, nil
+}
+
+func (s *TwoFactorService) Verify(userID uuid.UUID, req TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	secret, err := s.findSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, common.BadRequest("Two-factor enrolment was not started!")
+	}
+	if secret.EnabledAt != nil {
+		return nil, common.Conflict("Two-factor authentication is already enabled!")
+	}
+
+	step, ok := matchTOTP(secret.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, common.BadRequest("Invalid verification code!")
+	}
+
+	codes, models, err := newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	secret.EnabledAt = &now
+	secret.LastUsedStep = step
+	if err := s.Repo.Enable(secret, models); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{Codes: codes}, nil
+}
+
+// Check accepts either a current TOTP code or an unused recovery code.
+func (s *TwoFactorService) Check(userID uuid.UUID, code string) error {
+	secret, err := s.findSecret(userID)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.EnabledAt == nil {
+		return common.BadRequest("Two-factor authentication is not enabled!")
+	}
+
+	if step, ok := matchTOTP(secret.Secret, code, time.Now()); ok {
+		fresh, err := s.Repo.UpdateLastUsedStep(secret.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return common.Unauthorized("Verification code was already used!")
+		}
+		return nil
+	}
+
+	used, err := s.Repo.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return common.Unauthorized("Invalid verification code!")
+	}
+	return nil
+}
+
+func (s *TwoFactorService) Disable(userID uuid.UUID, ip string, req DisableTwoFactorRequest) error {
+	if err := s.Validator.Struct(req); err != nil {
+		return common.BadRequest(err.Error())
+	}
+
+	user, err := s.UserService.VerifyPassword(userID, req.Password, ip)
+	if err != nil {
+		return err
+	}
+
+	required, err := s.IsRequired(user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return common.Forbidden("Two-factor authentication is required for this account!")
+	}
+
+	if err := s.Check(userID, req.Code); err != nil {
+		return err
+	}
+
+	return s.Repo.Disable(userID)
+}
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, req TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if err := s.Check(userID, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, models, err := newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.ReplaceRecoveryCodes(userID, models); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{Codes: codes}, nil
+}
+
+func (s *TwoFactorService) ListRequirements() ([]TwoFactorRequirementResponse, error) {
+	reqs, err := s.Repo.ListRequirements()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]TwoFactorRequirementResponse, 0, len(reqs))
+	for _, r := range reqs {
+		res = append(res, *ToTwoFactorRequirementResponse(&r))
+	}
+	return res, nil
+}
+
+func (s *TwoFactorService) CreateRequirement(req CreateTwoFactorRequirementRequest) (*TwoFactorRequirementResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if req.Target == TeamTarget {
+		if _, err := s.TeamService.GetByID(*req.TeamID); err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := s.Repo.ListRequirements()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.Target == req.Target && (req.Target == AdminsTarget || *e.TeamID == *req.TeamID) {
+			return nil, common.Conflict("Two-factor requirement already exists!")
+		}
+	}
+
+	requirement := &TwoFactorRequirement{
+		Target: req.Target,
+		TeamID: req.TeamID,
+	}
+	if err := s.Repo.CreateRequirement(requirement); err != nil {
+		return nil, err
+	}
+
+	return ToTwoFactorRequirementResponse(requirement), nil
+}
+
+func (s *TwoFactorService) DeleteRequirement(id uuid.UUID) error {
+	deleted, err := s.Repo.DeleteRequirementByID(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return common.NotFound(fmt.Sprintf("Two-factor requirement with id %s was not found!", id))
+	}
+	return nil
+}
+
+func newRecoveryCodes(userID uuid.UUID) ([]string, []RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	models := make([]RecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(totpEncoding.EncodeToString(b))
+		code := encoded[:5] + "-" + encoded[5:10]
+		codes = append(codes, code)
+		models = append(models, RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+	return codes, models, nil
+}
+
+func hashRecoveryCode(code string) string {
+	return common.HashToken(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(code)), "-", ""))
+}
+
+type SessionService struct {
+	Repo         SessionRepository
+	UserService  *org.UserService
+	TwoFactor    *TwoFactorService
+	Lockout      *LockoutService
+	Validator    *validator.Validate
+	SessionTTL   time.Duration
+	ChallengeTTL time.Duration
+}
+
+func NewSessionService(
+	repo SessionRepository,
+	userService *org.UserService,
+	twoFactor *TwoFactorService,
+	lockout *LockoutService,
+	validator *validator.Validate,
+	sessionTTL time.Duration,
+	challengeTTL time.Duration,
+) *SessionService {
+	return &SessionService{
+		Repo:         repo,
+		UserService:  userService,
+		TwoFactor:    twoFactor,
+		Lockout:      lockout,
+		Validator:    validator,
+		SessionTTL:   sessionTTL,
+		ChallengeTTL: challengeTTL,
+	}
+}
+
+func (s *SessionService) Login(req LoginRequest, ip string) (*LoginResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	var userID *uuid.UUID
+	known, err := s.UserService.Repo.GetByEmail(req.Email)
+	if err == nil {
+		userID = &known.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := s.Lockout.Check(userID, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserService.Authenticate(req.Email, req.Password)
+	if err != nil {
+		return nil, s.recordFailure(err, userID, ip)
+	}
+
+	enabled, err := s.TwoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.TwoFactor.IsRequired(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled && !required {
+		if err := s.Lockout.RecordSuccess(user.ID); err != nil {
+			return nil, err
+		}
+		return s.issueSession(user.ID)
+	}
+
+	raw, err := common.NewRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &LoginChallenge{
+		UserID:    user.ID,
+		TokenHash: common.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.ChallengeTTL),
+	}
+	if err := s.Repo.CreateChallenge(challenge); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		ChallengeToken:         raw,
+		ChallengeExpiresAt:     &challenge.ExpiresAt,
+		TwoFactorRequired:      true,
+		TwoFactorSetupRequired: !enabled,
+	}, nil
+}
+
+// SetupTwoFactor lets a user who must use 2FA but hasn't enrolled yet do so during login.
+func (s *SessionService) SetupTwoFactor(req ChallengeRequest) (*TwoFactorEnrollmentResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	challenge, err := s.findChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.TwoFactor.Enroll(challenge.UserID)
+}
+
+func (s *SessionService) CompleteTwoFactor(req TwoFactorLoginRequest, ip string) (*LoginResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	challenge, err := s.findChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Lockout.Check(&challenge.UserID, ip); err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.TwoFactor.IsEnabled(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = s.TwoFactor.Check(challenge.UserID, req.Code)
+	} else {
+		var codes *RecoveryCodesResponse
+		codes, err = s.TwoFactor.Verify(challenge.UserID, TwoFactorCodeRequest{Code: req.Code})
+		if codes != nil {
+			recoveryCodes = codes.Codes
+		}
+	}
+	if err != nil {
+		return nil, s.recordFailure(err, &challenge.UserID, ip)
+	}
+
+	if err := s.Lockout.RecordSuccess(challenge.UserID); err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.Repo.DeleteChallengeByID(challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, common.Unauthorized("Invalid or expired login challenge!")
+	}
+
+	resp, err := s.issueSession(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// recordFailure counts rejected credentials towards the lockout and passes the original error through.
+func (s *SessionService) recordFailure(err error, userID *uuid.UUID, ip string) error {
+	var apiErr *common.ApiError
+	if !errors.As(err, &apiErr) || (apiErr.StatusCode != http.StatusUnauthorized && apiErr.StatusCode != http.StatusBadRequest) {
+		return err
+	}
+	if recordErr := s.Lockout.RecordFailure(userID, ip); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+func (s *SessionService) findChallenge(raw string) (*LoginChallenge, error) {
+	challenge, err := s.Repo.GetChallengeByTokenHash(common.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.Unauthorized("Invalid or expired login challenge!")
+		}
+		return nil, err
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, common.Unauthorized("Invalid or expired login challenge!")
+	}
+	return challenge, nil
+}
+
+func (s *SessionService) issueSession(userID uuid.UUID) (*LoginResponse, error) {
+	random, err := common.NewRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := SessionTokenPrefix + random
+
+	session := &Session{
+		UserID:    userID,
+		TokenHash: common.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.SessionTTL),
+	}
+	if err := s.Repo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		Token:     raw,
+		ExpiresAt: &session.ExpiresAt,
+	}, nil
+}
+
+func (s *SessionService) Authenticate(raw string) (*common.Principal, error) {
+	session, err := s.Repo.GetByTokenHash(common.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.Unauthorized("Invalid session token!")
+		}
+		return nil, err
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, common.Unauthorized("Session has expired!")
+	}
+
+	if !session.User.IsActive() {
+		return nil, common.Unauthorized("Account is deactivated!")
+	}
+
+	return &common.Principal{
+		UserID:    session.UserID,
+		IsAdmin:   session.User.Role == org.Admin,
+		SessionID: &session.ID,
+	}, nil
+}
+
+func (s *SessionService) Logout(raw string) error {
+	return s.Repo.DeleteByTokenHash(common.HashToken(raw))
+}
+
+const (
+	userThrottlePrefix = "user:"
+	ipThrottlePrefix   = "ip:"
+)
+
+type LockoutPolicy struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	FailureWindow      time.Duration
+	LockoutDuration    time.Duration
+	DelayBase          time.Duration
+	DelayMax           time.Duration
+}
+
+// delay is the time a caller has to wait after the given number of consecutive failures.
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	d := p.DelayBase
+	for i := 1; i < failures && d < p.DelayMax; i++ {
+		d *= 2
+	}
+	return min(d, p.DelayMax)
+}
+
+type LockoutService struct {
+	Repo        LoginThrottleRepository
+	UserService *org.UserService
+	Policy      LockoutPolicy
+}
+
+func NewLockoutService(repo LoginThrottleRepository, userService *org.UserService, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		Repo:        repo,
+		UserService: userService,
+		Policy:      policy,
+	}
+}
+
+func UserThrottleSubject(userID uuid.UUID) string {
+	return userThrottlePrefix + userID.String()
+}
+
+func (s *LockoutService) find(subject string) (*LoginThrottle, error) {
+	t, err := s.Repo.Get(subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *LockoutService) Check(userID *uuid.UUID, ip string) error {
+	now := time.Now()
+
+	ipThrottle, err := s.find(ipThrottlePrefix + ip)
+	if err != nil {
+		return err
+	}
+	if ipThrottle != nil && ipThrottle.LockedUntil != nil && now.Before(*ipThrottle.LockedUntil) {
+		return common.TooManyRequests("Too many failed login attempts from this address!", ipThrottle.LockedUntil.Sub(now))
+	}
+
+	if userID == nil {
+		return nil
+	}
+
+	userThrottle, err := s.find(UserThrottleSubject(*userID))
+	if err != nil {
+		return err
+	}
+	if userThrottle == nil {
+		return nil
+	}
+	if userThrottle.LockedUntil != nil && now.Before(*userThrottle.LockedUntil) {
+		return common.Locked("Account is temporarily locked after too many failed login attempts!", userThrottle.LockedUntil.Sub(now))
+	}
+	if userThrottle.Failures > 0 && now.Sub(userThrottle.LastFailureAt) < s.Policy.FailureWindow {
+		if wait := s.Policy.delay(userThrottle.Failures) - now.Sub(userThrottle.LastFailureAt); wait > 0 {
+			return common.TooManyRequests("Too many failed login attempts, try again later!", wait)
+		}
+	}
+	return nil
+}
+
+func (s *LockoutService) RecordFailure(userID *uuid.UUID, ip string) error {
+	if err := s.Repo.Update(ipThrottlePrefix+ip, s.registerFailure(s.Policy.MaxIPFailures)); err != nil {
+		return err
+	}
+	if userID == nil {
+		return nil
+	}
+	return s.Repo.Update(UserThrottleSubject(*userID), s.registerFailure(s.Policy.MaxAccountFailures))
+}
+
+func (s *LockoutService) registerFailure(maxFailures int) func(t *LoginThrottle) {
+	now := time.Now()
+	return func(t *LoginThrottle) {
+		if now.Sub(t.LastFailureAt) > s.Policy.FailureWindow {
+			t.Failures = 0
+		}
+		t.Failures++
+		t.LastFailureAt = now
+
+		if t.Failures >= maxFailures {
+			lockedUntil := now.Add(s.Policy.LockoutDuration)
+			t.LockedUntil = &lockedUntil
+			t.Failures = 0
+		}
+	}
+}
+
+func (s *LockoutService) RecordSuccess(userID uuid.UUID) error {
+	return s.Repo.Delete(UserThrottleSubject(userID))
+}
+
+func (s *LockoutService) ListLocked() ([]LockoutResponse, error) {
+	throttles, err := s.Repo.ListLocked(userThrottlePrefix, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]LockoutResponse, 0, len(throttles))
+	for _, t := range throttles {
+		userID, err := uuid.Parse(strings.TrimPrefix(t.Subject, userThrottlePrefix))
+		if err != nil {
+			continue
+		}
+		res = append(res, LockoutResponse{UserID: userID, LockedUntil: *t.LockedUntil})
+	}
+	return res, nil
+}
+
+func (s *LockoutService) Unlock(userID uuid.UUID) error {
+	if _, err := s.UserService.GetByID(userID); err != nil {
+		return err
+	}
+	return s.Repo.Delete(UserThrottleSubject(userID))
+}
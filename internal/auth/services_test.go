@@ -0,0 +1,628 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type userRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *userRepositoryMock) GetByID(id uuid.UUID) (*org.User, error) {
+	args := m.Called(id)
+	if u := args.Get(0); u != nil {
+		return u.(*org.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *userRepositoryMock) GetByEmail(email string) (*org.User, error) {
+	args := m.Called(email)
+	if u := args.Get(0); u != nil {
+		return u.(*org.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *userRepositoryMock) Create(user *org.User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
+}
+
+func (m *userRepositoryMock) Update(user *org.User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
+}
+
+func (m *userRepositoryMock) ChangePassword(user *org.User, keepSessionID *uuid.UUID, event *audit.Event) error {
+	return m.Called(user, keepSessionID, event).Error(0)
+}
+
+func (m *userRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return m.Called(id, event).Error(0)
+}
+
+func (m *userRepositoryMock) List(offset, limit int, includeDeactivated bool) ([]org.User, int, error) {
+	args := m.Called(offset, limit, includeDeactivated)
+	return args.Get(0).([]org.User), args.Int(1), args.Error(2)
+}
+
+type tokenRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *tokenRepositoryMock) Create(token *PersonalAccessToken) error {
+	return m.Called(token).Error(0)
+}
+
+func (m *tokenRepositoryMock) GetByTokenHash(hash string) (*PersonalAccessToken, error) {
+	args := m.Called(hash)
+	if t := args.Get(0); t != nil {
+		return t.(*PersonalAccessToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *tokenRepositoryMock) ListByUserID(userID uuid.UUID) ([]PersonalAccessToken, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]PersonalAccessToken), args.Error(1)
+}
+
+func (m *tokenRepositoryMock) DeleteByIDAndUserID(id, userID uuid.UUID) (bool, error) {
+	args := m.Called(id, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *tokenRepositoryMock) UpdateLastUsed(id uuid.UUID, at time.Time) error {
+	return m.Called(id, at).Error(0)
+}
+
+func newTestUser(role org.UserRole) *org.User {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Pass1234"), bcrypt.MinCost)
+	return &org.User{
+		BaseEntity:   common.BaseEntity{ID: uuid.New()},
+		Email:        "test@test.com",
+		Name:         "testUser",
+		PasswordHash: string(hash),
+		Role:         role,
+	}
+}
+
+func setupTokenServiceTest() (*TokenService, *tokenRepositoryMock) {
+	tokenRepo := &tokenRepositoryMock{}
+	return NewTokenService(tokenRepo, validator.New()), tokenRepo
+}
+
+func sessionPrincipal(isAdmin bool) *common.Principal {
+	sessionID := uuid.New()
+	return &common.Principal{UserID: uuid.New(), IsAdmin: isAdmin, SessionID: &sessionID}
+}
+
+func TestTokenService_Create(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+	p := sessionPrincipal(false)
+	tokenRepo.On("Create", mock.AnythingOfType("*auth.PersonalAccessToken")).Return(nil)
+
+	resp, err := service.Create(p, CreateTokenRequest{
+		Name:   "ci",
+		Scopes: []string{common.ScopeTeamsRead, common.ScopeItemsWrite, common.ScopeTeamsRead},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, len(resp.Token) > len(TokenPrefix))
+	assert.Equal(t, []string{common.ScopeItemsWrite, common.ScopeTeamsRead}, resp.Scopes)
+
+	stored := tokenRepo.Calls[0].Arguments.Get(0).(*PersonalAccessToken)
+	assert.Equal(t, common.HashToken(resp.Token), stored.TokenHash)
+	assert.Equal(t, p.UserID, stored.UserID)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestTokenService_Create_RequiresSession(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+	p := &common.Principal{UserID: uuid.New(), Scopes: []string{common.ScopeItemsWrite}}
+
+	resp, err := service.Create(p, CreateTokenRequest{Name: "ci", Scopes: []string{common.ScopeItemsWrite}})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 403, err.(*common.ApiError).StatusCode)
+	tokenRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTokenService_Create_AdminScopeRequiresAdmin(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+
+	resp, err := service.Create(sessionPrincipal(false), CreateTokenRequest{
+		Name:   "ci",
+		Scopes: []string{common.ScopeAdmin},
+	})
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	tokenRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTokenService_Create_InvalidScope(t *testing.T) {
+	service, _ := setupTokenServiceTest()
+
+	resp, err := service.Create(sessionPrincipal(false), CreateTokenRequest{
+		Name:   "ci",
+		Scopes: []string{"everything"},
+	})
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+}
+
+func TestTokenService_Authenticate(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+	user := newTestUser(org.Admin)
+	token := &PersonalAccessToken{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		UserID:     user.ID,
+		User:       *user,
+		Scopes:     "admin teams:read",
+	}
+	tokenRepo.On("GetByTokenHash", common.HashToken("glp_raw")).Return(token, nil)
+	tokenRepo.On("UpdateLastUsed", token.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	principal, err := service.Authenticate("glp_raw")
+
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, principal.UserID)
+	assert.True(t, principal.IsAdmin)
+	assert.True(t, principal.HasScope(common.ScopeTeamsRead))
+	assert.False(t, principal.HasScope(common.ScopeItemsWrite))
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestTokenService_Authenticate_Expired(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+	expiredAt := time.Now().Add(-time.Hour)
+	token := &PersonalAccessToken{BaseEntity: common.BaseEntity{ID: uuid.New()}, ExpiresAt: &expiredAt}
+	tokenRepo.On("GetByTokenHash", common.HashToken("glp_raw")).Return(token, nil)
+
+	principal, err := service.Authenticate("glp_raw")
+
+	assert.Nil(t, principal)
+	assert.Error(t, err)
+	tokenRepo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+}
+
+func TestTokenService_Authenticate_Unknown(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+	tokenRepo.On("GetByTokenHash", common.HashToken("glp_raw")).Return(nil, gorm.ErrRecordNotFound)
+
+	principal, err := service.Authenticate("glp_raw")
+
+	assert.Nil(t, principal)
+	assert.Error(t, err)
+}
+
+func TestTokenService_Revoke_NotFound(t *testing.T) {
+	service, tokenRepo := setupTokenServiceTest()
+	userID, tokenID := uuid.New(), uuid.New()
+	tokenRepo.On("DeleteByIDAndUserID", tokenID, userID).Return(false, nil)
+
+	err := service.Revoke(userID, tokenID)
+
+	assert.Error(t, err)
+}
+
+func TestTOTPCode_RFC6238Vector(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	code, err := totpCode(secret, totpStep(time.Unix(59, 0)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestMatchTOTP_Window(t *testing.T) {
+	secret, _ := generateTOTPSecret()
+	now := time.Now()
+	previous, _ := totpCode(secret, totpStep(now)-1)
+	stale, _ := totpCode(secret, totpStep(now)-3)
+
+	step, ok := matchTOTP(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now)-1, step)
+
+	_, ok = matchTOTP(secret, stale, now)
+	assert.False(t, ok)
+}
+
+type twoFactorRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *twoFactorRepositoryMock) GetSecret(userID uuid.UUID) (*TwoFactorSecret, error) {
+	args := m.Called(userID)
+	if s := args.Get(0); s != nil {
+		return s.(*TwoFactorSecret), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *twoFactorRepositoryMock) SaveSecret(secret *TwoFactorSecret) error {
+	return m.Called(secret).Error(0)
+}
+
+func (m *twoFactorRepositoryMock) Enable(secret *TwoFactorSecret, codes []RecoveryCode) error {
+	return m.Called(secret, codes).Error(0)
+}
+
+func (m *twoFactorRepositoryMock) Disable(userID uuid.UUID) error {
+	return m.Called(userID).Error(0)
+}
+
+func (m *twoFactorRepositoryMock) ReplaceRecoveryCodes(userID uuid.UUID, codes []RecoveryCode) error {
+	return m.Called(userID, codes).Error(0)
+}
+
+func (m *twoFactorRepositoryMock) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *twoFactorRepositoryMock) UpdateLastUsedStep(id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *twoFactorRepositoryMock) IsRequired(userID uuid.UUID, isAdmin bool) (bool, error) {
+	args := m.Called(userID, isAdmin)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *twoFactorRepositoryMock) ListRequirements() ([]TwoFactorRequirement, error) {
+	args := m.Called()
+	return args.Get(0).([]TwoFactorRequirement), args.Error(1)
+}
+
+func (m *twoFactorRepositoryMock) CreateRequirement(req *TwoFactorRequirement) error {
+	return m.Called(req).Error(0)
+}
+
+func (m *twoFactorRepositoryMock) DeleteRequirementByID(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+type sessionRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *sessionRepositoryMock) Create(session *Session) error {
+	return m.Called(session).Error(0)
+}
+
+func (m *sessionRepositoryMock) GetByTokenHash(hash string) (*Session, error) {
+	args := m.Called(hash)
+	if s := args.Get(0); s != nil {
+		return s.(*Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *sessionRepositoryMock) DeleteByTokenHash(hash string) error {
+	return m.Called(hash).Error(0)
+}
+
+func (m *sessionRepositoryMock) CreateChallenge(challenge *LoginChallenge) error {
+	return m.Called(challenge).Error(0)
+}
+
+func (m *sessionRepositoryMock) GetChallengeByTokenHash(hash string) (*LoginChallenge, error) {
+	args := m.Called(hash)
+	if c := args.Get(0); c != nil {
+		return c.(*LoginChallenge), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *sessionRepositoryMock) DeleteChallengeByID(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+type loginThrottleRepositoryMock struct {
+	mock.Mock
+	throttles map[string]*LoginThrottle
+}
+
+func (m *loginThrottleRepositoryMock) Get(subject string) (*LoginThrottle, error) {
+	if t, ok := m.throttles[subject]; ok {
+		return t, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *loginThrottleRepositoryMock) Update(subject string, apply func(t *LoginThrottle)) error {
+	t, ok := m.throttles[subject]
+	if !ok {
+		t = &LoginThrottle{Subject: subject}
+		m.throttles[subject] = t
+	}
+	apply(t)
+	return nil
+}
+
+func (m *loginThrottleRepositoryMock) Delete(subject string) error {
+	delete(m.throttles, subject)
+	return nil
+}
+
+func (m *loginThrottleRepositoryMock) ListLocked(subjectPrefix string, now time.Time) ([]LoginThrottle, error) {
+	args := m.Called(subjectPrefix, now)
+	return args.Get(0).([]LoginThrottle), args.Error(1)
+}
+
+var testLockoutPolicy = LockoutPolicy{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      10,
+	FailureWindow:      15 * time.Minute,
+	LockoutDuration:    15 * time.Minute,
+	DelayBase:          time.Second,
+	DelayMax:           30 * time.Second,
+}
+
+func setupSessionServiceTest() (*SessionService, *sessionRepositoryMock, *twoFactorRepositoryMock, *userRepositoryMock) {
+	service, sessionRepo, twoFactorRepo, userRepo, _ := setupSessionServiceTestWithThrottles()
+	return service, sessionRepo, twoFactorRepo, userRepo
+}
+
+func setupSessionServiceTestWithThrottles() (*SessionService, *sessionRepositoryMock, *twoFactorRepositoryMock, *userRepositoryMock, *loginThrottleRepositoryMock) {
+	v := validator.New()
+	userRepo := &userRepositoryMock{}
+	userService := org.NewUserService(userRepo, v, org.DefaultPasswordPolicy())
+	twoFactorRepo := &twoFactorRepositoryMock{}
+	twoFactor := NewTwoFactorService(twoFactorRepo, userService, nil, v, "Gollab")
+	throttleRepo := &loginThrottleRepositoryMock{throttles: map[string]*LoginThrottle{}}
+	lockout := NewLockoutService(throttleRepo, userService, testLockoutPolicy)
+	userService.Guard = lockout
+	sessionRepo := &sessionRepositoryMock{}
+	service := NewSessionService(sessionRepo, userService, twoFactor, lockout, v, time.Hour, 5*time.Minute)
+	return service, sessionRepo, twoFactorRepo, userRepo, throttleRepo
+}
+
+func TestSessionService_Login_WithoutTwoFactor(t *testing.T) {
+	service, sessionRepo, twoFactorRepo, userRepo := setupSessionServiceTest()
+	user := newTestUser(org.Standard)
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	twoFactorRepo.On("GetSecret", user.ID).Return(nil, gorm.ErrRecordNotFound)
+	twoFactorRepo.On("IsRequired", user.ID, false).Return(false, nil)
+	sessionRepo.On("Create", mock.AnythingOfType("*auth.Session")).Return(nil)
+
+	resp, err := service.Login(LoginRequest{Email: user.Email, Password: "Pass1234"}, "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.True(t, len(resp.Token) > len(SessionTokenPrefix))
+	assert.Empty(t, resp.ChallengeToken)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestSessionService_Login_WithTwoFactorReturnsChallenge(t *testing.T) {
+	service, sessionRepo, twoFactorRepo, userRepo := setupSessionServiceTest()
+	user := newTestUser(org.Admin)
+	enabledAt := time.Now()
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	twoFactorRepo.On("GetSecret", user.ID).Return(&TwoFactorSecret{UserID: user.ID, EnabledAt: &enabledAt}, nil)
+	twoFactorRepo.On("IsRequired", user.ID, true).Return(true, nil)
+	sessionRepo.On("CreateChallenge", mock.AnythingOfType("*auth.LoginChallenge")).Return(nil)
+
+	resp, err := service.Login(LoginRequest{Email: user.Email, Password: "Pass1234"}, "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Token)
+	assert.NotEmpty(t, resp.ChallengeToken)
+	assert.True(t, resp.TwoFactorRequired)
+	assert.False(t, resp.TwoFactorSetupRequired)
+	sessionRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestSessionService_Login_RequiredButNotEnrolled(t *testing.T) {
+	service, sessionRepo, twoFactorRepo, userRepo := setupSessionServiceTest()
+	user := newTestUser(org.Standard)
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	twoFactorRepo.On("GetSecret", user.ID).Return(nil, gorm.ErrRecordNotFound)
+	twoFactorRepo.On("IsRequired", user.ID, false).Return(true, nil)
+	sessionRepo.On("CreateChallenge", mock.AnythingOfType("*auth.LoginChallenge")).Return(nil)
+
+	resp, err := service.Login(LoginRequest{Email: user.Email, Password: "Pass1234"}, "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.True(t, resp.TwoFactorSetupRequired)
+	sessionRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestSessionService_CompleteTwoFactor(t *testing.T) {
+	service, sessionRepo, twoFactorRepo, _ := setupSessionServiceTest()
+	userID := uuid.New()
+	raw, _ := generateTOTPSecret()
+	enabledAt := time.Now()
+	secret := &TwoFactorSecret{BaseEntity: common.BaseEntity{ID: uuid.New()}, UserID: userID, Secret: raw, EnabledAt: &enabledAt}
+	challenge := &LoginChallenge{BaseEntity: common.BaseEntity{ID: uuid.New()}, UserID: userID, ExpiresAt: time.Now().Add(time.Minute)}
+	code, _ := totpCode(raw, totpStep(time.Now()))
+
+	sessionRepo.On("GetChallengeByTokenHash", common.HashToken("challenge")).Return(challenge, nil)
+	twoFactorRepo.On("GetSecret", userID).Return(secret, nil)
+	twoFactorRepo.On("UpdateLastUsedStep", secret.ID, mock.AnythingOfType("int64")).Return(true, nil)
+	sessionRepo.On("DeleteChallengeByID", challenge.ID).Return(true, nil)
+	sessionRepo.On("Create", mock.AnythingOfType("*auth.Session")).Return(nil)
+
+	resp, err := service.CompleteTwoFactor(TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}, "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestSessionService_CompleteTwoFactor_ReplayedCode(t *testing.T) {
+	service, sessionRepo, twoFactorRepo, _ := setupSessionServiceTest()
+	userID := uuid.New()
+	raw, _ := generateTOTPSecret()
+	enabledAt := time.Now()
+	secret := &TwoFactorSecret{BaseEntity: common.BaseEntity{ID: uuid.New()}, UserID: userID, Secret: raw, EnabledAt: &enabledAt}
+	challenge := &LoginChallenge{BaseEntity: common.BaseEntity{ID: uuid.New()}, UserID: userID, ExpiresAt: time.Now().Add(time.Minute)}
+	code, _ := totpCode(raw, totpStep(time.Now()))
+
+	sessionRepo.On("GetChallengeByTokenHash", common.HashToken("challenge")).Return(challenge, nil)
+	twoFactorRepo.On("GetSecret", userID).Return(secret, nil)
+	twoFactorRepo.On("UpdateLastUsedStep", secret.ID, mock.AnythingOfType("int64")).Return(false, nil)
+
+	resp, err := service.CompleteTwoFactor(TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}, "10.0.0.1")
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	sessionRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestSessionService_CompleteTwoFactor_ExpiredChallenge(t *testing.T) {
+	service, sessionRepo, _, _ := setupSessionServiceTest()
+	challenge := &LoginChallenge{UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	sessionRepo.On("GetChallengeByTokenHash", common.HashToken("challenge")).Return(challenge, nil)
+
+	resp, err := service.CompleteTwoFactor(TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "123456"}, "10.0.0.1")
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+}
+
+func TestTwoFactorService_Verify(t *testing.T) {
+	service, _, twoFactorRepo, _ := setupSessionServiceTest()
+	userID := uuid.New()
+	raw, _ := generateTOTPSecret()
+	secret := &TwoFactorSecret{UserID: userID, Secret: raw}
+	code, _ := totpCode(raw, totpStep(time.Now()))
+
+	twoFactorRepo.On("GetSecret", userID).Return(secret, nil)
+	twoFactorRepo.On("Enable", secret, mock.AnythingOfType("[]auth.RecoveryCode")).Return(nil)
+
+	resp, err := service.TwoFactor.Verify(userID, TwoFactorCodeRequest{Code: code})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Codes, recoveryCodeCount)
+	assert.NotNil(t, secret.EnabledAt)
+	stored := twoFactorRepo.Calls[1].Arguments.Get(1).([]RecoveryCode)
+	assert.Equal(t, hashRecoveryCode(resp.Codes[0]), stored[0].CodeHash)
+}
+
+func TestTwoFactorService_Check_RecoveryCode(t *testing.T) {
+	service, _, twoFactorRepo, _ := setupSessionServiceTest()
+	userID := uuid.New()
+	raw, _ := generateTOTPSecret()
+	enabledAt := time.Now()
+	twoFactorRepo.On("GetSecret", userID).Return(&TwoFactorSecret{UserID: userID, Secret: raw, EnabledAt: &enabledAt}, nil)
+	twoFactorRepo.On("UseRecoveryCode", userID, hashRecoveryCode("abcde-fghij")).Return(true, nil)
+
+	err := service.TwoFactor.Check(userID, "ABCDE-FGHIJ")
+
+	assert.NoError(t, err)
+}
+
+func TestTwoFactorService_Disable_WhenRequired(t *testing.T) {
+	service, _, twoFactorRepo, userRepo := setupSessionServiceTest()
+	user := newTestUser(org.Admin)
+	userRepo.On("GetByID", user.ID).Return(user, nil)
+	twoFactorRepo.On("IsRequired", user.ID, true).Return(true, nil)
+
+	err := service.TwoFactor.Disable(user.ID, "10.0.0.1", DisableTwoFactorRequest{Password: "Pass1234", Code: "123456"})
+
+	assert.Error(t, err)
+	twoFactorRepo.AssertNotCalled(t, "Disable", mock.Anything)
+}
+
+func TestTwoFactorService_Disable_WrongPasswordsLockAccount(t *testing.T) {
+	service, _, twoFactorRepo, userRepo, throttleRepo := setupSessionServiceTestWithThrottles()
+	user := newTestUser(org.Standard)
+	userRepo.On("GetByID", user.ID).Return(user, nil)
+
+	for range testLockoutPolicy.MaxAccountFailures {
+		err := service.TwoFactor.Disable(user.ID, "10.0.0.1", DisableTwoFactorRequest{Password: "WrongPass1", Code: "123456"})
+		assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+		// Skip the progressive delay between attempts.
+		throttleRepo.throttles[userThrottlePrefix+user.ID.String()].LastFailureAt = time.Now().Add(-time.Minute)
+	}
+
+	err := service.TwoFactor.Disable(user.ID, "10.0.0.1", DisableTwoFactorRequest{Password: "Pass1234", Code: "123456"})
+
+	assert.Equal(t, 423, err.(*common.ApiError).StatusCode)
+	twoFactorRepo.AssertNotCalled(t, "Disable", mock.Anything)
+}
+
+func TestLockoutPolicy_Delay(t *testing.T) {
+	assert.Equal(t, time.Second, testLockoutPolicy.delay(1))
+	assert.Equal(t, 4*time.Second, testLockoutPolicy.delay(3))
+	assert.Equal(t, 30*time.Second, testLockoutPolicy.delay(40))
+}
+
+func TestSessionService_Login_FailureAppliesDelay(t *testing.T) {
+	service, _, _, userRepo, throttles := setupSessionServiceTestWithThrottles()
+	user := newTestUser(org.Standard)
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+
+	_, err := service.Login(LoginRequest{Email: user.Email, Password: "WrongPass1"}, "10.0.0.1")
+	assert.Equal(t, 401, err.(*common.ApiError).StatusCode)
+	assert.Equal(t, 1, throttles.throttles[userThrottlePrefix+user.ID.String()].Failures)
+	assert.Equal(t, 1, throttles.throttles[ipThrottlePrefix+"10.0.0.1"].Failures)
+
+	_, err = service.Login(LoginRequest{Email: user.Email, Password: "Pass1234"}, "10.0.0.1")
+	assert.Equal(t, 429, err.(*common.ApiError).StatusCode)
+	assert.Equal(t, 1, err.(*common.ApiError).RetryAfter)
+}
+
+func TestSessionService_Login_LocksAccount(t *testing.T) {
+	service, _, _, userRepo, throttles := setupSessionServiceTestWithThrottles()
+	user := newTestUser(org.Standard)
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+
+	for range testLockoutPolicy.MaxAccountFailures {
+		_, err := service.Login(LoginRequest{Email: user.Email, Password: "WrongPass1"}, "10.0.0.1")
+		assert.Equal(t, 401, err.(*common.ApiError).StatusCode)
+		// Skip the progressive delay between attempts.
+		throttles.throttles[userThrottlePrefix+user.ID.String()].LastFailureAt = time.Now().Add(-time.Minute)
+	}
+
+	_, err := service.Login(LoginRequest{Email: user.Email, Password: "Pass1234"}, "10.0.0.1")
+	assert.Equal(t, 423, err.(*common.ApiError).StatusCode)
+
+	assert.NoError(t, service.Lockout.Repo.Delete(userThrottlePrefix+user.ID.String()))
+	assert.NoError(t, service.Lockout.Check(&user.ID, "10.0.0.1"))
+}
+
+func TestSessionService_Login_UnknownEmailCountsTowardsIP(t *testing.T) {
+	service, _, _, userRepo, throttles := setupSessionServiceTestWithThrottles()
+	userRepo.On("GetByEmail", "unknown@test.com").Return(nil, gorm.ErrRecordNotFound)
+
+	for range testLockoutPolicy.MaxIPFailures {
+		_, err := service.Login(LoginRequest{Email: "unknown@test.com", Password: "Pass1234"}, "10.0.0.2")
+		assert.Equal(t, 401, err.(*common.ApiError).StatusCode)
+	}
+
+	_, err := service.Login(LoginRequest{Email: "unknown@test.com", Password: "Pass1234"}, "10.0.0.2")
+	assert.Equal(t, 429, err.(*common.ApiError).StatusCode)
+	assert.NotNil(t, throttles.throttles[ipThrottlePrefix+"10.0.0.2"].LockedUntil)
+}
+
+func TestLockoutService_Unlock(t *testing.T) {
+	service, _, _, userRepo, throttles := setupSessionServiceTestWithThrottles()
+	user := newTestUser(org.Standard)
+	lockedUntil := time.Now().Add(time.Hour)
+	throttles.throttles[userThrottlePrefix+user.ID.String()] = &LoginThrottle{LockedUntil: &lockedUntil}
+	userRepo.On("GetByID", user.ID).Return(user, nil)
+
+	err := service.Lockout.Unlock(user.ID)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, throttles.throttles, userThrottlePrefix+user.ID.String())
+}
@@ -0,0 +1,398 @@
+package backlog
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateBoardRequest struct {
+	TeamID       uuid.UUID          `json:"teamId" validate:"required"`
+	Name         string             `json:"name" validate:"required,min=2,max=255"`
+	Description  string             `json:"description"`
+	EstimateUnit EstimateUnit       `json:"estimateUnit" validate:"omitempty,oneof=points hours"`
+	WIPLimits    map[ItemStatus]int `json:"wipLimits" validate:"omitempty,dive,keys,oneof=not_planned to_do in_progress on_hold in_review done,endkeys,gte=1"`
+}
+
+// BoardDocument holds the board fields a JSON merge patch can change.
+type BoardDocument struct {
+	Name         string             `json:"name" validate:"required,min=2,max=255"`
+	Description  string             `json:"description"`
+	EstimateUnit EstimateUnit       `json:"estimateUnit" validate:"required,oneof=points hours"`
+	WIPLimits    map[ItemStatus]int `json:"wipLimits" validate:"omitempty,dive,keys,oneof=not_planned to_do in_progress on_hold in_review done,endkeys,gte=1"`
+}
+
+func ToBoardDocument(board *Board) *BoardDocument {
+	return &BoardDocument{
+		Name:         board.Name,
+		Description:  board.Description,
+		EstimateUnit: board.EstimateUnit,
+		WIPLimits:    board.WIPLimitMap(),
+	}
+}
+
+// ColumnResponse is the live item count of a status column next to its WIP limit, which is
+// nil for columns without one. Blocked counts the items waiting on an unfinished blocker.
+type ColumnResponse struct {
+	Status  ItemStatus `json:"status"`
+	Count   int        `json:"count"`
+	Blocked int        `json:"blocked"`
+	Limit   *int       `json:"limit"`
+}
+
+// WIPLimitDetails is the detail of the error returned when a column is at its WIP limit.
+type WIPLimitDetails struct {
+	Status ItemStatus `json:"status"`
+	Count  int        `json:"count"`
+	Limit  int        `json:"limit"`
+}
+
+type BoardResponse struct {
+	ID           uuid.UUID          `json:"id"`
+	TeamID       uuid.UUID          `json:"teamId"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	EstimateUnit EstimateUnit       `json:"estimateUnit"`
+	WIPLimits    map[ItemStatus]int `json:"wipLimits"`
+	Columns      []ColumnResponse   `json:"columns,omitempty"`
+	Version      int                `json:"version"`
+}
+
+func ToBoardResponse(board *Board) *BoardResponse {
+	limits := board.WIPLimitMap()
+	if limits == nil {
+		limits = map[ItemStatus]int{}
+	}
+	return &BoardResponse{
+		ID:           board.ID,
+		TeamID:       board.TeamID,
+		Name:         board.Name,
+		Description:  board.Description,
+		EstimateUnit: board.EstimateUnit,
+		WIPLimits:    limits,
+		Version:      board.Version,
+	}
+}
+
+type CreateItemRequest struct {
+	Title       string      `json:"title" validate:"required,min=1,max=50"`
+	Description string      `json:"description"`
+	Status      ItemStatus  `json:"status" validate:"omitempty,oneof=not_planned to_do in_progress on_hold in_review done"`
+	Type        ItemType    `json:"type" validate:"omitempty,oneof=epic story task bug subtask"`
+	ParentID    *uuid.UUID  `json:"parentId"`
+	Priority    int         `json:"priority" validate:"gte=0"`
+	Estimate    *float64    `json:"estimate" validate:"omitempty,gte=0"`
+	DueDate     *time.Time  `json:"dueDate"`
+	AssigneeIDs []uuid.UUID `json:"assigneeIds"`
+}
+
+// UpdateItemRequest only changes the fields that are present.
+type UpdateItemRequest struct {
+	Title       *string      `json:"title" validate:"omitempty,min=1,max=50"`
+	Description *string      `json:"description"`
+	Status      *ItemStatus  `json:"status" validate:"omitempty,oneof=not_planned to_do in_progress on_hold in_review done"`
+	Type        *ItemType    `json:"type" validate:"omitempty,oneof=epic story task bug subtask"`
+	ParentID    *uuid.UUID   `json:"parentId"`
+	Priority    *int         `json:"priority" validate:"omitempty,gte=0"`
+	Estimate    *float64     `json:"estimate" validate:"omitempty,gte=0"`
+	DueDate     *time.Time   `json:"dueDate"`
+	AssigneeIDs *[]uuid.UUID `json:"assigneeIds"`
+}
+
+// ItemDocument holds the item fields a JSON merge patch can change. Unlike UpdateItemRequest,
+// a null due date, estimate, parent or description clears the field.
+type ItemDocument struct {
+	Title       string      `json:"title" validate:"required,min=1,max=50"`
+	Description string      `json:"description"`
+	Status      ItemStatus  `json:"status" validate:"required,oneof=not_planned to_do in_progress on_hold in_review done"`
+	Type        ItemType    `json:"type" validate:"required,oneof=epic story task bug subtask"`
+	ParentID    *uuid.UUID  `json:"parentId"`
+	Priority    int         `json:"priority" validate:"gte=0"`
+	Estimate    *float64    `json:"estimate" validate:"omitempty,gte=0"`
+	DueDate     *time.Time  `json:"dueDate"`
+	AssigneeIDs []uuid.UUID `json:"assigneeIds"`
+}
+
+func ToItemDocument(item *Item) *ItemDocument {
+	return &ItemDocument{
+		Title:       item.Title,
+		Description: item.Description,
+		Status:      item.Status,
+		Type:        item.Type,
+		ParentID:    item.ParentID,
+		Priority:    item.Priority,
+		Estimate:    item.Estimate,
+		DueDate:     item.DueDate,
+		AssigneeIDs: assigneeIDs(item),
+	}
+}
+
+type ItemResponse struct {
+	ID          uuid.UUID         `json:"id"`
+	BoardID     uuid.UUID         `json:"boardId"`
+	AuthorID    uuid.UUID         `json:"authorId"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Status      ItemStatus        `json:"status"`
+	Type        ItemType          `json:"type"`
+	ParentID    *uuid.UUID        `json:"parentId"`
+	Priority    int               `json:"priority"`
+	Rank        string            `json:"rank"`
+	Estimate    *float64          `json:"estimate"`
+	DueDate     *time.Time        `json:"dueDate"`
+	SprintID    *uuid.UUID        `json:"sprintId"`
+	Blocked     bool              `json:"blocked"`
+	AssigneeIDs []uuid.UUID       `json:"assigneeIds"`
+	Mentions    []MentionResponse `json:"mentions"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+	Version     int               `json:"version"`
+}
+
+// RedactedFields keeps the names of mentioned users out of the audit log.
+func (*ItemResponse) RedactedFields() []string {
+	return []string{"mentions"}
+}
+
+func ToItemResponse(item *Item) *ItemResponse {
+	return &ItemResponse{
+		ID:          item.ID,
+		BoardID:     item.BoardID,
+		AuthorID:    item.AuthorID,
+		Title:       item.Title,
+		Description: item.Description,
+		Status:      item.Status,
+		Type:        item.Type,
+		ParentID:    item.ParentID,
+		Priority:    item.Priority,
+		Rank:        item.Rank,
+		Estimate:    item.Estimate,
+		DueDate:     item.DueDate,
+		SprintID:    item.SprintID,
+		Blocked:     len(item.blockers()) > 0,
+		AssigneeIDs: assigneeIDs(item),
+		Mentions:    ToMentionResponses(item.Mentions),
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
+	}
+}
+
+// MoveItemRequest puts an item into a status column, below BeforeID and above AfterID.
+type MoveItemRequest struct {
+	Status   ItemStatus `json:"status" validate:"required,oneof=not_planned to_do in_progress on_hold in_review done"`
+	BeforeID *uuid.UUID `json:"beforeId"`
+	AfterID  *uuid.UUID `json:"afterId"`
+}
+
+// RollupResponse sums up an item's descendants. Estimate adds up their own estimates, so
+// estimating both a story and its subtasks counts both.
+type RollupResponse struct {
+	Items        int     `json:"items"`
+	DoneItems    int     `json:"doneItems"`
+	Progress     float64 `json:"progress"`
+	Estimate     float64 `json:"estimate"`
+	DoneEstimate float64 `json:"doneEstimate"`
+}
+
+// ItemTreeResponse is an item with its descendants. Rollup covers all of them, not just the
+// direct children.
+type ItemTreeResponse struct {
+	Item     *ItemResponse      `json:"item"`
+	Rollup   RollupResponse     `json:"rollup"`
+	Children []ItemTreeResponse `json:"children"`
+}
+
+// The link types as seen from either end. Blocked-by and duplicated-by are stored as the
+// reverse blocks and duplicates links.
+const (
+	LinkBlocks       = "blocks"
+	LinkBlockedBy    = "blocked_by"
+	LinkRelatesTo    = "relates_to"
+	LinkDuplicates   = "duplicates"
+	LinkDuplicatedBy = "duplicated_by"
+)
+
+type CreateLinkRequest struct {
+	Type   string    `json:"type" validate:"required,oneof=blocks blocked_by relates_to duplicates duplicated_by"`
+	ItemID uuid.UUID `json:"itemId" validate:"required"`
+}
+
+// LinkResponse is a link as seen from one of its items. Type reads from that item to the
+// linked one, so a link shown as blocks on one end is blocked_by on the other.
+type LinkResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ItemID    uuid.UUID  `json:"itemId"`
+	BoardID   uuid.UUID  `json:"boardId"`
+	Title     string     `json:"title"`
+	Status    ItemStatus `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func ToLinkResponse(link *ItemLink, itemID uuid.UUID) *LinkResponse {
+	other, linkType := link.Target, string(link.Type)
+	if link.TargetID == itemID {
+		other = link.Source
+		switch link.Type {
+		case Blocks:
+			linkType = LinkBlockedBy
+		case Duplicates:
+			linkType = LinkDuplicatedBy
+		}
+	}
+	return &LinkResponse{
+		ID:        link.ID,
+		Type:      linkType,
+		ItemID:    other.ID,
+		BoardID:   other.BoardID,
+		Title:     other.Title,
+		Status:    other.Status,
+		CreatedAt: link.CreatedAt,
+	}
+}
+
+// ItemStatusChangedPayload is sent to webhooks when an item moves to another status.
+type ItemStatusChangedPayload struct {
+	Item *ItemResponse `json:"item"`
+	From ItemStatus    `json:"from"`
+	To   ItemStatus    `json:"to"`
+}
+
+type CreateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=10000"`
+}
+
+type CommentResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	ItemID    uuid.UUID         `json:"itemId"`
+	UserID    uuid.UUID         `json:"userId"`
+	Content   string            `json:"content"`
+	Mentions  []MentionResponse `json:"mentions"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+func ToCommentResponse(comment *Comment) *CommentResponse {
+	return &CommentResponse{
+		ID:        comment.ID,
+		ItemID:    comment.ItemID,
+		UserID:    comment.UserID,
+		Content:   comment.Content,
+		Mentions:  ToMentionResponses(comment.Mentions),
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// MentionResponse is a mentioned user. Handle is the name as written in the text, which differs
+// from Username once the user is renamed.
+type MentionResponse struct {
+	UserID   uuid.UUID `json:"userId"`
+	Username string    `json:"username"`
+	Handle   string    `json:"handle"`
+}
+
+func ToMentionResponses(mentions []Mention) []MentionResponse {
+	res := make([]MentionResponse, 0, len(mentions))
+	for _, m := range mentions {
+		res = append(res, MentionResponse{
+			UserID:   m.UserID,
+			Username: m.User.Name,
+			Handle:   m.Handle,
+		})
+	}
+	return res
+}
+
+const (
+	ActivityChange  = "change"
+	ActivityComment = "comment"
+)
+
+// ActivityEntryResponse is one entry of an item's timeline, either a field change or a comment.
+type ActivityEntryResponse struct {
+	Type      string          `json:"type"`
+	At        time.Time       `json:"at"`
+	ActorID   *uuid.UUID      `json:"actorId"`
+	Field     string          `json:"field,omitempty"`
+	From      json.RawMessage `json:"from,omitempty"`
+	To        json.RawMessage `json:"to,omitempty"`
+	CommentID *uuid.UUID      `json:"commentId,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type CreateSprintRequest struct {
+	Name      string    `json:"name" validate:"required,min=1,max=50"`
+	Goal      string    `json:"goal"`
+	StartDate time.Time `json:"startDate" validate:"required"`
+	EndDate   time.Time `json:"endDate" validate:"required,gtfield=StartDate"`
+}
+
+type SprintResponse struct {
+	ID        uuid.UUID   `json:"id"`
+	BoardID   uuid.UUID   `json:"boardId"`
+	Name      string      `json:"name"`
+	Goal      string      `json:"goal"`
+	StartDate time.Time   `json:"startDate"`
+	EndDate   time.Time   `json:"endDate"`
+	State     SprintState `json:"state"`
+	StartedAt *time.Time  `json:"startedAt"`
+	ClosedAt  *time.Time  `json:"closedAt"`
+	Version   int         `json:"version"`
+}
+
+func ToSprintResponse(sprint *Sprint) *SprintResponse {
+	return &SprintResponse{
+		ID:        sprint.ID,
+		BoardID:   sprint.BoardID,
+		Name:      sprint.Name,
+		Goal:      sprint.Goal,
+		StartDate: sprint.StartDate,
+		EndDate:   sprint.EndDate,
+		State:     sprint.State,
+		StartedAt: sprint.StartedAt,
+		ClosedAt:  sprint.ClosedAt,
+		Version:   sprint.Version,
+	}
+}
+
+type SprintItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"itemIds" validate:"required,min=1"`
+}
+
+// CompleteSprintRequest names the planned sprint that takes over the unfinished items.
+// Without one, they go back to the backlog.
+type CompleteSprintRequest struct {
+	NextSprintID *uuid.UUID `json:"nextSprintId"`
+}
+
+type SprintReportItem struct {
+	ItemID    uuid.UUID  `json:"itemId"`
+	Title     string     `json:"title"`
+	Status    ItemStatus `json:"status"`
+	Completed bool       `json:"completed"`
+}
+
+type SprintReportResponse struct {
+	SprintID        uuid.UUID          `json:"sprintId"`
+	CompletedItems  int                `json:"completedItems"`
+	IncompleteItems int                `json:"incompleteItems"`
+	CarriedOverTo   *uuid.UUID         `json:"carriedOverTo"`
+	Items           []SprintReportItem `json:"items"`
+	CreatedAt       time.Time          `json:"createdAt"`
+}
+
+func ToSprintReportResponse(report *SprintReport) (*SprintReportResponse, error) {
+	var items []SprintReportItem
+	if err := json.Unmarshal([]byte(report.Items), &items); err != nil {
+		return nil, err
+	}
+	return &SprintReportResponse{
+		SprintID:        report.SprintID,
+		CompletedItems:  report.CompletedItems,
+		IncompleteItems: report.IncompleteItems,
+		CarriedOverTo:   report.CarriedOverTo,
+		Items:           items,
+		CreatedAt:       report.CreatedAt,
+	}, nil
+}
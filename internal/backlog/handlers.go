@@ -0,0 +1,657 @@
+package backlog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/realtime"
+	"github.com/go-chi/chi/v5"
+)
+
+func pagination(r *http.Request) (int, int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if size <= 0 {
+		size = 10
+	}
+	return page, size
+}
+
+// wipOverride reads the overrideWipLimit query parameter, with which project managers can put
+// an item into a column that's at its WIP limit.
+func wipOverride(r *http.Request) bool {
+	override, _ := strconv.ParseBool(r.URL.Query().Get("overrideWipLimit"))
+	return override
+}
+
+type BoardHandler struct {
+	Service         *BoardService
+	EventService    *realtime.EventService
+	PresenceService *realtime.PresenceService
+}
+
+func NewBoardHandler(service *BoardService, eventService *realtime.EventService, presenceService *realtime.PresenceService) *BoardHandler {
+	return &BoardHandler{
+		Service:         service,
+		EventService:    eventService,
+		PresenceService: presenceService,
+	}
+}
+
+func (h *BoardHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateBoardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.Create(audit.ActorFromRequest(r), req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *BoardHandler) ListByTeam(w http.ResponseWriter, r *http.Request) {
+	teamID, err := common.ParseUUID(r.URL.Query().Get("teamId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	page, size := pagination(r)
+	resp, err := h.Service.ListByTeamID(teamID, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *BoardHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.GetByID(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Events streams the changes on the board as Server-Sent Events.
+func (h *BoardHandler) Events(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	board, err := h.Service.GetByID(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	if err := h.Service.RequireMember(principal, board); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.EventService.Stream(w, r, id); err != nil {
+		common.WriteError(w, err)
+	}
+}
+
+// Presence opens the WebSocket that tracks who is viewing the board and what they are editing.
+func (h *BoardHandler) Presence(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, err := h.PresenceService.Principal(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	board, err := h.Service.GetByID(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.RequireMember(principal, board); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.PresenceService.Serve(w, r, id, principal.UserID); err != nil {
+		common.WriteError(w, err)
+	}
+}
+
+func (h *BoardHandler) PatchByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	patch, err := common.ReadMergePatch(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.PatchByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), patch)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *BoardHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.DeleteByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r)); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ItemHandler struct {
+	Service        *ItemService
+	CommentService *CommentService
+	LinkService    *LinkService
+}
+
+func NewItemHandler(service *ItemService, commentService *CommentService, linkService *LinkService) *ItemHandler {
+	return &ItemHandler{
+		Service:        service,
+		CommentService: commentService,
+		LinkService:    linkService,
+	}
+}
+
+func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req CreateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.Create(audit.ActorFromRequest(r), boardID, req, wipOverride(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *ItemHandler) ListByBoard(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	page, size := pagination(r)
+	resp, err := h.Service.ListByBoardID(boardID, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.GetByID(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) Tree(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.Tree(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req UpdateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.UpdateByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), req, wipOverride(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) Move(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req MoveItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.Move(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), req, wipOverride(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) PatchByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	patch, err := common.ReadMergePatch(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.PatchByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), patch, wipOverride(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.DeleteByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r)); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ItemHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.CommentService.Create(audit.ActorFromRequest(r), itemID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *ItemHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req CreateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.LinkService.Create(audit.ActorFromRequest(r), itemID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *ItemHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.LinkService.ListByItemID(itemID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	id, err := common.ParseUUID(chi.URLParam(r, "linkId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.LinkService.Delete(audit.ActorFromRequest(r), itemID, id); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ItemHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.CommentService.ListByItemID(itemID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ItemHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.CommentService.Activity(itemID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+type SprintHandler struct {
+	Service *SprintService
+}
+
+func NewSprintHandler(service *SprintService) *SprintHandler {
+	return &SprintHandler{Service: service}
+}
+
+func (h *SprintHandler) Create(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req CreateSprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.Create(audit.ActorFromRequest(r), boardID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *SprintHandler) ListByBoard(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	page, size := pagination(r)
+	resp, err := h.Service.ListByBoardID(boardID, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SprintHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.GetByID(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SprintHandler) ListItems(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.ListItems(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SprintHandler) AddItems(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req SprintItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.AddItems(audit.ActorFromRequest(r), id, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SprintHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	itemID, err := common.ParseUUID(chi.URLParam(r, "itemId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.RemoveItem(audit.ActorFromRequest(r), id, itemID); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SprintHandler) Start(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.Start(audit.ActorFromRequest(r), id, common.ParseIfMatch(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SprintHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	// The body is optional, an empty one sends the unfinished items back to the backlog.
+	var req CompleteSprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	resp, err := h.Service.Complete(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *SprintHandler) Report(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.GetReport(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
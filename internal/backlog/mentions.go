@@ -0,0 +1,95 @@
+package backlog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/google/uuid"
+)
+
+// mentionPattern matches @handles that aren't part of a word, so email addresses don't count.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_][\p{L}\p{N}_.\-]*)`)
+
+// parseMentions returns the distinct handles mentioned in the text, in order of appearance.
+// Trailing dots and dashes are punctuation rather than part of the handle.
+func parseMentions(text string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		handle := strings.TrimRight(m[1], ".-")
+		if handle == "" || seen[strings.ToLower(handle)] {
+			continue
+		}
+		seen[strings.ToLower(handle)] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// ReplaceMention rewrites the @handle mentions in the text, in any case, to name.
+func ReplaceMention(text, handle, name string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start := m[2]
+		end := start + len(strings.TrimRight(text[start:m[3]], ".-"))
+		if !strings.EqualFold(text[start:end], handle) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(name)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// matchMentions pairs the handles with the users they name. An exact match wins over one that
+// only differs in case. Handles that don't name any of the users are dropped.
+func matchMentions(handles []string, users []org.User) []Mention {
+	mentions := make([]Mention, 0, len(handles))
+	mentioned := make(map[uuid.UUID]bool)
+	for _, h := range handles {
+		user := findUser(h, users)
+		if user == nil || mentioned[user.ID] {
+			continue
+		}
+		mentioned[user.ID] = true
+		mentions = append(mentions, Mention{
+			BaseEntity: common.BaseEntity{ID: uuid.New()},
+			UserID:     user.ID,
+			User:       *user,
+			Handle:     h,
+		})
+	}
+	return mentions
+}
+
+func (i *Item) setMentions(mentions []Mention) {
+	for j := range mentions {
+		mentions[j].ItemID = &i.ID
+	}
+	i.Mentions = mentions
+}
+
+func (c *Comment) setMentions(mentions []Mention) {
+	for j := range mentions {
+		mentions[j].CommentID = &c.ID
+	}
+	c.Mentions = mentions
+}
+
+func findUser(handle string, users []org.User) *org.User {
+	var folded *org.User
+	for i := range users {
+		if users[i].Name == handle {
+			return &users[i]
+		}
+		if folded == nil && strings.EqualFold(users[i].Name, handle) {
+			folded = &users[i]
+		}
+	}
+	return folded
+}
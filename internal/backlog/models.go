@@ -1,6 +1,7 @@
 package backlog
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/StefanShivarov/gollab-backend/internal/common"
@@ -8,12 +9,29 @@ import (
 	"github.com/google/uuid"
 )
 
+// EstimateUnit is what the estimates of a board's items are counted in.
+type EstimateUnit string
+
+const (
+	StoryPoints EstimateUnit = "points"
+	Hours       EstimateUnit = "hours"
+)
+
 type Board struct {
 	common.BaseEntity
-	Name        string    `gorm:"not null;uniqueIndex:idx_team_board_name"`
-	Description string    `gorm:"type:text;not null"`
-	TeamID      uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_team_board_name"`
-	Team        org.Team  `gorm:"foreignKey:TeamID"`
+	Name         string       `gorm:"not null;uniqueIndex:idx_team_board_name"`
+	Description  string       `gorm:"type:text;not null"`
+	TeamID       uuid.UUID    `gorm:"type:uuid;not null;index;uniqueIndex:idx_team_board_name"`
+	Team         org.Team     `gorm:"foreignKey:TeamID"`
+	EstimateUnit EstimateUnit `gorm:"type:varchar(10);not null;default:'points';check: estimate_unit IN ('points', 'hours')"`
+	// WIPLimits caps the number of items in a status column, keyed by status.
+	WIPLimits string `gorm:"type:jsonb;not null;default:'{}'"`
+}
+
+func (b *Board) WIPLimitMap() map[ItemStatus]int {
+	var limits map[ItemStatus]int
+	_ = json.Unmarshal([]byte(b.WIPLimits), &limits)
+	return limits
 }
 
 type ItemStatus string
@@ -27,19 +45,73 @@ const (
 	Done       ItemStatus = "done"
 )
 
+// itemStatuses lists the statuses in the order their columns appear on a board.
+var itemStatuses = []ItemStatus{NotPlanned, ToDo, InProgress, OnHold, InReview, Done}
+
+// ItemType places an item in the hierarchy of epics, stories, tasks, bugs and subtasks.
+type ItemType string
+
+const (
+	Epic    ItemType = "epic"
+	Story   ItemType = "story"
+	Task    ItemType = "task"
+	Bug     ItemType = "bug"
+	Subtask ItemType = "subtask"
+)
+
+// parentTypes lists the types an item of each type can be placed under. Every type only goes
+// under types above it, so the hierarchy can't be deeper than four levels.
+var parentTypes = map[ItemType][]ItemType{
+	Epic:    nil,
+	Story:   {Epic},
+	Task:    {Epic, Story},
+	Bug:     {Epic, Story},
+	Subtask: {Story, Task},
+}
+
 type Item struct {
 	common.BaseEntity
 	DueDate     *time.Time
 	Title       string     `gorm:"type:varchar(50);not null"`
 	Description string     `gorm:"type:text"`
 	Status      ItemStatus `gorm:"type:varchar(20);not null;default:'not_planned';check: status IN ('not_planned', 'to_do', 'in_progress', 'on_hold', 'in_review', 'done')"`
+	Type        ItemType   `gorm:"type:varchar(20);not null;default:'task';check: type IN ('epic', 'story', 'task', 'bug', 'subtask')"`
+	ParentID    *uuid.UUID `gorm:"type:uuid;index"`
 	Priority    int        `gorm:"default:0"`
+	Rank        string     `gorm:"type:text;not null;default:''"`
+	Estimate    *float64
 	AuthorID    uuid.UUID  `gorm:"type:uuid;not null"`
 	Author      org.User   `gorm:"foreignKey:AuthorID"`
 	BoardID     uuid.UUID  `gorm:"type:uuid;not null;index"`
 	Board       Board      `gorm:"foreignKey:BoardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SprintID    *uuid.UUID `gorm:"type:uuid;index"`
 	Tags        []Tag      `gorm:"many2many:items_tags"`
 	Assignees   []org.User `gorm:"many2many:items_assignees"`
+	Mentions    []Mention  `gorm:"foreignKey:ItemID"`
+	BlockedBy   []ItemLink `gorm:"foreignKey:TargetID"`
+}
+
+// blockers returns the unfinished items blocking the item.
+func (i *Item) blockers() []Item {
+	var blockers []Item
+	for _, l := range i.BlockedBy {
+		if l.Type == Blocks && l.Source.Status != Done {
+			blockers = append(blockers, l.Source)
+		}
+	}
+	return blockers
+}
+
+// BoardColumn is the items of a board in one status.
+type BoardColumn struct {
+	BoardID uuid.UUID
+	Status  ItemStatus
+}
+
+// ColumnCount is the number of items in a board column and how many of them are blocked.
+type ColumnCount struct {
+	Items   int
+	Blocked int
 }
 
 type Tag struct {
@@ -52,9 +124,86 @@ type Tag struct {
 
 type Comment struct {
 	common.BaseEntity
-	Content string    `gorm:"type:text;not null"`
-	UserID  uuid.UUID `gorm:"type:uuid;not null;"`
-	User    org.User  `gorm:"foreignKey:UserID;onUpdate:CASCADE,onDelete:CASCADE"`
-	ItemID  uuid.UUID `gorm:"type:uuid;not null;index"`
-	Item    Item      `gorm:"foreignKey:ItemID;onUpdate:CASCADE,onDelete:CASCADE"`
+	Content  string    `gorm:"type:text;not null"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;"`
+	User     org.User  `gorm:"foreignKey:UserID;onUpdate:CASCADE,onDelete:CASCADE"`
+	ItemID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Item     Item      `gorm:"foreignKey:ItemID;onUpdate:CASCADE,onDelete:CASCADE"`
+	Mentions []Mention `gorm:"foreignKey:CommentID"`
+}
+
+// Mention is a user mentioned in an item's description or in a comment, whichever is set.
+// The user is referenced by ID, so the mention survives renaming them.
+type Mention struct {
+	common.BaseEntity
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User      org.User   `gorm:"foreignKey:UserID"`
+	ItemID    *uuid.UUID `gorm:"type:uuid;index"`
+	CommentID *uuid.UUID `gorm:"type:uuid;index"`
+	// Handle is the name as it was written.
+	Handle string `gorm:"type:varchar(50);not null"`
+}
+
+type LinkType string
+
+const (
+	Blocks     LinkType = "blocks"
+	RelatesTo  LinkType = "relates_to"
+	Duplicates LinkType = "duplicates"
+)
+
+// ItemLink connects two items of the same team, possibly on different boards. The source
+// blocks or duplicates the target, while relates_to has no direction.
+type ItemLink struct {
+	common.BaseEntity
+	SourceID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_item_link"`
+	Source   Item      `gorm:"foreignKey:SourceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TargetID uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_item_link"`
+	Target   Item      `gorm:"foreignKey:TargetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Type     LinkType  `gorm:"type:varchar(20);not null;uniqueIndex:idx_item_link;check: type IN ('blocks', 'relates_to', 'duplicates')"`
+}
+
+// ItemActivity records one field change of an item. Values are stored as JSON so
+// scalars and assignee lists share a column.
+type ItemActivity struct {
+	common.BaseEntity
+	ItemID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	ActorID  *uuid.UUID `gorm:"type:uuid"`
+	Field    string     `gorm:"type:varchar(30);not null"`
+	OldValue string     `gorm:"type:jsonb;not null"`
+	NewValue string     `gorm:"type:jsonb;not null"`
+}
+
+type SprintState string
+
+const (
+	SprintPlanned SprintState = "planned"
+	SprintActive  SprintState = "active"
+	SprintClosed  SprintState = "closed"
+)
+
+// Sprint time-boxes part of a board's items. A board has at most one active sprint.
+type Sprint struct {
+	common.BaseEntity
+	BoardID   uuid.UUID   `gorm:"type:uuid;not null;index"`
+	Board     Board       `gorm:"foreignKey:BoardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string      `gorm:"type:varchar(50);not null"`
+	Goal      string      `gorm:"type:text"`
+	StartDate time.Time   `gorm:"not null"`
+	EndDate   time.Time   `gorm:"not null"`
+	State     SprintState `gorm:"type:varchar(20);not null;default:'planned';check: state IN ('planned', 'active', 'closed')"`
+	StartedAt *time.Time
+	ClosedAt  *time.Time
+}
+
+// SprintReport is the snapshot taken when a sprint is completed. Items holds the sprint's items
+// as they were then, so later changes to them don't rewrite the report.
+type SprintReport struct {
+	common.BaseEntity
+	SprintID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	CompletedItems  int       `gorm:"not null"`
+	IncompleteItems int       `gorm:"not null"`
+	// CarriedOverTo is the sprint the unfinished items moved to, nil if they went back to the backlog.
+	CarriedOverTo *uuid.UUID `gorm:"type:uuid"`
+	Items         string     `gorm:"type:jsonb;not null"`
 }
@@ -0,0 +1,96 @@
+package backlog
+
+import (
+	"errors"
+	"strings"
+)
+
+// Ranks order the items of a board column. They are base 62 fractions written without the
+// leading "0.", compared byte by byte, so a rank between any two others can always be found
+// by making it longer. Ranks never end in the zero digit, which keeps them unique per value.
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxRankLength is the length past which a column's ranks are respaced.
+const maxRankLength = 32
+
+// respaceBatchSize is how many columns a run of the respacing job handles.
+const respaceBatchSize = 100
+
+var errRankOrder = errors.New("ranks are not in order")
+
+// rankBetween returns a rank between a and b, where an empty a is the start of the column and
+// an empty b is its end.
+func rankBetween(a, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", errRankOrder
+	}
+	return midpoint(a, b), nil
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	low, high := 0, len(rankDigits)
+	if a != "" {
+		low = strings.IndexByte(rankDigits, a[0])
+	}
+	if b != "" {
+		high = strings.IndexByte(rankDigits, b[0])
+	}
+	if high-low > 1 {
+		return string(rankDigits[(low+high+1)/2])
+	}
+
+	// The first digits are adjacent, so the rank has to start with a's first digit and go on
+	// past the rest of a.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	return string(rankDigits[low]) + midpoint(rest, "")
+}
+
+func digitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return rankDigits[0]
+}
+
+// spacedRanks returns n ascending ranks of equal length, spread evenly so that later moves
+// have room between them.
+func spacedRanks(n int) []string {
+	base := len(rankDigits)
+	width, capacity := 1, base
+	for capacity <= n {
+		width++
+		capacity *= base
+	}
+	// One more digit leaves a whole digit of room between neighbours.
+	width++
+	capacity *= base
+
+	ranks := make([]string, n)
+	step := capacity / (n + 1)
+	for i := range ranks {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%base]
+			value /= base
+		}
+		ranks[i] = strings.TrimRight(string(digits), rankDigits[:1])
+	}
+	return ranks
+}
@@ -0,0 +1,598 @@
+package backlog
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BoardRepository interface {
+	GetByID(id uuid.UUID) (*Board, error)
+	Create(board *Board, event *audit.Event) error
+	Update(board *Board, event *audit.Event) error
+	DeleteByID(id uuid.UUID, event *audit.Event) error
+	ListByTeamID(teamID uuid.UUID, offset, limit int) ([]Board, int, error)
+	CountItems(boardIDs ...uuid.UUID) (map[uuid.UUID]map[ItemStatus]ColumnCount, error)
+}
+
+type boardRepository struct {
+	DB *gorm.DB
+}
+
+func NewBoardRepository(db *gorm.DB) BoardRepository {
+	return &boardRepository{DB: db}
+}
+
+func (r *boardRepository) GetByID(id uuid.UUID) (*Board, error) {
+	var board Board
+	if err := r.DB.First(&board, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &board, nil
+}
+
+func (r *boardRepository) Create(board *Board, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(board).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+func (r *boardRepository) Update(board *Board, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, board, &board.BaseEntity); err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+func (r *boardRepository) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&Board{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+func (r *boardRepository) ListByTeamID(teamID uuid.UUID, offset, limit int) ([]Board, int, error) {
+	var boards []Board
+	var total int64
+	query := r.DB.Model(&Board{}).Where("team_id = ?", teamID)
+	query.Count(&total)
+	if err := query.Order("name").Offset(offset).Limit(limit).Find(&boards).Error; err != nil {
+		return nil, 0, err
+	}
+	return boards, int(total), nil
+}
+
+// CountItems counts the items of the boards by status, along with those blocked by an
+// unfinished item.
+func (r *boardRepository) CountItems(boardIDs ...uuid.UUID) (map[uuid.UUID]map[ItemStatus]ColumnCount, error) {
+	var rows []struct {
+		BoardID uuid.UUID
+		Status  ItemStatus
+		Count   int
+		Blocked int
+	}
+	err := r.DB.Model(&Item{}).
+		Select(`board_id, status, COUNT(*) AS count,
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM item_links JOIN items AS blockers ON blockers.id = item_links.source_id
+				WHERE item_links.target_id = items.id AND item_links.type = ? AND blockers.status <> ?
+			)) AS blocked`, Blocks, Done).
+		Where("board_id IN ?", boardIDs).
+		Group("board_id, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]map[ItemStatus]ColumnCount, len(boardIDs))
+	for _, row := range rows {
+		if counts[row.BoardID] == nil {
+			counts[row.BoardID] = make(map[ItemStatus]ColumnCount)
+		}
+		counts[row.BoardID][row.Status] = ColumnCount{Items: row.Count, Blocked: row.Blocked}
+	}
+	return counts, nil
+}
+
+type ItemRepository interface {
+	GetByID(id uuid.UUID) (*Item, error)
+	Create(item *Item, event *audit.Event, message *outbox.Message) error
+	Update(item *Item, activities []ItemActivity, event *audit.Event, messages ...*outbox.Message) error
+	DeleteByID(id uuid.UUID, event *audit.Event, message *outbox.Message) error
+	ListByBoardID(boardID uuid.UUID, offset, limit int) ([]Item, int, error)
+	ListDueBetween(from, to time.Time) ([]Item, error)
+	ListActivities(itemID uuid.UUID) ([]ItemActivity, error)
+	ListChildren(id uuid.UUID) ([]Item, error)
+	ListDescendants(id uuid.UUID) ([]Item, error)
+	ListAncestorIDs(id uuid.UUID) ([]uuid.UUID, error)
+	WithBoardLock(boardID uuid.UUID, fn func(repo ItemRepository) error) error
+	LastRank(boardID uuid.UUID, status ItemStatus) (string, error)
+	CountInColumn(column BoardColumn) (int, error)
+	Respace(column BoardColumn) error
+	ListColumnsToRespace(limit int) ([]BoardColumn, error)
+}
+
+type itemRepository struct {
+	DB *gorm.DB
+}
+
+func NewItemRepository(db *gorm.DB) ItemRepository {
+	return &itemRepository{DB: db}
+}
+
+func (r *itemRepository) GetByID(id uuid.UUID) (*Item, error) {
+	var item Item
+	if err := r.DB.Preload("Assignees").Preload("Board").Preload("Mentions.User").Scopes(withBlockers).First(&item, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *itemRepository) Create(item *Item, event *audit.Event, message *outbox.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(item).Error; err != nil {
+			return err
+		}
+		if err := replaceAssignees(tx, item); err != nil {
+			return err
+		}
+		if err := createMentions(tx, item.Mentions); err != nil {
+			return err
+		}
+		if err := audit.Record(tx, event); err != nil {
+			return err
+		}
+		return outbox.Record(tx, message)
+	})
+}
+
+// Update saves the item together with its activity, so the history can't drift from the item.
+func (r *itemRepository) Update(item *Item, activities []ItemActivity, event *audit.Event, messages ...*outbox.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, item, &item.BaseEntity); err != nil {
+			return err
+		}
+		if err := replaceAssignees(tx, item); err != nil {
+			return err
+		}
+		if err := replaceMentions(tx, item); err != nil {
+			return err
+		}
+		if len(activities) > 0 {
+			if err := tx.Create(&activities).Error; err != nil {
+				return err
+			}
+		}
+		if err := audit.Record(tx, event); err != nil {
+			return err
+		}
+		return outbox.Record(tx, messages...)
+	})
+}
+
+// rankOrder sorts items by rank. Ranks only tie until the column is respaced, and the
+// tiebreakers keep the order the same for everyone meanwhile.
+const rankOrder = "rank, created_at, id"
+
+// withBlockers loads the blocks links pointing at the items, with the items blocking them.
+func withBlockers(db *gorm.DB) *gorm.DB {
+	return db.Preload("BlockedBy", "type = ?", Blocks).Preload("BlockedBy.Source")
+}
+
+func replaceAssignees(tx *gorm.DB, item *Item) error {
+	return tx.Model(item).Omit("Assignees.*").Association("Assignees").Replace(item.Assignees)
+}
+
+func replaceMentions(tx *gorm.DB, item *Item) error {
+	if err := tx.Delete(&Mention{}, "item_id = ?", item.ID).Error; err != nil {
+		return err
+	}
+	return createMentions(tx, item.Mentions)
+}
+
+func createMentions(tx *gorm.DB, mentions []Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	return tx.Omit(clause.Associations).Create(&mentions).Error
+}
+
+func (r *itemRepository) DeleteByID(id uuid.UUID, event *audit.Event, message *outbox.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&Item{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, event); err != nil {
+			return err
+		}
+		return outbox.Record(tx, message)
+	})
+}
+
+func (r *itemRepository) ListByBoardID(boardID uuid.UUID, offset, limit int) ([]Item, int, error) {
+	var items []Item
+	var total int64
+	query := r.DB.Model(&Item{}).Where("board_id = ?", boardID)
+	query.Count(&total)
+	if err := query.Preload("Assignees").Preload("Mentions.User").Scopes(withBlockers).Order(rankOrder).Offset(offset).Limit(limit).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, int(total), nil
+}
+
+// ListDueBetween returns the unfinished items due in the range, with assignees and board.
+func (r *itemRepository) ListDueBetween(from, to time.Time) ([]Item, error) {
+	var items []Item
+	err := r.DB.Preload("Assignees").Preload("Board").
+		Where("due_date BETWEEN ? AND ? AND status <> ?", from, to, Done).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *itemRepository) ListActivities(itemID uuid.UUID) ([]ItemActivity, error) {
+	var activities []ItemActivity
+	err := r.DB.Where("item_id = ?", itemID).Order("created_at").Find(&activities).Error
+	return activities, err
+}
+
+func (r *itemRepository) ListChildren(id uuid.UUID) ([]Item, error) {
+	var items []Item
+	err := r.DB.Where("parent_id = ?", id).Order("created_at").Find(&items).Error
+	return items, err
+}
+
+// ListDescendants returns the items under the item at any depth.
+func (r *itemRepository) ListDescendants(id uuid.UUID) ([]Item, error) {
+	descendants := r.DB.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM items WHERE parent_id = ?
+			UNION
+			SELECT i.id FROM items i JOIN tree t ON i.parent_id = t.id
+		)
+		SELECT id FROM tree`, id)
+
+	var items []Item
+	err := r.DB.Preload("Assignees").Scopes(withBlockers).Where("id IN (?)", descendants).Order("created_at").Find(&items).Error
+	return items, err
+}
+
+// ListAncestorIDs returns the IDs of the item and of every item above it.
+func (r *itemRepository) ListAncestorIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM items WHERE id = ?
+			UNION
+			SELECT i.id, i.parent_id FROM items i JOIN chain c ON i.id = c.parent_id
+		)
+		SELECT id FROM chain`, id).Scan(&ids).Error
+	return ids, err
+}
+
+// WithBoardLock runs fn in a transaction holding a lock on the board, with a repository bound
+// to that transaction. It serializes changes to the order of the board's items, so each one
+// sees the ranks the previous one left.
+func (r *itemRepository) WithBoardLock(boardID uuid.UUID, fn func(repo ItemRepository) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// NO KEY UPDATE doesn't hold up inserts referencing the board.
+		if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).
+			Select("id").
+			First(&Board{}, "id = ?", boardID).Error; err != nil {
+			return err
+		}
+		return fn(&itemRepository{DB: tx})
+	})
+}
+
+func (r *itemRepository) LastRank(boardID uuid.UUID, status ItemStatus) (string, error) {
+	var rank *string
+	err := r.DB.Model(&Item{}).Where("board_id = ? AND status = ?", boardID, status).Select("MAX(rank)").Scan(&rank).Error
+	if err != nil || rank == nil {
+		return "", err
+	}
+	return *rank, nil
+}
+
+func (r *itemRepository) CountInColumn(column BoardColumn) (int, error) {
+	var count int64
+	err := r.DB.Model(&Item{}).Where("board_id = ? AND status = ?", column.BoardID, column.Status).Count(&count).Error
+	return int(count), err
+}
+
+// Respace gives the column's items evenly spaced ranks in their current order. Changed items
+// get a new version, so saving a copy loaded before fails instead of restoring the old rank.
+func (r *itemRepository) Respace(column BoardColumn) error {
+	var items []Item
+	if err := r.DB.Select("id", "rank").
+		Where("board_id = ? AND status = ?", column.BoardID, column.Status).
+		Order(rankOrder).
+		Find(&items).Error; err != nil {
+		return err
+	}
+
+	ranks := spacedRanks(len(items))
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for i, item := range items {
+			if item.Rank == ranks[i] {
+				continue
+			}
+			if err := tx.Model(&Item{}).Where("id = ?", item.ID).Updates(map[string]any{
+				"rank":    ranks[i],
+				"version": gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListColumnsToRespace returns the columns whose ranks got too long, tie or are missing.
+func (r *itemRepository) ListColumnsToRespace(limit int) ([]BoardColumn, error) {
+	var columns []BoardColumn
+	err := r.DB.Model(&Item{}).
+		Select("board_id", "status").
+		Group("board_id, status").
+		Having("MAX(LENGTH(rank)) > ? OR MIN(rank) = '' OR COUNT(DISTINCT rank) < COUNT(*)", maxRankLength).
+		Limit(limit).
+		Scan(&columns).Error
+	return columns, err
+}
+
+type LinkRepository interface {
+	GetByID(id uuid.UUID) (*ItemLink, error)
+	Create(link *ItemLink, event *audit.Event) error
+	Delete(link *ItemLink, event *audit.Event) error
+	ListByItemID(itemID uuid.UUID) ([]ItemLink, error)
+	Exists(sourceID, targetID uuid.UUID, linkType LinkType) (bool, error)
+	Blocks(sourceID, targetID uuid.UUID) (bool, error)
+}
+
+type linkRepository struct {
+	DB *gorm.DB
+}
+
+func NewLinkRepository(db *gorm.DB) LinkRepository {
+	return &linkRepository{DB: db}
+}
+
+func (r *linkRepository) GetByID(id uuid.UUID) (*ItemLink, error) {
+	var link ItemLink
+	if err := r.DB.Preload("Source").Preload("Target").First(&link, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *linkRepository) Create(link *ItemLink, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(link).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+func (r *linkRepository) Delete(link *ItemLink, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&ItemLink{}, "id = ?", link.ID).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+// ListByItemID returns the links from and to the item.
+func (r *linkRepository) ListByItemID(itemID uuid.UUID) ([]ItemLink, error) {
+	var links []ItemLink
+	err := r.DB.Preload("Source").Preload("Target").
+		Where("source_id = ? OR target_id = ?", itemID, itemID).
+		Order("created_at").
+		Find(&links).Error
+	return links, err
+}
+
+func (r *linkRepository) Exists(sourceID, targetID uuid.UUID, linkType LinkType) (bool, error) {
+	var count int64
+	err := r.DB.Model(&ItemLink{}).
+		Where("source_id = ? AND target_id = ? AND type = ?", sourceID, targetID, linkType).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Blocks tells whether the source blocks the target, directly or through other items.
+func (r *linkRepository) Blocks(sourceID, targetID uuid.UUID) (bool, error) {
+	var blocks bool
+	err := r.DB.Raw(`
+		WITH RECURSIVE blocked AS (
+			SELECT target_id FROM item_links WHERE source_id = ? AND type = ?
+			UNION
+			SELECT l.target_id FROM item_links l JOIN blocked b ON l.source_id = b.target_id WHERE l.type = ?
+		)
+		SELECT EXISTS (SELECT 1 FROM blocked WHERE target_id = ?)`, sourceID, Blocks, Blocks, targetID).Scan(&blocks).Error
+	return blocks, err
+}
+
+type CommentRepository interface {
+	Create(comment *Comment, message *outbox.Message) error
+	ListByItemID(itemID uuid.UUID) ([]Comment, error)
+}
+
+type commentRepository struct {
+	DB *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{DB: db}
+}
+
+func (r *commentRepository) Create(comment *Comment, message *outbox.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(comment).Error; err != nil {
+			return err
+		}
+		if err := createMentions(tx, comment.Mentions); err != nil {
+			return err
+		}
+		return outbox.Record(tx, message)
+	})
+}
+
+func (r *commentRepository) ListByItemID(itemID uuid.UUID) ([]Comment, error) {
+	var comments []Comment
+	err := r.DB.Preload("Mentions.User").Where("item_id = ?", itemID).Order("created_at").Find(&comments).Error
+	return comments, err
+}
+
+type SprintRepository interface {
+	GetByID(id uuid.UUID) (*Sprint, error)
+	Create(sprint *Sprint, event *audit.Event) error
+	Update(sprint *Sprint, event *audit.Event) error
+	ListByBoardID(boardID uuid.UUID, offset, limit int) ([]Sprint, int, error)
+	HasActive(boardID uuid.UUID) (bool, error)
+	ListItems(sprintID uuid.UUID) ([]Item, error)
+	MoveItems(itemIDs []uuid.UUID, sprintID *uuid.UUID, activities []ItemActivity) error
+	Complete(sprint *Sprint, event *audit.Event, report func(items []Item) (*SprintReport, []ItemActivity, error)) error
+	GetReport(sprintID uuid.UUID) (*SprintReport, error)
+}
+
+type sprintRepository struct {
+	DB *gorm.DB
+}
+
+func NewSprintRepository(db *gorm.DB) SprintRepository {
+	return &sprintRepository{DB: db}
+}
+
+func (r *sprintRepository) GetByID(id uuid.UUID) (*Sprint, error) {
+	var sprint Sprint
+	if err := r.DB.First(&sprint, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+func (r *sprintRepository) Create(sprint *Sprint, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(sprint).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+func (r *sprintRepository) Update(sprint *Sprint, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, sprint, &sprint.BaseEntity); err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+func (r *sprintRepository) ListByBoardID(boardID uuid.UUID, offset, limit int) ([]Sprint, int, error) {
+	var sprints []Sprint
+	var total int64
+	query := r.DB.Model(&Sprint{}).Where("board_id = ?", boardID)
+	query.Count(&total)
+	if err := query.Order("start_date").Offset(offset).Limit(limit).Find(&sprints).Error; err != nil {
+		return nil, 0, err
+	}
+	return sprints, int(total), nil
+}
+
+func (r *sprintRepository) HasActive(boardID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Sprint{}).Where("board_id = ? AND state = ?", boardID, SprintActive).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sprintRepository) ListItems(sprintID uuid.UUID) ([]Item, error) {
+	var items []Item
+	err := r.DB.Preload("Assignees").Preload("Mentions.User").Scopes(withBlockers).Where("sprint_id = ?", sprintID).Order("created_at").Find(&items).Error
+	return items, err
+}
+
+// MoveItems puts the items into the sprint, or back into the backlog if it's nil.
+func (r *sprintRepository) MoveItems(itemIDs []uuid.UUID, sprintID *uuid.UUID, activities []ItemActivity) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := moveItems(tx, sprintID, "id IN ?", itemIDs); err != nil {
+			return err
+		}
+		if len(activities) == 0 {
+			return nil
+		}
+		return tx.Create(&activities).Error
+	})
+}
+
+// Complete closes the sprint, carries its unfinished items over and stores the report at once,
+// so the report always matches where the items went. The sprint's items are locked while report
+// takes stock of them, and exactly the items it records activities for are carried over.
+func (r *sprintRepository) Complete(sprint *Sprint, event *audit.Event, report func(items []Item) (*SprintReport, []ItemActivity, error)) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, sprint, &sprint.BaseEntity); err != nil {
+			return err
+		}
+
+		var items []Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("sprint_id = ?", sprint.ID).
+			Order("created_at").
+			Find(&items).Error; err != nil {
+			return err
+		}
+
+		rep, activities, err := report(items)
+		if err != nil {
+			return err
+		}
+
+		if len(activities) > 0 {
+			ids := make([]uuid.UUID, 0, len(activities))
+			for _, a := range activities {
+				ids = append(ids, a.ItemID)
+			}
+			if err := moveItems(tx, rep.CarriedOverTo, "id IN ?", ids); err != nil {
+				return err
+			}
+			if err := tx.Create(&activities).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(rep).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+// moveItems puts the matching items into the sprint. Their version is bumped, since their
+// representation changes.
+func moveItems(tx *gorm.DB, sprintID *uuid.UUID, query string, args ...any) error {
+	return tx.Model(&Item{}).Where(query, args...).Updates(map[string]any{
+		"sprint_id":  sprintID,
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}).Error
+}
+
+func (r *sprintRepository) GetReport(sprintID uuid.UUID) (*SprintReport, error) {
+	var report SprintReport
+	if err := r.DB.First(&report, "sprint_id = ?", sprintID).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
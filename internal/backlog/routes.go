@@ -0,0 +1,75 @@
+package backlog
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func BoardRoutes(r chi.Router, boardHandler *BoardHandler, itemHandler *ItemHandler, sprintHandler *SprintHandler) {
+	r.Route("/boards", func(r chi.Router) {
+		r.With(common.RequireScope(common.ScopeItemsRead)).Get("/", boardHandler.ListByTeam)
+		r.With(common.RequireScope(common.ScopeItemsWrite)).Post("/", boardHandler.Create)
+
+		r.Route("/{boardId}", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(common.RequireScope(common.ScopeItemsRead))
+				r.Get("/", boardHandler.GetByID)
+				r.Get("/items", itemHandler.ListByBoard)
+				r.With(common.RequireAuth).Get("/events", boardHandler.Events)
+				r.Get("/presence", boardHandler.Presence)
+				r.Get("/sprints", sprintHandler.ListByBoard)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(common.RequireScope(common.ScopeItemsWrite))
+				r.Patch("/", boardHandler.PatchByID)
+				r.Delete("/", boardHandler.DeleteByID)
+				r.With(common.RequireAuth).Post("/items", itemHandler.Create)
+				r.Post("/sprints", sprintHandler.Create)
+			})
+		})
+	})
+}
+
+func ItemRoutes(r chi.Router, handler *ItemHandler) {
+	r.Route("/items/{itemId}", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireScope(common.ScopeItemsRead))
+			r.Get("/", handler.GetByID)
+			r.Get("/comments", handler.ListComments)
+			r.Get("/activity", handler.Activity)
+			r.Get("/tree", handler.Tree)
+			r.Get("/links", handler.ListLinks)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireScope(common.ScopeItemsWrite))
+			r.Put("/", handler.UpdateByID)
+			r.Patch("/", handler.PatchByID)
+			r.Delete("/", handler.DeleteByID)
+			r.Post("/move", handler.Move)
+			r.With(common.RequireAuth).Post("/comments", handler.CreateComment)
+			r.Post("/links", handler.CreateLink)
+			r.Delete("/links/{linkId}", handler.DeleteLink)
+		})
+	})
+}
+
+func SprintRoutes(r chi.Router, handler *SprintHandler) {
+	r.Route("/sprints/{sprintId}", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireScope(common.ScopeItemsRead))
+			r.Get("/", handler.GetByID)
+			r.Get("/items", handler.ListItems)
+			r.Get("/report", handler.Report)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireScope(common.ScopeItemsWrite))
+			r.Post("/items", handler.AddItems)
+			r.Delete("/items/{itemId}", handler.RemoveItem)
+			r.Post("/start", handler.Start)
+			r.Post("/complete", handler.Complete)
+		})
+	})
+}
@@ -0,0 +1,1622 @@
+package backlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/notifications"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BoardService struct {
+	Repo        BoardRepository
+	TeamService *org.TeamService
+	Validator   *validator.Validate
+}
+
+func NewBoardService(repo BoardRepository, teamService *org.TeamService, validator *validator.Validate) *BoardService {
+	return &BoardService{
+		Repo:        repo,
+		TeamService: teamService,
+		Validator:   validator,
+	}
+}
+
+func (s *BoardService) findByID(id uuid.UUID) (*Board, error) {
+	board, err := s.Repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Board with id %s was not found!", id))
+		}
+		return nil, err
+	}
+	return board, nil
+}
+
+func (s *BoardService) GetByID(id uuid.UUID) (*BoardResponse, error) {
+	board, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withColumns(ToBoardResponse(board))
+}
+
+// withColumns adds the live item and blocked counts of the boards' columns to the responses.
+func (s *BoardService) withColumns(boards ...*BoardResponse) (*BoardResponse, error) {
+	if len(boards) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(boards))
+	for _, b := range boards {
+		ids = append(ids, b.ID)
+	}
+	counts, err := s.Repo.CountItems(ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range boards {
+		b.Columns = make([]ColumnResponse, 0, len(itemStatuses))
+		for _, status := range itemStatuses {
+			count := counts[b.ID][status]
+			column := ColumnResponse{Status: status, Count: count.Items, Blocked: count.Blocked}
+			if limit, ok := b.WIPLimits[status]; ok {
+				column.Limit = &limit
+			}
+			b.Columns = append(b.Columns, column)
+		}
+	}
+	return boards[0], nil
+}
+
+// encodeWIPLimits turns the WIP limits into the JSON stored on the board.
+func encodeWIPLimits(limits map[ItemStatus]int) (string, error) {
+	if limits == nil {
+		limits = map[ItemStatus]int{}
+	}
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *BoardService) Create(actor audit.Actor, req CreateBoardRequest) (*BoardResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if _, err := s.TeamService.GetByID(req.TeamID); err != nil {
+		return nil, err
+	}
+
+	unit := req.EstimateUnit
+	if unit == "" {
+		unit = StoryPoints
+	}
+
+	limits, err := encodeWIPLimits(req.WIPLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &Board{
+		BaseEntity:   common.BaseEntity{ID: uuid.New()},
+		Name:         req.Name,
+		Description:  req.Description,
+		TeamID:       req.TeamID,
+		EstimateUnit: unit,
+		WIPLimits:    limits,
+	}
+
+	event, err := audit.NewEvent(actor, audit.BoardCreated, audit.TargetBoard, board.ID, nil, ToBoardResponse(board))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(board, event); err != nil {
+		return nil, err
+	}
+
+	return s.withColumns(ToBoardResponse(board))
+}
+
+func (s *BoardService) PatchByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, patch []byte) (*BoardResponse, error) {
+	board, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(board.Version); err != nil {
+		return nil, err
+	}
+
+	var doc BoardDocument
+	if err := common.MergePatch(ToBoardDocument(board), patch, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validator.Struct(doc); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	limits, err := encodeWIPLimits(doc.WIPLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	before := ToBoardResponse(board)
+	board.Name = doc.Name
+	board.Description = doc.Description
+	board.EstimateUnit = doc.EstimateUnit
+	board.WIPLimits = limits
+
+	event, err := audit.NewEvent(actor, audit.BoardUpdated, audit.TargetBoard, board.ID, before, ToBoardResponse(board))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Update(board, event); err != nil {
+		return nil, err
+	}
+
+	return s.withColumns(ToBoardResponse(board))
+}
+
+func (s *BoardService) DeleteByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition) error {
+	board, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := ifMatch.Check(board.Version); err != nil {
+		return err
+	}
+
+	event, err := audit.NewEvent(actor, audit.BoardDeleted, audit.TargetBoard, board.ID, ToBoardResponse(board), nil)
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteByID(id, event)
+}
+
+// RequireMember lets admins and members of the board's team through.
+func (s *BoardService) RequireMember(p *common.Principal, board *BoardResponse) error {
+	if p.IsAdmin && p.HasScope(common.ScopeAdmin) {
+		return nil
+	}
+	member, err := s.TeamService.IsMember(board.TeamID, p.UserID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return common.Forbidden("Only members of the team can follow the board!")
+	}
+	return nil
+}
+
+func (s *BoardService) ListByTeamID(teamID uuid.UUID, page, size int) (*common.PaginatedResponse[BoardResponse], error) {
+	offset := (page - 1) * size
+	boards, total, err := s.Repo.ListByTeamID(teamID, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]BoardResponse, len(boards))
+	responses := make([]*BoardResponse, len(boards))
+	for i, b := range boards {
+		res[i] = *ToBoardResponse(&b)
+		responses[i] = &res[i]
+	}
+	if _, err := s.withColumns(responses...); err != nil {
+		return nil, err
+	}
+
+	return &common.PaginatedResponse[BoardResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+type ItemService struct {
+	Repo                ItemRepository
+	BoardService        *BoardService
+	UserService         *org.UserService
+	NotificationService *notifications.NotificationService
+	Validator           *validator.Validate
+}
+
+func NewItemService(
+	repo ItemRepository,
+	boardService *BoardService,
+	userService *org.UserService,
+	notificationService *notifications.NotificationService,
+	validator *validator.Validate,
+) *ItemService {
+	return &ItemService{
+		Repo:                repo,
+		BoardService:        boardService,
+		UserService:         userService,
+		NotificationService: notificationService,
+		Validator:           validator,
+	}
+}
+
+func (s *ItemService) findByID(id uuid.UUID) (*Item, error) {
+	item, err := s.Repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Item with id %s was not found!", id))
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *ItemService) GetByID(id uuid.UUID) (*ItemResponse, error) {
+	item, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return ToItemResponse(item), nil
+}
+
+func (s *ItemService) Create(actor audit.Actor, boardID uuid.UUID, req CreateItemRequest, override bool) (*ItemResponse, error) {
+	if actor.UserID == nil {
+		return nil, common.Unauthorized("Authentication required!")
+	}
+
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	board, err := s.BoardService.findByID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignees, err := s.resolveAssignees(board.TeamID, req.AssigneeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions, err := s.resolveMentions(board.TeamID, req.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	status := req.Status
+	if status == "" {
+		status = NotPlanned
+	}
+
+	itemType := req.Type
+	if itemType == "" {
+		itemType = Task
+	}
+
+	item := &Item{
+		BaseEntity:  common.BaseEntity{ID: uuid.New()},
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      status,
+		Type:        itemType,
+		ParentID:    req.ParentID,
+		Priority:    req.Priority,
+		Estimate:    req.Estimate,
+		DueDate:     req.DueDate,
+		AuthorID:    *actor.UserID,
+		BoardID:     board.ID,
+		Board:       *board,
+		Assignees:   assignees,
+	}
+	item.setMentions(mentions)
+
+	if err := s.checkHierarchy(nil, item); err != nil {
+		return nil, err
+	}
+
+	// New items go to the end of their column.
+	err = s.Repo.WithBoardLock(board.ID, func(repo ItemRepository) error {
+		if err := s.checkWIPLimit(repo, actor, board, item.Status, override); err != nil {
+			return err
+		}
+
+		last, err := repo.LastRank(board.ID, item.Status)
+		if err != nil {
+			return err
+		}
+		if item.Rank, err = rankBetween(last, ""); err != nil {
+			return err
+		}
+
+		event, err := audit.NewEvent(actor, audit.ItemCreated, audit.TargetItem, item.ID, nil, ToItemResponse(item))
+		if err != nil {
+			return err
+		}
+
+		message, err := outbox.NewBoardMessage(board.TeamID, board.ID, outbox.ItemCreated, ToItemResponse(item))
+		if err != nil {
+			return err
+		}
+
+		return repo.Create(item, event, message)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyAssigned(actor, nil, item)
+	s.notifyMentioned(actor, nil, item.Mentions, item, nil)
+	return ToItemResponse(item), nil
+}
+
+func (s *ItemService) UpdateByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, req UpdateItemRequest, override bool) (*ItemResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	item, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(item.Version); err != nil {
+		return nil, err
+	}
+
+	before := *item
+	if req.Title != nil {
+		item.Title = *req.Title
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.Status != nil {
+		item.Status = *req.Status
+	}
+	if req.Type != nil {
+		item.Type = *req.Type
+	}
+	if req.ParentID != nil {
+		item.ParentID = req.ParentID
+	}
+	if req.Priority != nil {
+		item.Priority = *req.Priority
+	}
+	if req.Estimate != nil {
+		item.Estimate = req.Estimate
+	}
+	if req.DueDate != nil {
+		item.DueDate = req.DueDate
+	}
+	if req.AssigneeIDs != nil {
+		board, err := s.BoardService.findByID(item.BoardID)
+		if err != nil {
+			return nil, err
+		}
+		if item.Assignees, err = s.resolveAssignees(board.TeamID, *req.AssigneeIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.saveLocked(actor, &before, item, override)
+}
+
+// PatchByID applies a JSON merge patch to the item and validates the merged result.
+func (s *ItemService) PatchByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, patch []byte, override bool) (*ItemResponse, error) {
+	item, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(item.Version); err != nil {
+		return nil, err
+	}
+
+	var doc ItemDocument
+	if err := common.MergePatch(ToItemDocument(item), patch, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validator.Struct(doc); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	before := *item
+	item.Title = doc.Title
+	item.Description = doc.Description
+	item.Status = doc.Status
+	item.Type = doc.Type
+	item.ParentID = doc.ParentID
+	item.Priority = doc.Priority
+	item.Estimate = doc.Estimate
+	item.DueDate = doc.DueDate
+
+	if !slices.Equal(sortedIDs(doc.AssigneeIDs), assigneeIDs(item)) {
+		board, err := s.BoardService.findByID(item.BoardID)
+		if err != nil {
+			return nil, err
+		}
+		if item.Assignees, err = s.resolveAssignees(board.TeamID, doc.AssigneeIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.saveLocked(actor, &before, item, override)
+}
+
+// saveLocked saves the item, under the board lock when it changes columns, so that two items
+// can't both take the last place under a WIP limit. An item changing columns goes to the end
+// of its new one, as a new item would.
+func (s *ItemService) saveLocked(actor audit.Actor, before, item *Item, override bool) (*ItemResponse, error) {
+	if item.Status == before.Status {
+		return s.save(s.Repo, actor, before, item, override)
+	}
+
+	var resp *ItemResponse
+	err := s.Repo.WithBoardLock(item.BoardID, func(repo ItemRepository) error {
+		last, err := repo.LastRank(item.BoardID, item.Status)
+		if err != nil {
+			return err
+		}
+		if item.Rank, err = rankBetween(last, ""); err != nil {
+			return err
+		}
+
+		resp, err = s.save(repo, actor, before, item, override)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save stores the item together with its activity and audit event. repo is the service's own
+// repository, unless the item is saved under a board lock, which it has to be when the item
+// changes columns.
+func (s *ItemService) save(repo ItemRepository, actor audit.Actor, before, item *Item, override bool) (*ItemResponse, error) {
+	if item.Description != before.Description {
+		mentions, err := s.resolveMentions(item.Board.TeamID, item.Description)
+		if err != nil {
+			return nil, err
+		}
+		item.setMentions(mentions)
+	}
+
+	if item.Status == InProgress && before.Status != InProgress {
+		if blockers := item.blockers(); len(blockers) > 0 {
+			titles := make([]string, 0, len(blockers))
+			for _, b := range blockers {
+				titles = append(titles, b.Title)
+			}
+			return nil, common.Conflict(fmt.Sprintf("The item is blocked by unfinished items: %s!", strings.Join(titles, ", ")))
+		}
+	}
+
+	if item.Type != before.Type || !equalIDs(item.ParentID, before.ParentID) {
+		if err := s.checkHierarchy(before, item); err != nil {
+			return nil, err
+		}
+	}
+
+	if item.Status != before.Status {
+		if err := s.checkWIPLimit(repo, actor, &item.Board, item.Status, override); err != nil {
+			return nil, err
+		}
+	}
+
+	activities, err := trackChanges(actor, before, item)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := audit.NewEvent(actor, audit.ItemUpdated, audit.TargetItem, item.ID, ToItemResponse(before), ToItemResponse(item))
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := itemUpdateMessages(before, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.Update(item, activities, event, messages...); err != nil {
+		return nil, err
+	}
+
+	s.notifyAssigned(actor, before, item)
+	s.notifyMentioned(actor, before.Mentions, item.Mentions, item, nil)
+
+	return ToItemResponse(item), nil
+}
+
+// Move puts the item into the status column between two neighbours, where a missing before
+// neighbour stands for the top of the column and a missing after neighbour for its bottom.
+// Without either, the item goes to the bottom.
+func (s *ItemService) Move(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, req MoveItemRequest, override bool) (*ItemResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	item, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(item.Version); err != nil {
+		return nil, err
+	}
+
+	var resp *ItemResponse
+	err = s.Repo.WithBoardLock(item.BoardID, func(repo ItemRepository) error {
+		rank, err := s.moveRank(repo, item, req)
+		if errors.Is(err, errRankOrder) {
+			// The neighbours tie or aren't ranked yet. Respacing the column sorts that out, but
+			// also changes the item's version, so it's read again.
+			if err := repo.Respace(BoardColumn{BoardID: item.BoardID, Status: req.Status}); err != nil {
+				return err
+			}
+			if item, err = repo.GetByID(id); err != nil {
+				return err
+			}
+			rank, err = s.moveRank(repo, item, req)
+		}
+		if err != nil {
+			return err
+		}
+
+		before := *item
+		item.Status = req.Status
+		item.Rank = rank
+		resp, err = s.save(repo, actor, &before, item, override)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// moveRank returns the rank between the neighbours the item is moved to, as they are now.
+func (s *ItemService) moveRank(repo ItemRepository, item *Item, req MoveItemRequest) (string, error) {
+	if req.BeforeID == nil && req.AfterID == nil {
+		last, err := repo.LastRank(item.BoardID, req.Status)
+		if err != nil {
+			return "", err
+		}
+		if last == item.Rank && item.Status == req.Status {
+			return item.Rank, nil
+		}
+		return rankBetween(last, "")
+	}
+
+	var bounds [2]string
+	for i, neighbourID := range []*uuid.UUID{req.BeforeID, req.AfterID} {
+		if neighbourID == nil {
+			continue
+		}
+		if *neighbourID == item.ID {
+			return "", common.BadRequest("An item can't be its own neighbour!")
+		}
+
+		neighbour, err := repo.GetByID(*neighbourID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", common.NotFound(fmt.Sprintf("Item with id %s was not found!", *neighbourID))
+			}
+			return "", err
+		}
+		if neighbour.BoardID != item.BoardID || neighbour.Status != req.Status {
+			return "", common.BadRequest(fmt.Sprintf("Item with id %s is not in the %s column of the board!", neighbour.ID, req.Status))
+		}
+		if neighbour.Rank == "" {
+			return "", errRankOrder
+		}
+		bounds[i] = neighbour.Rank
+	}
+	return rankBetween(bounds[0], bounds[1])
+}
+
+// checkWIPLimit makes sure another item fits into the status column of the board. Project
+// managers can go over the column's WIP limit when they ask to override it.
+func (s *ItemService) checkWIPLimit(repo ItemRepository, actor audit.Actor, board *Board, status ItemStatus, override bool) error {
+	limit, ok := board.WIPLimitMap()[status]
+	if !ok {
+		return nil
+	}
+
+	count, err := repo.CountInColumn(BoardColumn{BoardID: board.ID, Status: status})
+	if err != nil {
+		return err
+	}
+	if count < limit {
+		return nil
+	}
+
+	if override && actor.UserID != nil {
+		manager, err := s.BoardService.TeamService.IsManager(board.TeamID, *actor.UserID)
+		if err != nil {
+			return err
+		}
+		if !manager {
+			return common.Forbidden("Only project managers can override WIP limits!")
+		}
+		return nil
+	}
+	conflict := common.Conflict(fmt.Sprintf("The %s column already has %d items, which reaches its WIP limit of %d!", status, count, limit))
+	conflict.Details = WIPLimitDetails{Status: status, Count: count, Limit: limit}
+	return conflict
+}
+
+// RespaceRanks respaces the columns whose ranks got too long or tie. It runs as a background
+// job, since every move into the same gap makes the rank longer.
+func (s *ItemService) RespaceRanks() error {
+	columns, err := s.Repo.ListColumnsToRespace(respaceBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range columns {
+		err := s.Repo.WithBoardLock(c.BoardID, func(repo ItemRepository) error {
+			return repo.Respace(c)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkHierarchy validates the item's place in the hierarchy. Its parent has to be on the same
+// board, of a type the item can go under and not the item itself or one of its descendants.
+// When the type of an existing item changes, its children have to fit under the new type.
+// before is nil for new items.
+func (s *ItemService) checkHierarchy(before, item *Item) error {
+	if item.ParentID != nil {
+		parent, err := s.findByID(*item.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.BoardID != item.BoardID {
+			return common.BadRequest("The parent item must be on the same board!")
+		}
+		if !slices.Contains(parentTypes[item.Type], parent.Type) {
+			return common.BadRequest(fmt.Sprintf("An item of type %s can't be placed under one of type %s!", item.Type, parent.Type))
+		}
+
+		if before != nil {
+			ancestors, err := s.Repo.ListAncestorIDs(parent.ID)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(ancestors, item.ID) {
+				return common.BadRequest("An item can't be placed under itself or one of its descendants!")
+			}
+		}
+	}
+
+	if before == nil || before.Type == item.Type {
+		return nil
+	}
+
+	children, err := s.Repo.ListChildren(item.ID)
+	if err != nil {
+		return err
+	}
+	for _, c := range children {
+		if !slices.Contains(parentTypes[c.Type], item.Type) {
+			return common.BadRequest(fmt.Sprintf("The item can't become of type %s while it has children of type %s!", item.Type, c.Type))
+		}
+	}
+	return nil
+}
+
+// Tree returns the item with its descendants, each with a roll-up of the items under it.
+func (s *ItemService) Tree(id uuid.UUID) (*ItemTreeResponse, error) {
+	item, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	descendants, err := s.Repo.ListDescendants(item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]*Item)
+	for i := range descendants {
+		d := &descendants[i]
+		children[*d.ParentID] = append(children[*d.ParentID], d)
+	}
+
+	tree := buildTree(item, children)
+	return &tree, nil
+}
+
+func buildTree(item *Item, children map[uuid.UUID][]*Item) ItemTreeResponse {
+	node := ItemTreeResponse{
+		Item:     ToItemResponse(item),
+		Children: make([]ItemTreeResponse, 0, len(children[item.ID])),
+	}
+	for _, c := range children[item.ID] {
+		child := buildTree(c, children)
+		node.Rollup.add(c, child.Rollup)
+		node.Children = append(node.Children, child)
+	}
+	if node.Rollup.Items > 0 {
+		node.Rollup.Progress = float64(node.Rollup.DoneItems) / float64(node.Rollup.Items)
+	}
+	return node
+}
+
+// add counts the child and everything under it.
+func (r *RollupResponse) add(child *Item, under RollupResponse) {
+	r.Items += under.Items + 1
+	r.DoneItems += under.DoneItems
+	r.Estimate += under.Estimate
+	r.DoneEstimate += under.DoneEstimate
+
+	var estimate float64
+	if child.Estimate != nil {
+		estimate = *child.Estimate
+	}
+	r.Estimate += estimate
+	if child.Status == Done {
+		r.DoneItems++
+		r.DoneEstimate += estimate
+	}
+}
+
+// resolveMentions returns the members of the team mentioned in the text. Handles of anyone
+// else stay plain text.
+func (s *ItemService) resolveMentions(teamID uuid.UUID, text string) ([]Mention, error) {
+	handles := parseMentions(text)
+	users, err := s.BoardService.TeamService.FindMembersByName(teamID, handles)
+	if err != nil {
+		return nil, err
+	}
+	return matchMentions(handles, users), nil
+}
+
+// notifyMentioned tells the mentioned users who weren't mentioned before that they are now.
+// The comment is nil for mentions in the item's description.
+func (s *ItemService) notifyMentioned(actor audit.Actor, before, after []Mention, item *Item, comment *Comment) {
+	previous := make(map[uuid.UUID]bool)
+	for _, m := range before {
+		previous[m.UserID] = true
+	}
+
+	title := fmt.Sprintf("You were mentioned in %s", item.Title)
+	var commentID *uuid.UUID
+	if comment != nil {
+		title = fmt.Sprintf("You were mentioned in a comment on %s", item.Title)
+		commentID = &comment.ID
+	}
+
+	var mentioned []*notifications.Notification
+	for _, m := range after {
+		if previous[m.UserID] {
+			continue
+		}
+		mentioned = append(mentioned, &notifications.Notification{
+			UserID:    m.UserID,
+			Type:      notifications.Mentioned,
+			Title:     title,
+			TeamID:    &item.Board.TeamID,
+			BoardID:   &item.BoardID,
+			ItemID:    &item.ID,
+			CommentID: commentID,
+		})
+	}
+	s.NotificationService.Send(actor.UserID, mentioned...)
+}
+
+// notifyAssigned tells the users who weren't assigned to the item before that they are now.
+func (s *ItemService) notifyAssigned(actor audit.Actor, before, after *Item) {
+	previous := make(map[uuid.UUID]bool)
+	if before != nil {
+		for _, a := range before.Assignees {
+			previous[a.ID] = true
+		}
+	}
+
+	var assigned []*notifications.Notification
+	for _, a := range after.Assignees {
+		if previous[a.ID] {
+			continue
+		}
+		assigned = append(assigned, &notifications.Notification{
+			UserID:  a.ID,
+			Type:    notifications.ItemAssigned,
+			Title:   fmt.Sprintf("You were assigned to %s", after.Title),
+			TeamID:  &after.Board.TeamID,
+			BoardID: &after.BoardID,
+			ItemID:  &after.ID,
+		})
+	}
+	s.NotificationService.Send(actor.UserID, assigned...)
+}
+
+// NotifyDueSoon reminds the assignees of unfinished items due within the window. The due date
+// is part of the dedup key, so moving it sends a new reminder.
+func (s *ItemService) NotifyDueSoon(window time.Duration) error {
+	now := time.Now()
+	items, err := s.Repo.ListDueBetween(now, now.Add(window))
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		key := fmt.Sprintf("%s:%s:%s", notifications.ItemDueSoon, item.ID, item.DueDate.UTC().Format(time.RFC3339))
+		for _, a := range item.Assignees {
+			if err := s.NotificationService.Notify(&notifications.Notification{
+				UserID:   a.ID,
+				Type:     notifications.ItemDueSoon,
+				Title:    fmt.Sprintf("%s is due soon", item.Title),
+				TeamID:   &item.Board.TeamID,
+				BoardID:  &item.BoardID,
+				ItemID:   &item.ID,
+				DedupKey: &key,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// itemUpdateMessages reports every update, and status changes additionally as their own event.
+func itemUpdateMessages(before, after *Item) ([]*outbox.Message, error) {
+	updated, err := outbox.NewBoardMessage(after.Board.TeamID, after.BoardID, outbox.ItemUpdated, ToItemResponse(after))
+	if err != nil {
+		return nil, err
+	}
+	messages := []*outbox.Message{updated}
+
+	if before.Status != after.Status {
+		changed, err := outbox.NewBoardMessage(after.Board.TeamID, after.BoardID, outbox.ItemStatusChanged, ItemStatusChangedPayload{
+			Item: ToItemResponse(after),
+			From: before.Status,
+			To:   after.Status,
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, changed)
+	}
+	return messages, nil
+}
+
+// resolveAssignees checks that every assignee is an active member of the board's team.
+func (s *ItemService) resolveAssignees(teamID uuid.UUID, ids []uuid.UUID) ([]org.User, error) {
+	assignees := make([]org.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := s.UserService.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if user.DeactivatedAt != nil {
+			return nil, common.BadRequest("Deactivated users can't be assigned to items!")
+		}
+
+		member, err := s.BoardService.TeamService.IsMember(teamID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, common.BadRequest(fmt.Sprintf("User with id %s is not a member of the board's team!", id))
+		}
+
+		assignees = append(assignees, org.User{BaseEntity: common.BaseEntity{ID: id}})
+	}
+	return assignees, nil
+}
+
+func (s *ItemService) DeleteByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition) error {
+	item, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := ifMatch.Check(item.Version); err != nil {
+		return err
+	}
+
+	event, err := audit.NewEvent(actor, audit.ItemDeleted, audit.TargetItem, item.ID, ToItemResponse(item), nil)
+	if err != nil {
+		return err
+	}
+
+	message, err := outbox.NewBoardMessage(item.Board.TeamID, item.BoardID, outbox.ItemDeleted, ToItemResponse(item))
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteByID(id, event, message)
+}
+
+func (s *ItemService) ListByBoardID(boardID uuid.UUID, page, size int) (*common.PaginatedResponse[ItemResponse], error) {
+	if _, err := s.BoardService.findByID(boardID); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * size
+	items, total, err := s.Repo.ListByBoardID(boardID, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ItemResponse, 0, len(items))
+	for _, i := range items {
+		res = append(res, *ToItemResponse(&i))
+	}
+
+	return &common.PaginatedResponse[ItemResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+// trackChanges returns an activity for each tracked field that differs between the two versions of the item.
+func trackChanges(actor audit.Actor, before, after *Item) ([]ItemActivity, error) {
+	tracked := []struct {
+		field    string
+		old, new any
+	}{
+		{"status", before.Status, after.Status},
+		{"type", before.Type, after.Type},
+		{"parent", before.ParentID, after.ParentID},
+		{"priority", before.Priority, after.Priority},
+		{"estimate", before.Estimate, after.Estimate},
+		{"dueDate", before.DueDate, after.DueDate},
+		{"assignees", assigneeIDs(before), assigneeIDs(after)},
+	}
+
+	var activities []ItemActivity
+	for _, t := range tracked {
+		oldValue, err := json.Marshal(t.old)
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := json.Marshal(t.new)
+		if err != nil {
+			return nil, err
+		}
+		if string(oldValue) == string(newValue) {
+			continue
+		}
+
+		activities = append(activities, ItemActivity{
+			ItemID:   after.ID,
+			ActorID:  actor.UserID,
+			Field:    t.field,
+			OldValue: string(oldValue),
+			NewValue: string(newValue),
+		})
+	}
+	return activities, nil
+}
+
+// assigneeIDs returns the sorted assignee IDs, so reordering assignees isn't reported as a change.
+func assigneeIDs(item *Item) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(item.Assignees))
+	for _, a := range item.Assignees {
+		ids = append(ids, a.ID)
+	}
+	return sortedIDs(ids)
+}
+
+func equalIDs(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sortedIDs(ids []uuid.UUID) []uuid.UUID {
+	sorted := slices.Clone(ids)
+	if sorted == nil {
+		sorted = []uuid.UUID{}
+	}
+	slices.SortFunc(sorted, func(a, b uuid.UUID) int {
+		return slices.Compare(a[:], b[:])
+	})
+	return sorted
+}
+
+type CommentService struct {
+	Repo        CommentRepository
+	ItemService *ItemService
+	Validator   *validator.Validate
+}
+
+func NewCommentService(repo CommentRepository, itemService *ItemService, validator *validator.Validate) *CommentService {
+	return &CommentService{
+		Repo:        repo,
+		ItemService: itemService,
+		Validator:   validator,
+	}
+}
+
+func (s *CommentService) Create(actor audit.Actor, itemID uuid.UUID, req CreateCommentRequest) (*CommentResponse, error) {
+	if actor.UserID == nil {
+		return nil, common.Unauthorized("Authentication required!")
+	}
+
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	item, err := s.ItemService.findByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions, err := s.ItemService.resolveMentions(item.Board.TeamID, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &Comment{
+		BaseEntity: common.BaseEntity{ID: uuid.New(), CreatedAt: time.Now()},
+		Content:    req.Content,
+		UserID:     *actor.UserID,
+		ItemID:     itemID,
+	}
+	comment.setMentions(mentions)
+
+	message, err := outbox.NewBoardMessage(item.Board.TeamID, item.BoardID, outbox.CommentCreated, ToCommentResponse(comment))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(comment, message); err != nil {
+		return nil, err
+	}
+
+	s.ItemService.notifyMentioned(actor, nil, comment.Mentions, item, comment)
+	return ToCommentResponse(comment), nil
+}
+
+func (s *CommentService) ListByItemID(itemID uuid.UUID) ([]CommentResponse, error) {
+	if _, err := s.ItemService.findByID(itemID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.Repo.ListByItemID(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]CommentResponse, 0, len(comments))
+	for _, c := range comments {
+		res = append(res, *ToCommentResponse(&c))
+	}
+	return res, nil
+}
+
+// Activity returns the item's field changes merged with its comments, oldest first.
+func (s *CommentService) Activity(id uuid.UUID) ([]ActivityEntryResponse, error) {
+	if _, err := s.ItemService.findByID(id); err != nil {
+		return nil, err
+	}
+
+	activities, err := s.ItemService.Repo.ListActivities(id)
+	if err != nil {
+		return nil, err
+	}
+
+	itemComments, err := s.Repo.ListByItemID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ActivityEntryResponse, 0, len(activities)+len(itemComments))
+	for _, a := range activities {
+		entries = append(entries, ActivityEntryResponse{
+			Type:    ActivityChange,
+			At:      a.CreatedAt,
+			ActorID: a.ActorID,
+			Field:   a.Field,
+			From:    json.RawMessage(a.OldValue),
+			To:      json.RawMessage(a.NewValue),
+		})
+	}
+	for _, c := range itemComments {
+		entries = append(entries, ActivityEntryResponse{
+			Type:      ActivityComment,
+			At:        c.CreatedAt,
+			ActorID:   &c.UserID,
+			CommentID: &c.ID,
+			Content:   c.Content,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.Before(entries[j].At)
+	})
+	return entries, nil
+}
+
+type LinkService struct {
+	Repo        LinkRepository
+	ItemService *ItemService
+	Validator   *validator.Validate
+}
+
+func NewLinkService(repo LinkRepository, itemService *ItemService, validator *validator.Validate) *LinkService {
+	return &LinkService{
+		Repo:        repo,
+		ItemService: itemService,
+		Validator:   validator,
+	}
+}
+
+// Create links the item to another item of its team. Reverse types are stored as the forward
+// link from the other item, and relates_to links always go from the smaller ID, so each pair
+// of items has one link of each type.
+func (s *LinkService) Create(actor audit.Actor, itemID uuid.UUID, req CreateLinkRequest) (*LinkResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if req.ItemID == itemID {
+		return nil, common.BadRequest("An item can't be linked to itself!")
+	}
+
+	item, err := s.ItemService.findByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+	other, err := s.ItemService.findByID(req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Board.TeamID != other.Board.TeamID {
+		return nil, common.BadRequest("Only items of the same team can be linked!")
+	}
+
+	link := &ItemLink{
+		BaseEntity: common.BaseEntity{ID: uuid.New(), CreatedAt: time.Now()},
+		Source:     *item,
+		Target:     *other,
+	}
+	switch req.Type {
+	case LinkBlocks:
+		link.Type = Blocks
+	case LinkBlockedBy:
+		link.Type, link.Source, link.Target = Blocks, *other, *item
+	case LinkDuplicates:
+		link.Type = Duplicates
+	case LinkDuplicatedBy:
+		link.Type, link.Source, link.Target = Duplicates, *other, *item
+	case LinkRelatesTo:
+		link.Type = RelatesTo
+		if slices.Compare(other.ID[:], item.ID[:]) < 0 {
+			link.Source, link.Target = *other, *item
+		}
+	}
+	link.SourceID, link.TargetID = link.Source.ID, link.Target.ID
+
+	exists, err := s.Repo.Exists(link.SourceID, link.TargetID, link.Type)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, common.Conflict("The items are already linked!")
+	}
+
+	if link.Type == Blocks {
+		cycle, err := s.Repo.Blocks(link.TargetID, link.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			return nil, common.BadRequest("The link would make the items block each other!")
+		}
+	}
+
+	event, err := audit.NewEvent(actor, audit.ItemLinked, audit.TargetItem, itemID, nil, ToLinkResponse(link, itemID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(link, event); err != nil {
+		return nil, err
+	}
+	return ToLinkResponse(link, itemID), nil
+}
+
+func (s *LinkService) ListByItemID(itemID uuid.UUID) ([]LinkResponse, error) {
+	if _, err := s.ItemService.findByID(itemID); err != nil {
+		return nil, err
+	}
+
+	links, err := s.Repo.ListByItemID(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]LinkResponse, 0, len(links))
+	for _, l := range links {
+		res = append(res, *ToLinkResponse(&l, itemID))
+	}
+	return res, nil
+}
+
+func (s *LinkService) Delete(actor audit.Actor, itemID, id uuid.UUID) error {
+	link, err := s.Repo.GetByID(id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if link == nil || (link.SourceID != itemID && link.TargetID != itemID) {
+		return common.NotFound(fmt.Sprintf("Link with id %s was not found on item %s!", id, itemID))
+	}
+
+	event, err := audit.NewEvent(actor, audit.ItemUnlinked, audit.TargetItem, itemID, ToLinkResponse(link, itemID), nil)
+	if err != nil {
+		return err
+	}
+	return s.Repo.Delete(link, event)
+}
+
+type SprintService struct {
+	Repo         SprintRepository
+	BoardService *BoardService
+	ItemService  *ItemService
+	Validator    *validator.Validate
+}
+
+func NewSprintService(repo SprintRepository, boardService *BoardService, itemService *ItemService, validator *validator.Validate) *SprintService {
+	return &SprintService{
+		Repo:         repo,
+		BoardService: boardService,
+		ItemService:  itemService,
+		Validator:    validator,
+	}
+}
+
+func (s *SprintService) findByID(id uuid.UUID) (*Sprint, error) {
+	sprint, err := s.Repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Sprint with id %s was not found!", id))
+		}
+		return nil, err
+	}
+	return sprint, nil
+}
+
+func (s *SprintService) GetByID(id uuid.UUID) (*SprintResponse, error) {
+	sprint, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return ToSprintResponse(sprint), nil
+}
+
+func (s *SprintService) Create(actor audit.Actor, boardID uuid.UUID, req CreateSprintRequest) (*SprintResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	board, err := s.BoardService.findByID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	sprint := &Sprint{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		BoardID:    board.ID,
+		Name:       req.Name,
+		Goal:       req.Goal,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+		State:      SprintPlanned,
+	}
+
+	event, err := audit.NewEvent(actor, audit.SprintCreated, audit.TargetSprint, sprint.ID, nil, ToSprintResponse(sprint))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(sprint, event); err != nil {
+		return nil, err
+	}
+
+	return ToSprintResponse(sprint), nil
+}
+
+func (s *SprintService) ListByBoardID(boardID uuid.UUID, page, size int) (*common.PaginatedResponse[SprintResponse], error) {
+	if _, err := s.BoardService.findByID(boardID); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * size
+	sprints, total, err := s.Repo.ListByBoardID(boardID, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]SprintResponse, 0, len(sprints))
+	for _, sp := range sprints {
+		res = append(res, *ToSprintResponse(&sp))
+	}
+
+	return &common.PaginatedResponse[SprintResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+func (s *SprintService) ListItems(id uuid.UUID) ([]ItemResponse, error) {
+	if _, err := s.findByID(id); err != nil {
+		return nil, err
+	}
+
+	items, err := s.Repo.ListItems(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ItemResponse, 0, len(items))
+	for _, i := range items {
+		res = append(res, *ToItemResponse(&i))
+	}
+	return res, nil
+}
+
+// AddItems moves items of the sprint's board into the sprint, also from another open sprint.
+func (s *SprintService) AddItems(actor audit.Actor, id uuid.UUID, req SprintItemsRequest) ([]ItemResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	sprint, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if sprint.State == SprintClosed {
+		return nil, common.Conflict("Items can't be added to a closed sprint!")
+	}
+
+	var moved []uuid.UUID
+	var activities []ItemActivity
+	for _, itemID := range req.ItemIDs {
+		item, err := s.ItemService.findByID(itemID)
+		if err != nil {
+			return nil, err
+		}
+		if item.BoardID != sprint.BoardID {
+			return nil, common.BadRequest(fmt.Sprintf("Item with id %s is not on the sprint's board!", itemID))
+		}
+		if item.SprintID != nil && *item.SprintID == sprint.ID {
+			continue
+		}
+
+		activity, err := sprintActivity(actor, item.ID, item.SprintID, &sprint.ID)
+		if err != nil {
+			return nil, err
+		}
+		moved = append(moved, item.ID)
+		activities = append(activities, *activity)
+	}
+
+	if len(moved) > 0 {
+		if err := s.Repo.MoveItems(moved, &sprint.ID, activities); err != nil {
+			return nil, err
+		}
+	}
+	return s.ListItems(sprint.ID)
+}
+
+// RemoveItem moves the item from the sprint back to the backlog.
+func (s *SprintService) RemoveItem(actor audit.Actor, id, itemID uuid.UUID) error {
+	sprint, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+	if sprint.State == SprintClosed {
+		return common.Conflict("Items can't be removed from a closed sprint!")
+	}
+
+	item, err := s.ItemService.findByID(itemID)
+	if err != nil {
+		return err
+	}
+	if item.SprintID == nil || *item.SprintID != sprint.ID {
+		return common.NotFound(fmt.Sprintf("Item with id %s is not in the sprint!", itemID))
+	}
+
+	activity, err := sprintActivity(actor, item.ID, item.SprintID, nil)
+	if err != nil {
+		return err
+	}
+	return s.Repo.MoveItems([]uuid.UUID{item.ID}, nil, []ItemActivity{*activity})
+}
+
+func (s *SprintService) Start(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition) (*SprintResponse, error) {
+	sprint, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(sprint.Version); err != nil {
+		return nil, err
+	}
+
+	if sprint.State != SprintPlanned {
+		return nil, common.Conflict("Only planned sprints can be started!")
+	}
+
+	active, err := s.Repo.HasActive(sprint.BoardID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, common.Conflict("The board already has an active sprint!")
+	}
+
+	before := ToSprintResponse(sprint)
+	now := time.Now()
+	sprint.State = SprintActive
+	sprint.StartedAt = &now
+
+	event, err := audit.NewEvent(actor, audit.SprintStarted, audit.TargetSprint, sprint.ID, before, ToSprintResponse(sprint))
+	if err != nil {
+		return nil, err
+	}
+
+	// Another sprint of the board can start after the check above; the unique index catches it.
+	if err := s.Repo.Update(sprint, event); err != nil {
+		if common.IsUniqueViolation(err) {
+			return nil, common.Conflict("The board already has an active sprint!")
+		}
+		return nil, err
+	}
+
+	return ToSprintResponse(sprint), nil
+}
+
+// Complete closes the active sprint. Its unfinished items move to the next sprint if one is given
+// and back to the backlog otherwise, and a report of the sprint is recorded.
+func (s *SprintService) Complete(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, req CompleteSprintRequest) (*SprintReportResponse, error) {
+	sprint, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(sprint.Version); err != nil {
+		return nil, err
+	}
+
+	if sprint.State != SprintActive {
+		return nil, common.Conflict("Only active sprints can be completed!")
+	}
+
+	if req.NextSprintID != nil {
+		next, err := s.findByID(*req.NextSprintID)
+		if err != nil {
+			return nil, err
+		}
+		if next.BoardID != sprint.BoardID || next.State != SprintPlanned {
+			return nil, common.BadRequest("Unfinished items can only move to a planned sprint of the same board!")
+		}
+	}
+
+	before := ToSprintResponse(sprint)
+	now := time.Now()
+	sprint.State = SprintClosed
+	sprint.ClosedAt = &now
+
+	event, err := audit.NewEvent(actor, audit.SprintCompleted, audit.TargetSprint, sprint.ID, before, ToSprintResponse(sprint))
+	if err != nil {
+		return nil, err
+	}
+
+	var report *SprintReport
+	err = s.Repo.Complete(sprint, event, func(items []Item) (*SprintReport, []ItemActivity, error) {
+		r, activities, err := sprintReport(actor, sprint, req.NextSprintID, items)
+		report = r
+		return r, activities, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ToSprintReportResponse(report)
+}
+
+func (s *SprintService) GetReport(id uuid.UUID) (*SprintReportResponse, error) {
+	if _, err := s.findByID(id); err != nil {
+		return nil, err
+	}
+
+	report, err := s.Repo.GetReport(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Sprint with id %s has no report yet!", id))
+		}
+		return nil, err
+	}
+	return ToSprintReportResponse(report)
+}
+
+// sprintReport takes stock of the sprint's items as it closes. Every unfinished item gets an
+// activity for moving on to the next sprint, or back to the backlog if that is nil.
+func sprintReport(actor audit.Actor, sprint *Sprint, next *uuid.UUID, items []Item) (*SprintReport, []ItemActivity, error) {
+	report := &SprintReport{
+		BaseEntity:    common.BaseEntity{ID: uuid.New(), CreatedAt: time.Now()},
+		SprintID:      sprint.ID,
+		CarriedOverTo: next,
+	}
+	snapshot := make([]SprintReportItem, 0, len(items))
+	var activities []ItemActivity
+	for _, item := range items {
+		completed := item.Status == Done
+		snapshot = append(snapshot, SprintReportItem{
+			ItemID:    item.ID,
+			Title:     item.Title,
+			Status:    item.Status,
+			Completed: completed,
+		})
+		if completed {
+			report.CompletedItems++
+			continue
+		}
+
+		report.IncompleteItems++
+		activity, err := sprintActivity(actor, item.ID, &sprint.ID, next)
+		if err != nil {
+			return nil, nil, err
+		}
+		activities = append(activities, *activity)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Items = string(data)
+	return report, activities, nil
+}
+
+// sprintActivity records an item moving between sprints, where nil stands for the backlog.
+func sprintActivity(actor audit.Actor, itemID uuid.UUID, from, to *uuid.UUID) (*ItemActivity, error) {
+	oldValue, err := json.Marshal(from)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := json.Marshal(to)
+	if err != nil {
+		return nil, err
+	}
+	return &ItemActivity{
+		ItemID:   itemID,
+		ActorID:  actor.UserID,
+		Field:    "sprint",
+		OldValue: string(oldValue),
+		NewValue: string(newValue),
+	}, nil
+}
@@ -0,0 +1,1157 @@
+package backlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/notifications"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type boardRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *boardRepositoryMock) GetByID(id uuid.UUID) (*Board, error) {
+	args := m.Called(id)
+	if b := args.Get(0); b != nil {
+		return b.(*Board), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *boardRepositoryMock) Create(board *Board, event *audit.Event) error {
+	return m.Called(board, event).Error(0)
+}
+
+func (m *boardRepositoryMock) Update(board *Board, event *audit.Event) error {
+	return m.Called(board, event).Error(0)
+}
+
+func (m *boardRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return m.Called(id, event).Error(0)
+}
+
+func (m *boardRepositoryMock) ListByTeamID(teamID uuid.UUID, offset, limit int) ([]Board, int, error) {
+	args := m.Called(teamID, offset, limit)
+	return args.Get(0).([]Board), args.Int(1), args.Error(2)
+}
+
+func (m *boardRepositoryMock) CountItems(boardIDs ...uuid.UUID) (map[uuid.UUID]map[ItemStatus]ColumnCount, error) {
+	args := m.Called(boardIDs)
+	return args.Get(0).(map[uuid.UUID]map[ItemStatus]ColumnCount), args.Error(1)
+}
+
+type itemRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *itemRepositoryMock) GetByID(id uuid.UUID) (*Item, error) {
+	args := m.Called(id)
+	if i := args.Get(0); i != nil {
+		return i.(*Item), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *itemRepositoryMock) Create(item *Item, event *audit.Event, message *outbox.Message) error {
+	return m.Called(item, event, message).Error(0)
+}
+
+func (m *itemRepositoryMock) Update(item *Item, activities []ItemActivity, event *audit.Event, messages ...*outbox.Message) error {
+	return m.Called(item, activities, event, messages).Error(0)
+}
+
+func (m *itemRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event, message *outbox.Message) error {
+	return m.Called(id, event, message).Error(0)
+}
+
+func (m *itemRepositoryMock) ListByBoardID(boardID uuid.UUID, offset, limit int) ([]Item, int, error) {
+	args := m.Called(boardID, offset, limit)
+	return args.Get(0).([]Item), args.Int(1), args.Error(2)
+}
+
+func (m *itemRepositoryMock) ListDueBetween(from, to time.Time) ([]Item, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]Item), args.Error(1)
+}
+
+func (m *itemRepositoryMock) ListActivities(itemID uuid.UUID) ([]ItemActivity, error) {
+	args := m.Called(itemID)
+	return args.Get(0).([]ItemActivity), args.Error(1)
+}
+
+func (m *itemRepositoryMock) ListChildren(id uuid.UUID) ([]Item, error) {
+	args := m.Called(id)
+	return args.Get(0).([]Item), args.Error(1)
+}
+
+func (m *itemRepositoryMock) ListDescendants(id uuid.UUID) ([]Item, error) {
+	args := m.Called(id)
+	return args.Get(0).([]Item), args.Error(1)
+}
+
+func (m *itemRepositoryMock) ListAncestorIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(id)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *itemRepositoryMock) WithBoardLock(boardID uuid.UUID, fn func(repo ItemRepository) error) error {
+	if err := m.Called(boardID).Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+func (m *itemRepositoryMock) LastRank(boardID uuid.UUID, status ItemStatus) (string, error) {
+	args := m.Called(boardID, status)
+	return args.String(0), args.Error(1)
+}
+
+func (m *itemRepositoryMock) CountInColumn(column BoardColumn) (int, error) {
+	args := m.Called(column)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *itemRepositoryMock) Respace(column BoardColumn) error {
+	return m.Called(column).Error(0)
+}
+
+func (m *itemRepositoryMock) ListColumnsToRespace(limit int) ([]BoardColumn, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]BoardColumn), args.Error(1)
+}
+
+type commentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *commentRepositoryMock) Create(comment *Comment, message *outbox.Message) error {
+	return m.Called(comment, message).Error(0)
+}
+
+func (m *commentRepositoryMock) ListByItemID(itemID uuid.UUID) ([]Comment, error) {
+	args := m.Called(itemID)
+	return args.Get(0).([]Comment), args.Error(1)
+}
+
+type userRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *userRepositoryMock) GetByID(id uuid.UUID) (*org.User, error) {
+	args := m.Called(id)
+	if u := args.Get(0); u != nil {
+		return u.(*org.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *userRepositoryMock) GetByEmail(email string) (*org.User, error) {
+	args := m.Called(email)
+	if u := args.Get(0); u != nil {
+		return u.(*org.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *userRepositoryMock) Create(user *org.User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
+}
+
+func (m *userRepositoryMock) Update(user *org.User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
+}
+
+func (m *userRepositoryMock) ChangePassword(user *org.User, keepSessionID *uuid.UUID, event *audit.Event) error {
+	return m.Called(user, keepSessionID, event).Error(0)
+}
+
+func (m *userRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return m.Called(id, event).Error(0)
+}
+
+func (m *userRepositoryMock) List(offset, limit int, includeDeactivated bool) ([]org.User, int, error) {
+	args := m.Called(offset, limit, includeDeactivated)
+	return args.Get(0).([]org.User), args.Int(1), args.Error(2)
+}
+
+type teamRepositoryMock struct {
+	mock.Mock
+	org.TeamRepository
+}
+
+func (m *teamRepositoryMock) GetByID(id uuid.UUID) (*org.Team, error) {
+	args := m.Called(id)
+	if t := args.Get(0); t != nil {
+		return t.(*org.Team), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *teamRepositoryMock) IsMember(teamID, userID uuid.UUID) (bool, error) {
+	args := m.Called(teamID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *teamRepositoryMock) HasRole(teamID, userID uuid.UUID, role org.TeamRole) (bool, error) {
+	args := m.Called(teamID, userID, role)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *teamRepositoryMock) FindMembersByName(teamID uuid.UUID, names []string) ([]org.User, error) {
+	args := m.Called(teamID, names)
+	return args.Get(0).([]org.User), args.Error(1)
+}
+
+type notificationRepositoryMock struct {
+	mock.Mock
+	notifications.NotificationRepository
+}
+
+func (m *notificationRepositoryMock) IsEnabled(userID uuid.UUID, t notifications.NotificationType) (bool, error) {
+	args := m.Called(userID, t)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *notificationRepositoryMock) Create(n *notifications.Notification) error {
+	return m.Called(n).Error(0)
+}
+
+func (m *notificationRepositoryMock) GetEmailSettings(userID uuid.UUID) (*notifications.EmailSettings, error) {
+	args := m.Called(userID)
+	if s := args.Get(0); s != nil {
+		return s.(*notifications.EmailSettings), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type backlogMocks struct {
+	boards        *boardRepositoryMock
+	items         *itemRepositoryMock
+	comments      *commentRepositoryMock
+	users         *userRepositoryMock
+	teams         *teamRepositoryMock
+	notifications *notificationRepositoryMock
+}
+
+func setupBacklogTest() (*ItemService, *CommentService, *backlogMocks) {
+	v := validator.New()
+	m := &backlogMocks{
+		boards:   &boardRepositoryMock{},
+		items:    &itemRepositoryMock{},
+		comments: &commentRepositoryMock{},
+		users:    &userRepositoryMock{},
+		teams:    &teamRepositoryMock{},
+
+		notifications: &notificationRepositoryMock{},
+	}
+	m.items.On("WithBoardLock", mock.Anything).Return(nil).Maybe()
+	m.items.On("LastRank", mock.Anything, mock.Anything).Return("", nil).Maybe()
+	m.notifications.On("IsEnabled", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+	m.notifications.On("Create", mock.Anything).Return(nil).Maybe()
+	m.notifications.On("GetEmailSettings", mock.Anything).Return(nil, gorm.ErrRecordNotFound).Maybe()
+	userService := org.NewUserService(m.users, v, org.DefaultPasswordPolicy())
+	notificationService := notifications.NewNotificationService(m.notifications, nil, v, "", "")
+	teamService := org.NewTeamService(m.teams, userService, notificationService, v)
+	boardService := NewBoardService(m.boards, teamService, v)
+	itemService := NewItemService(m.items, boardService, userService, notificationService, v)
+	commentService := NewCommentService(m.comments, itemService, v)
+	return itemService, commentService, m
+}
+
+func testActor() audit.Actor {
+	id := uuid.New()
+	return audit.Actor{UserID: &id, IP: "127.0.0.1"}
+}
+
+func TestItemService_Create_AssigneeNotInTeam(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	assigneeID := uuid.New()
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.users.On("GetByID", assigneeID).Return(&org.User{BaseEntity: common.BaseEntity{ID: assigneeID}}, nil)
+	m.teams.On("IsMember", board.TeamID, assigneeID).Return(false, nil)
+
+	resp, err := service.Create(testActor(), board.ID, CreateItemRequest{Title: "Item", AssigneeIDs: []uuid.UUID{assigneeID}}, false)
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	m.items.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItemService_Create(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	actor := testActor()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	var message *outbox.Message
+	m.items.On("Create", mock.AnythingOfType("*backlog.Item"), mock.AnythingOfType("*audit.Event"), mock.AnythingOfType("*outbox.Message")).Run(func(args mock.Arguments) {
+		message = args.Get(2).(*outbox.Message)
+	}).Return(nil)
+
+	resp, err := service.Create(actor, board.ID, CreateItemRequest{Title: "Item"}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, NotPlanned, resp.Status)
+	assert.Equal(t, *actor.UserID, resp.AuthorID)
+	assert.Empty(t, resp.AssigneeIDs)
+	assert.Equal(t, board.TeamID, message.TeamID)
+	assert.Equal(t, outbox.ItemCreated, message.Event)
+}
+
+func TestItemService_Create_NotifiesAssignees(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	actor := testActor()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	assigneeID := uuid.New()
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	for _, id := range []uuid.UUID{assigneeID, *actor.UserID} {
+		m.users.On("GetByID", id).Return(&org.User{BaseEntity: common.BaseEntity{ID: id}}, nil)
+		m.teams.On("IsMember", board.TeamID, id).Return(true, nil)
+	}
+	m.items.On("Create", mock.AnythingOfType("*backlog.Item"), mock.AnythingOfType("*audit.Event"), mock.AnythingOfType("*outbox.Message")).Return(nil)
+
+	_, err := service.Create(actor, board.ID, CreateItemRequest{Title: "Item", AssigneeIDs: []uuid.UUID{assigneeID, *actor.UserID}}, false)
+
+	assert.NoError(t, err)
+	m.notifications.AssertNumberOfCalls(t, "Create", 1)
+	m.notifications.AssertCalled(t, "Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+		return n.UserID == assigneeID && n.Type == notifications.ItemAssigned && *n.ActorID == *actor.UserID && *n.BoardID == board.ID
+	}))
+}
+
+func TestItemService_NotifyDueSoon(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	due := time.Now().Add(time.Hour)
+	assigneeID := uuid.New()
+	item := Item{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		Title:      "Item",
+		BoardID:    uuid.New(),
+		DueDate:    &due,
+		Assignees:  []org.User{{BaseEntity: common.BaseEntity{ID: assigneeID}}},
+	}
+	m.items.On("ListDueBetween", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]Item{item}, nil)
+
+	err := service.NotifyDueSoon(24 * time.Hour)
+
+	assert.NoError(t, err)
+	m.notifications.AssertCalled(t, "Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+		return n.UserID == assigneeID && n.Type == notifications.ItemDueSoon && *n.ItemID == item.ID && n.DedupKey != nil
+	}))
+}
+
+func TestItemService_UpdateByID_TracksChanges(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	actor := testActor()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	assigneeID := uuid.New()
+	item := &Item{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		Title:      "Item",
+		Status:     ToDo,
+		Priority:   1,
+		BoardID:    board.ID,
+		Board:      *board,
+	}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.users.On("GetByID", assigneeID).Return(&org.User{BaseEntity: common.BaseEntity{ID: assigneeID}}, nil)
+	m.teams.On("IsMember", board.TeamID, assigneeID).Return(true, nil)
+
+	var recorded []ItemActivity
+	var messages []*outbox.Message
+	m.items.On("Update", item, mock.Anything, mock.MatchedBy(func(e *audit.Event) bool {
+		return e.Action == audit.ItemUpdated
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).([]ItemActivity)
+		messages = args.Get(3).([]*outbox.Message)
+	}).Return(nil)
+
+	status := InProgress
+	title := "Renamed"
+	priority := 1
+	assignees := []uuid.UUID{assigneeID}
+	resp, err := service.UpdateByID(actor, item.ID, nil, UpdateItemRequest{
+		Title:       &title,
+		Status:      &status,
+		Priority:    &priority,
+		AssigneeIDs: &assignees,
+	}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, InProgress, resp.Status)
+	assert.Len(t, recorded, 2)
+	assert.Equal(t, "status", recorded[0].Field)
+	assert.Equal(t, `"to_do"`, recorded[0].OldValue)
+	assert.Equal(t, `"in_progress"`, recorded[0].NewValue)
+	assert.Equal(t, actor.UserID, recorded[0].ActorID)
+	assert.Equal(t, "assignees", recorded[1].Field)
+	assert.Equal(t, `[]`, recorded[1].OldValue)
+	assert.Equal(t, `["`+assigneeID.String()+`"]`, recorded[1].NewValue)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, outbox.ItemUpdated, messages[0].Event)
+	assert.Equal(t, outbox.ItemStatusChanged, messages[1].Event)
+	assert.Equal(t, board.TeamID, messages[1].TeamID)
+	assert.Contains(t, messages[1].Payload, `"from":"to_do","to":"in_progress"`)
+}
+
+func TestItemService_PatchByID_ClearsDueDate(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	due := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	item := &Item{
+		BaseEntity:  common.BaseEntity{ID: uuid.New()},
+		Title:       "Item",
+		Description: "Details",
+		Status:      ToDo,
+		Type:        Task,
+		DueDate:     &due,
+	}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+
+	var recorded []ItemActivity
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).([]ItemActivity)
+	}).Return(nil)
+
+	resp, err := service.PatchByID(testActor(), item.ID, nil, []byte(`{"dueDate": null, "priority": 3}`), false)
+
+	assert.NoError(t, err)
+	assert.Nil(t, resp.DueDate)
+	assert.Equal(t, 3, resp.Priority)
+	assert.Equal(t, "Details", resp.Description)
+	assert.Len(t, recorded, 2)
+	assert.Equal(t, "priority", recorded[0].Field)
+	assert.Equal(t, "dueDate", recorded[1].Field)
+	assert.Equal(t, "null", recorded[1].NewValue)
+}
+
+func TestItemService_PatchByID_StatusChangeGoesToEndOfColumn(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	boardID := uuid.New()
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "Item", Status: ToDo, Type: Task, BoardID: boardID, Rank: "a"}
+	m.items.ExpectedCalls = nil
+	m.items.On("GetByID", item.ID).Return(item, nil)
+	m.items.On("WithBoardLock", boardID).Return(nil)
+	m.items.On("LastRank", boardID, InReview).Return("V", nil)
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Return(nil)
+
+	resp, err := service.PatchByID(testActor(), item.ID, nil, []byte(`{"status": "in_review"}`), false)
+
+	assert.NoError(t, err)
+	assert.Less(t, "V", resp.Rank)
+}
+
+func TestItemService_PatchByID_ValidatesMergedResult(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "Item", Status: ToDo, Type: Task}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+
+	for _, patch := range []string{
+		`{"title": null}`,
+		`{"status": "unknown"}`,
+		`{"version": 7}`,
+		`not json`,
+	} {
+		resp, err := service.PatchByID(testActor(), item.ID, nil, []byte(patch), false)
+
+		assert.Nil(t, resp, patch)
+		assert.Error(t, err, patch)
+	}
+	m.items.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItemService_DeleteByID_StaleVersion(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New(), Version: 5}}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+
+	err := service.DeleteByID(testActor(), item.ID, common.Precondition{4})
+
+	assert.Error(t, err)
+	m.items.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTrackChanges_IgnoresAssigneeOrder(t *testing.T) {
+	a, b := org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}}, org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	before := &Item{Assignees: []org.User{a, b}}
+	after := &Item{Assignees: []org.User{b, a}}
+
+	activities, err := trackChanges(testActor(), before, after)
+
+	assert.NoError(t, err)
+	assert.Empty(t, activities)
+}
+
+func TestCommentService_Activity_MergesComments(t *testing.T) {
+	_, service, m := setupBacklogTest()
+	itemID := uuid.New()
+	start := time.Now()
+	m.items.On("GetByID", itemID).Return(&Item{BaseEntity: common.BaseEntity{ID: itemID}}, nil)
+	m.items.On("ListActivities", itemID).Return([]ItemActivity{
+		{BaseEntity: common.BaseEntity{CreatedAt: start}, Field: "status", OldValue: `"to_do"`, NewValue: `"done"`},
+		{BaseEntity: common.BaseEntity{CreatedAt: start.Add(2 * time.Minute)}, Field: "priority", OldValue: `0`, NewValue: `2`},
+	}, nil)
+	m.comments.On("ListByItemID", itemID).Return([]Comment{
+		{BaseEntity: common.BaseEntity{ID: uuid.New(), CreatedAt: start.Add(time.Minute)}, Content: "Done!"},
+	}, nil)
+
+	entries, err := service.Activity(itemID)
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, ActivityChange, entries[0].Type)
+	assert.Equal(t, ActivityComment, entries[1].Type)
+	assert.Equal(t, "Done!", entries[1].Content)
+	assert.Equal(t, "priority", entries[2].Field)
+}
+
+func TestParseMentions(t *testing.T) {
+	handles := parseMentions("Hi @alice, can @Bob.Smith. review? Mail bob@example.com or ask @ALICE.")
+
+	assert.Equal(t, []string{"alice", "Bob.Smith"}, handles)
+}
+
+func TestReplaceMention(t *testing.T) {
+	text := ReplaceMention("Hi @alice, ask @ALICE. or @alice.b, not alice@example.com", "alice", "deleted-1")
+
+	assert.Equal(t, "Hi @deleted-1, ask @deleted-1. or @alice.b, not alice@example.com", text)
+}
+
+func TestCommentService_Create_ResolvesMentions(t *testing.T) {
+	_, service, m := setupBacklogTest()
+	actor := testActor()
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New(), Board: Board{TeamID: uuid.New()}}
+	alice := org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Alice"}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+	m.teams.On("FindMembersByName", item.Board.TeamID, []string{"alice", "outsider"}).Return([]org.User{alice}, nil)
+	m.comments.On("Create", mock.AnythingOfType("*backlog.Comment"), mock.AnythingOfType("*outbox.Message")).Return(nil)
+
+	resp, err := service.Create(actor, item.ID, CreateCommentRequest{Content: "@alice and @outsider, take a look"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "@alice and @outsider, take a look", resp.Content)
+	assert.Equal(t, []MentionResponse{{UserID: alice.ID, Username: "Alice", Handle: "alice"}}, resp.Mentions)
+	m.notifications.AssertCalled(t, "Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+		return n.UserID == alice.ID && n.Type == notifications.Mentioned && *n.CommentID == resp.ID
+	}))
+}
+
+func TestItemService_UpdateByID_NotifiesNewMentionsOnly(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	alice := org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "alice"}
+	bob := org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "bob"}
+	item := &Item{
+		BaseEntity:  common.BaseEntity{ID: uuid.New()},
+		Description: "Ask @alice",
+		Board:       Board{TeamID: uuid.New()},
+		Mentions:    []Mention{{UserID: alice.ID, User: alice, Handle: "alice"}},
+	}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+	m.teams.On("FindMembersByName", item.Board.TeamID, []string{"alice", "bob"}).Return([]org.User{alice, bob}, nil)
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Return(nil)
+	description := "Ask @alice or @bob"
+
+	resp, err := service.UpdateByID(testActor(), item.ID, nil, UpdateItemRequest{Description: &description}, false)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Mentions, 2)
+	m.notifications.AssertNumberOfCalls(t, "Create", 1)
+	m.notifications.AssertCalled(t, "Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+		return n.UserID == bob.ID && n.Type == notifications.Mentioned && n.CommentID == nil
+	}))
+}
+
+func TestItemService_GetByID_MentionOfRenamedUser(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	userID := uuid.New()
+	item := &Item{
+		BaseEntity:  common.BaseEntity{ID: uuid.New()},
+		Description: "Ask @oldname",
+		Mentions:    []Mention{{UserID: userID, User: org.User{BaseEntity: common.BaseEntity{ID: userID}, Name: "newname"}, Handle: "oldname"}},
+	}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+
+	resp, err := service.GetByID(item.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Ask @oldname", resp.Description)
+	assert.Equal(t, []MentionResponse{{UserID: userID, Username: "newname", Handle: "oldname"}}, resp.Mentions)
+}
+
+func TestItemService_Create_SubtaskUnderEpic(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	epic := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: board.ID, Type: Epic}
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.items.On("GetByID", epic.ID).Return(epic, nil)
+
+	resp, err := service.Create(testActor(), board.ID, CreateItemRequest{Title: "Item", Type: Subtask, ParentID: &epic.ID}, false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	m.items.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItemService_Create_ParentOnAnotherBoard(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	story := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New(), Type: Story}
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.items.On("GetByID", story.ID).Return(story, nil)
+
+	resp, err := service.Create(testActor(), board.ID, CreateItemRequest{Title: "Item", Type: Subtask, ParentID: &story.ID}, false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+}
+
+func TestItemService_UpdateByID_ParentUnderItself(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	boardID := uuid.New()
+	story := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Type: Story}
+	task := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Type: Task, ParentID: &story.ID}
+	// The types allow the task under any story, so only the ancestors give the cycle away.
+	other := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Type: Story, ParentID: &task.ID}
+	m.items.On("GetByID", task.ID).Return(task, nil)
+	m.items.On("GetByID", other.ID).Return(other, nil)
+	m.items.On("ListAncestorIDs", other.ID).Return([]uuid.UUID{other.ID, task.ID, story.ID}, nil)
+
+	resp, err := service.UpdateByID(testActor(), task.ID, nil, UpdateItemRequest{ParentID: &other.ID}, false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	m.items.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItemService_PatchByID_TypeDoesNotFitChildren(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	story := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "Story", Status: ToDo, Type: Story}
+	m.items.On("GetByID", story.ID).Return(story, nil)
+	m.items.On("ListChildren", story.ID).Return([]Item{{Type: Subtask}}, nil)
+
+	resp, err := service.PatchByID(testActor(), story.ID, nil, []byte(`{"type": "epic"}`), false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	m.items.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItemService_Tree_RollsUpDescendants(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	estimate := func(v float64) *float64 { return &v }
+	epic := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Type: Epic}
+	story := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Type: Story, ParentID: &epic.ID, Status: InProgress, Estimate: estimate(5)}
+	done := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Type: Subtask, ParentID: &story.ID, Status: Done, Estimate: estimate(2)}
+	open := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Type: Subtask, ParentID: &story.ID, Status: ToDo, Estimate: estimate(3)}
+	bug := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Type: Bug, ParentID: &epic.ID, Status: Done}
+	m.items.On("GetByID", epic.ID).Return(epic, nil)
+	m.items.On("ListDescendants", epic.ID).Return([]Item{story, done, open, bug}, nil)
+
+	resp, err := service.Tree(epic.ID)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Children, 2)
+	assert.Equal(t, RollupResponse{Items: 4, DoneItems: 2, Progress: 0.5, Estimate: 10, DoneEstimate: 2}, resp.Rollup)
+	assert.Equal(t, story.ID, resp.Children[0].Item.ID)
+	assert.Len(t, resp.Children[0].Children, 2)
+	assert.Equal(t, RollupResponse{Items: 2, DoneItems: 1, Progress: 0.5, Estimate: 5, DoneEstimate: 2}, resp.Children[0].Rollup)
+	assert.Empty(t, resp.Children[1].Children)
+}
+
+type linkRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *linkRepositoryMock) GetByID(id uuid.UUID) (*ItemLink, error) {
+	args := m.Called(id)
+	if l := args.Get(0); l != nil {
+		return l.(*ItemLink), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *linkRepositoryMock) Create(link *ItemLink, event *audit.Event) error {
+	return m.Called(link, event).Error(0)
+}
+
+func (m *linkRepositoryMock) Delete(link *ItemLink, event *audit.Event) error {
+	return m.Called(link, event).Error(0)
+}
+
+func (m *linkRepositoryMock) ListByItemID(itemID uuid.UUID) ([]ItemLink, error) {
+	args := m.Called(itemID)
+	return args.Get(0).([]ItemLink), args.Error(1)
+}
+
+func (m *linkRepositoryMock) Exists(sourceID, targetID uuid.UUID, linkType LinkType) (bool, error) {
+	args := m.Called(sourceID, targetID, linkType)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *linkRepositoryMock) Blocks(sourceID, targetID uuid.UUID) (bool, error) {
+	args := m.Called(sourceID, targetID)
+	return args.Bool(0), args.Error(1)
+}
+
+func setupLinkTest() (*LinkService, *linkRepositoryMock, *backlogMocks) {
+	itemService, _, m := setupBacklogTest()
+	repo := &linkRepositoryMock{}
+	return NewLinkService(repo, itemService, itemService.Validator), repo, m
+}
+
+// teamItems returns two items on different boards of the same team.
+func teamItems(m *backlogMocks) (*Item, *Item) {
+	teamID := uuid.New()
+	a := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "A", BoardID: uuid.New(), Board: Board{TeamID: teamID}}
+	b := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "B", BoardID: uuid.New(), Board: Board{TeamID: teamID}}
+	m.items.On("GetByID", a.ID).Return(a, nil)
+	m.items.On("GetByID", b.ID).Return(b, nil)
+	return a, b
+}
+
+func TestLinkService_Create_BlockedByIsStoredReversed(t *testing.T) {
+	service, repo, m := setupLinkTest()
+	a, b := teamItems(m)
+	repo.On("Exists", b.ID, a.ID, Blocks).Return(false, nil)
+	repo.On("Blocks", a.ID, b.ID).Return(false, nil)
+	var link *ItemLink
+	repo.On("Create", mock.AnythingOfType("*backlog.ItemLink"), auditEvent(audit.ItemLinked)).Run(func(args mock.Arguments) {
+		link = args.Get(0).(*ItemLink)
+	}).Return(nil)
+
+	resp, err := service.Create(testActor(), a.ID, CreateLinkRequest{Type: LinkBlockedBy, ItemID: b.ID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, LinkBlockedBy, resp.Type)
+	assert.Equal(t, b.ID, resp.ItemID)
+	assert.Equal(t, b.BoardID, resp.BoardID)
+	assert.Equal(t, b.ID, link.SourceID)
+	assert.Equal(t, a.ID, link.TargetID)
+	assert.Equal(t, LinkBlocks, ToLinkResponse(link, b.ID).Type)
+}
+
+func TestLinkService_Create_AnotherTeam(t *testing.T) {
+	service, repo, m := setupLinkTest()
+	a, b := teamItems(m)
+	b.Board.TeamID = uuid.New()
+
+	resp, err := service.Create(testActor(), a.ID, CreateLinkRequest{Type: LinkRelatesTo, ItemID: b.ID})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestLinkService_Create_BlockingCycle(t *testing.T) {
+	service, repo, m := setupLinkTest()
+	a, b := teamItems(m)
+	repo.On("Exists", a.ID, b.ID, Blocks).Return(false, nil)
+	repo.On("Blocks", b.ID, a.ID).Return(true, nil)
+
+	resp, err := service.Create(testActor(), a.ID, CreateLinkRequest{Type: LinkBlocks, ItemID: b.ID})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestLinkService_Create_AlreadyLinked(t *testing.T) {
+	service, repo, m := setupLinkTest()
+	a, b := teamItems(m)
+	repo.On("Exists", mock.Anything, mock.Anything, RelatesTo).Return(true, nil)
+
+	resp, err := service.Create(testActor(), a.ID, CreateLinkRequest{Type: LinkRelatesTo, ItemID: b.ID})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 409, err.(*common.ApiError).StatusCode)
+}
+
+func TestItemService_UpdateByID_StartingBlockedItem(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	blocker := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "Blocker", Status: InReview}
+	item := &Item{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		Status:     ToDo,
+		BlockedBy:  []ItemLink{{SourceID: blocker.ID, Source: blocker, Type: Blocks}},
+	}
+	m.items.On("GetByID", item.ID).Return(item, nil)
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Return(nil)
+	status := InProgress
+
+	resp, err := service.UpdateByID(testActor(), item.ID, nil, UpdateItemRequest{Status: &status}, false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 409, err.(*common.ApiError).StatusCode)
+	assert.Contains(t, err.Error(), "Blocker")
+
+	item.Status = ToDo
+	item.BlockedBy[0].Source.Status = Done
+	resp, err = service.UpdateByID(testActor(), item.ID, nil, UpdateItemRequest{Status: &status}, false)
+
+	assert.NoError(t, err)
+	assert.False(t, resp.Blocked)
+}
+
+type sprintRepositoryMock struct {
+	mock.Mock
+	report     *SprintReport
+	activities []ItemActivity
+}
+
+func (m *sprintRepositoryMock) GetByID(id uuid.UUID) (*Sprint, error) {
+	args := m.Called(id)
+	if s := args.Get(0); s != nil {
+		return s.(*Sprint), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *sprintRepositoryMock) Create(sprint *Sprint, event *audit.Event) error {
+	return m.Called(sprint, event).Error(0)
+}
+
+func (m *sprintRepositoryMock) Update(sprint *Sprint, event *audit.Event) error {
+	return m.Called(sprint, event).Error(0)
+}
+
+func (m *sprintRepositoryMock) ListByBoardID(boardID uuid.UUID, offset, limit int) ([]Sprint, int, error) {
+	args := m.Called(boardID, offset, limit)
+	return args.Get(0).([]Sprint), args.Int(1), args.Error(2)
+}
+
+func (m *sprintRepositoryMock) HasActive(boardID uuid.UUID) (bool, error) {
+	args := m.Called(boardID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *sprintRepositoryMock) ListItems(sprintID uuid.UUID) ([]Item, error) {
+	args := m.Called(sprintID)
+	return args.Get(0).([]Item), args.Error(1)
+}
+
+func (m *sprintRepositoryMock) MoveItems(itemIDs []uuid.UUID, sprintID *uuid.UUID, activities []ItemActivity) error {
+	return m.Called(itemIDs, sprintID, activities).Error(0)
+}
+
+// Complete hands the items it was given to report, and keeps what report returns.
+func (m *sprintRepositoryMock) Complete(sprint *Sprint, event *audit.Event, report func(items []Item) (*SprintReport, []ItemActivity, error)) error {
+	args := m.Called(sprint, event)
+	if err := args.Error(1); err != nil {
+		return err
+	}
+	var err error
+	m.report, m.activities, err = report(args.Get(0).([]Item))
+	return err
+}
+
+func (m *sprintRepositoryMock) GetReport(sprintID uuid.UUID) (*SprintReport, error) {
+	args := m.Called(sprintID)
+	if r := args.Get(0); r != nil {
+		return r.(*SprintReport), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// auditEvent matches the event a repository is asked to record alongside a change.
+func auditEvent(action string) any {
+	return mock.MatchedBy(func(e *audit.Event) bool {
+		return e.Action == action
+	})
+}
+
+func setupSprintTest() (*SprintService, *sprintRepositoryMock, *backlogMocks) {
+	itemService, _, m := setupBacklogTest()
+	repo := &sprintRepositoryMock{}
+	return NewSprintService(repo, itemService.BoardService, itemService, itemService.Validator), repo, m
+}
+
+func TestRankBetween(t *testing.T) {
+	ranks := []string{"", "01", "1", "V", "V0V", "V1", "z", "zz"}
+	for i := 0; i < len(ranks); i++ {
+		for j := i + 1; j < len(ranks); j++ {
+			a, b := ranks[i], ranks[j]
+			if b == "" {
+				continue
+			}
+			rank, err := rankBetween(a, b)
+
+			assert.NoError(t, err)
+			assert.Less(t, a, rank)
+			assert.Less(t, rank, b)
+			assert.NotEqual(t, byte('0'), rank[len(rank)-1])
+		}
+	}
+
+	last, err := rankBetween("zz", "")
+	assert.NoError(t, err)
+	assert.Less(t, "zz", last)
+
+	_, err = rankBetween("V", "V")
+	assert.ErrorIs(t, err, errRankOrder)
+}
+
+func TestSpacedRanks(t *testing.T) {
+	ranks := spacedRanks(1000)
+
+	assert.Len(t, ranks, 1000)
+	for i := 1; i < len(ranks); i++ {
+		assert.Less(t, ranks[i-1], ranks[i])
+	}
+}
+
+func TestItemService_Create_RanksAfterLastItem(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.items.ExpectedCalls = nil
+	m.items.On("WithBoardLock", board.ID).Return(nil)
+	m.items.On("LastRank", board.ID, NotPlanned).Return("V", nil)
+	m.items.On("Create", mock.AnythingOfType("*backlog.Item"), mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := service.Create(testActor(), board.ID, CreateItemRequest{Title: "Item"}, false)
+
+	assert.NoError(t, err)
+	assert.Less(t, "V", resp.Rank)
+}
+
+func TestItemService_Move_BetweenNeighbours(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	boardID := uuid.New()
+	above := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: ToDo, Rank: "A"}
+	below := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: ToDo, Rank: "B"}
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: InReview, Rank: "x"}
+	for _, i := range []*Item{above, below, item} {
+		m.items.On("GetByID", i.ID).Return(i, nil)
+	}
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Return(nil)
+
+	resp, err := service.Move(testActor(), item.ID, nil, MoveItemRequest{Status: ToDo, BeforeID: &above.ID, AfterID: &below.ID}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ToDo, resp.Status)
+	assert.Less(t, "A", resp.Rank)
+	assert.Less(t, resp.Rank, "B")
+	m.items.AssertNotCalled(t, "Respace", mock.Anything)
+}
+
+func TestItemService_Move_TiedNeighboursRespacesColumn(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	boardID := uuid.New()
+	above := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: ToDo, Rank: "A"}
+	below := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: ToDo, Rank: "A"}
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: ToDo, Rank: "x"}
+	for _, i := range []*Item{above, below, item} {
+		m.items.On("GetByID", i.ID).Return(i, nil)
+	}
+	m.items.On("Respace", BoardColumn{BoardID: boardID, Status: ToDo}).Run(func(mock.Arguments) {
+		above.Rank, below.Rank, item.Rank = "1", "2", "3"
+	}).Return(nil)
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Return(nil)
+
+	resp, err := service.Move(testActor(), item.ID, nil, MoveItemRequest{Status: ToDo, BeforeID: &above.ID, AfterID: &below.ID}, false)
+
+	assert.NoError(t, err)
+	assert.Less(t, "1", resp.Rank)
+	assert.Less(t, resp.Rank, "2")
+	m.items.AssertCalled(t, "Respace", BoardColumn{BoardID: boardID, Status: ToDo})
+}
+
+func TestItemService_Move_NeighbourInAnotherColumn(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	boardID := uuid.New()
+	neighbour := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: Done, Rank: "A"}
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, Status: ToDo, Rank: "x"}
+	m.items.On("GetByID", neighbour.ID).Return(neighbour, nil)
+	m.items.On("GetByID", item.ID).Return(item, nil)
+
+	resp, err := service.Move(testActor(), item.ID, nil, MoveItemRequest{Status: ToDo, AfterID: &neighbour.ID}, false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	m.items.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBoardService_RequireMember_NotAMember(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &BoardResponse{ID: uuid.New(), TeamID: uuid.New()}
+	p := &common.Principal{UserID: uuid.New()}
+	m.teams.On("IsMember", board.TeamID, p.UserID).Return(false, nil)
+
+	err := service.BoardService.RequireMember(p, board)
+
+	assert.Equal(t, 403, err.(*common.ApiError).StatusCode)
+}
+
+func TestBoardService_GetByID_CountsColumns(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, WIPLimits: `{"in_progress": 3}`}
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.boards.On("CountItems", []uuid.UUID{board.ID}).Return(map[uuid.UUID]map[ItemStatus]ColumnCount{
+		board.ID: {InProgress: {Items: 2, Blocked: 1}, Done: {Items: 5}},
+	}, nil)
+
+	resp, err := service.BoardService.GetByID(board.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[ItemStatus]int{InProgress: 3}, resp.WIPLimits)
+	assert.Len(t, resp.Columns, len(itemStatuses))
+	for _, c := range resp.Columns {
+		switch c.Status {
+		case InProgress:
+			assert.Equal(t, 2, c.Count)
+			assert.Equal(t, 1, c.Blocked)
+			assert.Equal(t, 3, *c.Limit)
+		case Done:
+			assert.Equal(t, 5, c.Count)
+			assert.Zero(t, c.Blocked)
+			assert.Nil(t, c.Limit)
+		default:
+			assert.Zero(t, c.Count)
+			assert.Zero(t, c.Blocked)
+			assert.Nil(t, c.Limit)
+		}
+	}
+}
+
+func TestItemService_Create_ColumnAtWIPLimit(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := &Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New(), WIPLimits: `{"to_do": 2}`}
+	m.boards.On("GetByID", board.ID).Return(board, nil)
+	m.items.On("CountInColumn", BoardColumn{BoardID: board.ID, Status: ToDo}).Return(2, nil)
+
+	resp, err := service.Create(testActor(), board.ID, CreateItemRequest{Title: "Item", Status: ToDo}, false)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 409, err.(*common.ApiError).StatusCode)
+	assert.Contains(t, err.Error(), "2 items")
+	assert.Equal(t, WIPLimitDetails{Status: ToDo, Count: 2, Limit: 2}, err.(*common.ApiError).Details)
+	m.items.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItemService_Move_OverrideWIPLimit(t *testing.T) {
+	service, _, m := setupBacklogTest()
+	board := Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New(), WIPLimits: `{"in_progress": 1}`}
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: board.ID, Board: board, Status: ToDo, Rank: "V"}
+	manager, developer := testActor(), testActor()
+	m.items.On("GetByID", item.ID).Return(item, nil)
+	m.items.On("CountInColumn", BoardColumn{BoardID: board.ID, Status: InProgress}).Return(1, nil)
+	m.items.On("Update", item, mock.Anything, mock.AnythingOfType("*audit.Event"), mock.Anything).Return(nil)
+	m.teams.On("HasRole", board.TeamID, *manager.UserID, org.ProjectManager).Return(true, nil)
+	m.teams.On("HasRole", board.TeamID, *developer.UserID, org.ProjectManager).Return(false, nil)
+	req := MoveItemRequest{Status: InProgress}
+
+	_, err := service.Move(manager, item.ID, nil, req, false)
+	assert.Equal(t, 409, err.(*common.ApiError).StatusCode)
+
+	item.Status = ToDo
+	_, err = service.Move(developer, item.ID, nil, req, true)
+	assert.Equal(t, 403, err.(*common.ApiError).StatusCode)
+
+	item.Status = ToDo
+	resp, err := service.Move(manager, item.ID, nil, req, true)
+	assert.NoError(t, err)
+	assert.Equal(t, InProgress, resp.Status)
+}
+
+func TestSprintService_Start(t *testing.T) {
+	service, repo, _ := setupSprintTest()
+	sprint := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New(), Version: 1}, BoardID: uuid.New(), State: SprintPlanned}
+	repo.On("GetByID", sprint.ID).Return(sprint, nil)
+	repo.On("HasActive", sprint.BoardID).Return(false, nil)
+	repo.On("Update", sprint, auditEvent(audit.SprintStarted)).Return(nil)
+
+	resp, err := service.Start(testActor(), sprint.ID, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, SprintActive, resp.State)
+	assert.NotNil(t, resp.StartedAt)
+}
+
+func TestSprintService_Start_AnotherSprintActive(t *testing.T) {
+	service, repo, _ := setupSprintTest()
+	sprint := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New(), State: SprintPlanned}
+	repo.On("GetByID", sprint.ID).Return(sprint, nil)
+	repo.On("HasActive", sprint.BoardID).Return(true, nil)
+
+	resp, err := service.Start(testActor(), sprint.ID, nil)
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestSprintService_Start_ConcurrentStart(t *testing.T) {
+	service, repo, _ := setupSprintTest()
+	sprint := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New(), Version: 1}, BoardID: uuid.New(), State: SprintPlanned}
+	repo.On("GetByID", sprint.ID).Return(sprint, nil)
+	repo.On("HasActive", sprint.BoardID).Return(false, nil)
+	repo.On("Update", sprint, auditEvent(audit.SprintStarted)).Return(&pgconn.PgError{Code: "23505"})
+
+	resp, err := service.Start(testActor(), sprint.ID, nil)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 409, err.(*common.ApiError).StatusCode)
+}
+
+func TestSprintService_Complete_CarriesOverUnfinishedItems(t *testing.T) {
+	service, repo, _ := setupSprintTest()
+	boardID := uuid.New()
+	sprint := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, State: SprintActive}
+	next := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: boardID, State: SprintPlanned}
+	done := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "Done", Status: Done}
+	open := Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, Title: "Open", Status: InProgress}
+	repo.On("GetByID", sprint.ID).Return(sprint, nil)
+	repo.On("GetByID", next.ID).Return(next, nil)
+	repo.On("Complete", sprint, auditEvent(audit.SprintCompleted)).Return([]Item{done, open}, nil)
+
+	resp, err := service.Complete(testActor(), sprint.ID, nil, CompleteSprintRequest{NextSprintID: &next.ID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, SprintClosed, sprint.State)
+	assert.Equal(t, 1, resp.CompletedItems)
+	assert.Equal(t, 1, resp.IncompleteItems)
+	assert.Equal(t, &next.ID, repo.report.CarriedOverTo)
+	assert.Len(t, resp.Items, 2)
+	assert.Len(t, repo.activities, 1)
+	assert.Equal(t, open.ID, repo.activities[0].ItemID)
+	assert.Equal(t, "sprint", repo.activities[0].Field)
+}
+
+func TestSprintService_Complete_NextSprintOnAnotherBoard(t *testing.T) {
+	service, repo, _ := setupSprintTest()
+	sprint := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New(), State: SprintActive}
+	next := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New(), State: SprintPlanned}
+	repo.On("GetByID", sprint.ID).Return(sprint, nil)
+	repo.On("GetByID", next.ID).Return(next, nil)
+
+	resp, err := service.Complete(testActor(), sprint.ID, nil, CompleteSprintRequest{NextSprintID: &next.ID})
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+}
+
+func TestSprintService_AddItems_ItemFromAnotherBoard(t *testing.T) {
+	service, repo, m := setupSprintTest()
+	sprint := &Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New(), State: SprintPlanned}
+	item := &Item{BaseEntity: common.BaseEntity{ID: uuid.New()}, BoardID: uuid.New()}
+	repo.On("GetByID", sprint.ID).Return(sprint, nil)
+	m.items.On("GetByID", item.ID).Return(item, nil)
+
+	resp, err := service.AddItems(testActor(), sprint.ID, SprintItemsRequest{ItemIDs: []uuid.UUID{item.ID}})
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "MoveItems", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeTeamsRead  = "teams:read"
+	ScopeTeamsWrite = "teams:write"
+	ScopeItemsRead  = "items:read"
+	ScopeItemsWrite = "items:write"
+	ScopeAdmin      = "admin"
+)
+
+type Principal struct {
+	UserID  uuid.UUID
+	IsAdmin bool
+	// Scopes is nil for interactive sessions, which aren't restricted.
+	Scopes []string
+	// SessionID is set for interactive sessions only.
+	SessionID *uuid.UUID
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p.Scopes == nil || slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PrincipalFromContext(r.Context()); !ok {
+			WriteError(w, Unauthorized("Authentication required!"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope rejects authenticated callers whose token wasn't granted the scope.
+// Anonymous access is left to RequireAuth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p, ok := PrincipalFromContext(r.Context()); ok && !p.HasScope(scope) {
+				WriteError(w, Forbidden("Token is missing the "+scope+" scope!"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			WriteError(w, Unauthorized("Authentication required!"))
+			return
+		}
+		if !p.IsAdmin || !p.HasScope(ScopeAdmin) {
+			WriteError(w, Forbidden("Admin access required!"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
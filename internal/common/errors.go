@@ -1,10 +1,20 @@
 package common
 
-import "net/http"
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
 
 type ApiError struct {
 	StatusCode int    `json:"statusCode"`
 	Message    string `json:"message"`
+	RetryAfter int    `json:"retryAfter,omitempty"`
+	// Details is an optional payload for clients that need more than the message.
+	Details any `json:"details,omitempty"`
 }
 
 func (e *ApiError) Error() string {
@@ -25,6 +35,64 @@ func BadRequest(msg string) *ApiError {
 	}
 }
 
+func Unauthorized(msg string) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusUnauthorized,
+		Message:    msg,
+	}
+}
+
+func Forbidden(msg string) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusForbidden,
+		Message:    msg,
+	}
+}
+
+func Conflict(msg string) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusConflict,
+		Message:    msg,
+	}
+}
+
+func PreconditionFailed(msg string) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusPreconditionFailed,
+		Message:    msg,
+	}
+}
+
+func UnsupportedMediaType(msg string) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusUnsupportedMediaType,
+		Message:    msg,
+	}
+}
+
+func UnprocessableEntity(msg string) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    msg,
+	}
+}
+
+func TooManyRequests(msg string, retryAfter time.Duration) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusTooManyRequests,
+		Message:    msg,
+		RetryAfter: int(math.Ceil(retryAfter.Seconds())),
+	}
+}
+
+func Locked(msg string, retryAfter time.Duration) *ApiError {
+	return &ApiError{
+		StatusCode: http.StatusLocked,
+		Message:    msg,
+		RetryAfter: int(math.Ceil(retryAfter.Seconds())),
+	}
+}
+
 func InternalServerError(msg string) *ApiError {
 	return &ApiError{
 		StatusCode: http.StatusInternalServerError,
@@ -32,6 +100,12 @@ func InternalServerError(msg string) *ApiError {
 	}
 }
 
+// IsUniqueViolation tells whether the database rejected a write for breaking a unique index.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 type ErrorResponse struct {
 	Error ApiError `json:"error"`
 }
@@ -3,7 +3,9 @@ package common
 import (
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
+	"strconv"
 )
 
 func WriteError(w http.ResponseWriter, err error) {
@@ -11,6 +13,9 @@ func WriteError(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if errors.As(err, &apiError) {
+		if apiError.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(apiError.RetryAfter))
+		}
 		w.WriteHeader(apiError.StatusCode)
 		_ = json.NewEncoder(w).Encode(&ErrorResponse{Error: *apiError})
 		return
@@ -27,3 +32,11 @@ func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(v)
 }
+
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
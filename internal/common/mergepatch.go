@@ -0,0 +1,80 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const MergePatchContentType = "application/merge-patch+json"
+
+const maxPatchSize = 1 << 20
+
+// ReadMergePatch returns the body of a PATCH request after checking it's a JSON merge patch.
+func ReadMergePatch(r *http.Request) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != MergePatchContentType {
+		return nil, UnsupportedMediaType("PATCH requests must use " + MergePatchContentType + "!")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize+1))
+	if err != nil {
+		return nil, BadRequest(err.Error())
+	}
+	if len(body) > maxPatchSize {
+		return nil, BadRequest("Patch is too large!")
+	}
+	return body, nil
+}
+
+// MergePatch applies an RFC 7396 merge patch to the JSON form of original and decodes the result into dst.
+// Members set to null are removed, so they decode to the zero value of their field. Unknown members are rejected.
+func MergePatch(original any, patch []byte, dst any) error {
+	doc, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+
+	var target, p any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return BadRequest("Patch is not valid JSON!")
+	}
+
+	merged, err := json.Marshal(mergeValue(target, p))
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return BadRequest(err.Error())
+	}
+	return nil
+}
+
+func mergeValue(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+	return targetObject
+}
@@ -11,11 +11,16 @@ type BaseEntity struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Version is bumped on every update and exposed as the ETag of the resource.
+	Version int `gorm:"not null;default:1"`
 }
 
 func (b *BaseEntity) BeforeCreate(*gorm.DB) (err error) {
 	if b.ID == uuid.Nil {
 		b.ID = uuid.New()
 	}
+	if b.Version == 0 {
+		b.Version = 1
+	}
 	return
 }
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict is returned when a row was changed after it was read.
+var ErrVersionConflict = PreconditionFailed("The resource was modified by someone else, reload it and try again!")
+
+// SaveVersioned updates all columns of value, but only if the row still has the version that was read.
+// On success the version of the entity is bumped.
+func SaveVersioned(tx *gorm.DB, value any, entity *BaseEntity) error {
+	expected := entity.Version
+	entity.Version++
+
+	res := tx.Model(value).
+		Where("version = ?", expected).
+		Select("*").
+		Omit(clause.Associations).
+		Updates(value)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrVersionConflict
+	}
+	if res.Error != nil {
+		entity.Version = expected
+	}
+	return res.Error
+}
+
+// Precondition holds the versions listed in an If-Match header. A nil Precondition matches any version.
+type Precondition []int
+
+func ParseIfMatch(r *http.Request) Precondition {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+
+	p := Precondition{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+
+		// Versions change on every write, so weak and strong comparison are the same.
+		tag = strings.TrimPrefix(tag, "W/")
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err == nil {
+			p = append(p, version)
+		}
+	}
+	return p
+}
+
+func (p Precondition) Check(version int) error {
+	if p == nil {
+		return nil
+	}
+	for _, v := range p {
+		if v == version {
+			return nil
+		}
+	}
+	return PreconditionFailed("The resource has changed since it was fetched!")
+}
+
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodically calls job every interval until ctx is cancelled. Errors are logged and the job keeps running.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(); err != nil {
+				log.Printf("%s failed: %v", name, err)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -13,11 +14,66 @@ type Config struct {
 	DBName    string
 	DBSSLMode string
 	ApiPort   int
+
+	AppBaseURL string
+
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string
+	MailFrom string
+
+	SessionTTL        time.Duration
+	LoginChallengeTTL time.Duration
+	TOTPIssuer        string
+
+	LoginMaxAccountFailures int
+	LoginMaxIPFailures      int
+	LoginFailureWindow      time.Duration
+	LoginLockoutDuration    time.Duration
+	LoginDelayBase          time.Duration
+	LoginDelayMax           time.Duration
+
+	ErasureWorkerInterval time.Duration
+
+	IdempotencyKeyTTL          time.Duration
+	IdempotencyCleanupInterval time.Duration
+
+	WebhookWorkerInterval time.Duration
+	WebhookMaxAttempts    int
+	WebhookBackoffBase    time.Duration
+	WebhookBackoffMax     time.Duration
+	WebhookTimeout        time.Duration
+
+	BoardEventRetention       time.Duration
+	BoardEventCleanupInterval time.Duration
+
+	PresenceHeartbeatInterval time.Duration
+
+	DueSoonWindow       time.Duration
+	DueReminderInterval time.Duration
+
+	RankRespaceInterval time.Duration
+
+	NotificationEmailInterval time.Duration
+	UnsubscribeSecret         string
+
+	PasswordResetTTL      time.Duration
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
 }
 
 func Load() Config {
 	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
 	apiPort, _ := strconv.Atoi(getEnv("GOLLAB_API_PORT", "8080"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	passwordMinLength, _ := strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8"))
+	loginMaxAccountFailures, _ := strconv.Atoi(getEnv("LOGIN_MAX_ACCOUNT_FAILURES", "5"))
+	loginMaxIPFailures, _ := strconv.Atoi(getEnv("LOGIN_MAX_IP_FAILURES", "50"))
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
 	return Config{
 		DBHost:    getEnv("DB_HOST", "localhost"),
 		DBPort:    dbPort,
@@ -26,6 +82,56 @@ func Load() Config {
 		DBName:    getEnv("DB_NAME", "gollab_db"),
 		DBSSLMode: getEnv("DB_SSL_MODE", "disable"),
 		ApiPort:   apiPort,
+
+		AppBaseURL: getEnv("GOLLAB_APP_BASE_URL", "http://localhost:3000"),
+
+		SMTPHost: getEnv("SMTP_HOST", ""),
+		SMTPPort: smtpPort,
+		SMTPUser: getEnv("SMTP_USER", ""),
+		SMTPPass: getEnv("SMTP_PASS", ""),
+		MailFrom: getEnv("MAIL_FROM", "no-reply@gollab.local"),
+
+		SessionTTL:        getEnvDuration("SESSION_TTL", 24*time.Hour),
+		LoginChallengeTTL: getEnvDuration("LOGIN_CHALLENGE_TTL", 5*time.Minute),
+		TOTPIssuer:        getEnv("TOTP_ISSUER", "Gollab"),
+
+		LoginMaxAccountFailures: loginMaxAccountFailures,
+		LoginMaxIPFailures:      loginMaxIPFailures,
+		LoginFailureWindow:      getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration:    getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginDelayBase:          getEnvDuration("LOGIN_DELAY_BASE", time.Second),
+		LoginDelayMax:           getEnvDuration("LOGIN_DELAY_MAX", 30*time.Second),
+
+		ErasureWorkerInterval: getEnvDuration("ERASURE_WORKER_INTERVAL", time.Minute),
+
+		IdempotencyKeyTTL:          getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		IdempotencyCleanupInterval: getEnvDuration("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+
+		WebhookWorkerInterval: getEnvDuration("WEBHOOK_WORKER_INTERVAL", 5*time.Second),
+		WebhookMaxAttempts:    webhookMaxAttempts,
+		WebhookBackoffBase:    getEnvDuration("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+		WebhookBackoffMax:     getEnvDuration("WEBHOOK_BACKOFF_MAX", 6*time.Hour),
+		WebhookTimeout:        getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		BoardEventRetention:       getEnvDuration("BOARD_EVENT_RETENTION", 15*time.Minute),
+		BoardEventCleanupInterval: getEnvDuration("BOARD_EVENT_CLEANUP_INTERVAL", time.Minute),
+
+		PresenceHeartbeatInterval: getEnvDuration("PRESENCE_HEARTBEAT_INTERVAL", 30*time.Second),
+
+		DueSoonWindow:       getEnvDuration("DUE_SOON_WINDOW", 24*time.Hour),
+		DueReminderInterval: getEnvDuration("DUE_REMINDER_INTERVAL", 15*time.Minute),
+
+		RankRespaceInterval: getEnvDuration("RANK_RESPACE_INTERVAL", 5*time.Minute),
+
+		NotificationEmailInterval: getEnvDuration("NOTIFICATION_EMAIL_INTERVAL", time.Minute),
+		UnsubscribeSecret:         getEnv("UNSUBSCRIBE_SECRET", ""),
+
+		PasswordResetTTL:      getEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+		PasswordMinLength:     passwordMinLength,
+		PasswordRequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
 	}
 }
 
@@ -35,3 +141,19 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -12,12 +12,14 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func Connect(cfg config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
+func DSN(cfg config.Config) string {
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
 		cfg.DBHost, cfg.DBUser, cfg.DBPass, cfg.DBName, cfg.DBPort, cfg.DBSSLMode,
 	)
+}
 
+func Connect(cfg config.Config) (*gorm.DB, error) {
 	dbLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
 		logger.Config{
@@ -27,7 +29,7 @@ func Connect(cfg config.Config) (*gorm.DB, error) {
 		},
 	)
 
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{
+	return gorm.Open(postgres.Open(DSN(cfg)), &gorm.Config{
 		DisableForeignKeyConstraintWhenMigrating: true,
 		Logger:                                   dbLogger,
 	})
@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+const (
+	maxKeyLength = 255
+	maxBodySize  = 1 << 20
+)
+
+// Middleware makes authenticated POST requests carrying an Idempotency-Key header safe to retry.
+// Anonymous requests are passed through, since there is no caller to keep their keys apart. So are
+// paths under the excluded prefixes, because their responses contain secrets that mustn't be stored.
+func Middleware(service *IdempotencyService, excludedPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			p, ok := common.PrincipalFromContext(r.Context())
+			if r.Method != http.MethodPost || key == "" || !ok || isExcluded(r.URL.Path, excludedPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxKeyLength {
+				common.WriteError(w, common.BadRequest("Idempotency key is too long!"))
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+			if err != nil || len(body) > maxBodySize {
+				common.WriteError(w, common.BadRequest("Request body can't be read!"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			claimed, stored, err := service.Begin(p.UserID.String(), key, RequestHash(r, body))
+			if err != nil {
+				common.WriteError(w, err)
+				return
+			}
+
+			if stored != nil {
+				replay(w, stored)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if err := service.Finish(claimed, rec.status, w.Header(), rec.body.Bytes()); err != nil {
+				log.Printf("storing idempotent response failed: %v", err)
+			}
+		})
+	}
+}
+
+func isExcluded(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func replay(w http.ResponseWriter, k *Key) {
+	var headers map[string]string
+	_ = json.Unmarshal([]byte(k.ResponseHeaders), &headers)
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(k.StatusCode)
+	_, _ = w.Write(k.ResponseBody)
+}
+
+// recorder passes the response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,25 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+)
+
+// Key remembers the outcome of a request sent with an Idempotency-Key header.
+// CompletedAt is nil while the first request is still being handled.
+type Key struct {
+	common.BaseEntity
+	Scope           string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_idempotency_scope_key"`
+	Key             string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_scope_key"`
+	RequestHash     string    `gorm:"type:varchar(64);not null"`
+	StatusCode      int       `gorm:"not null;default:0"`
+	ResponseHeaders string    `gorm:"type:jsonb;not null;default:'{}'"`
+	ResponseBody    []byte    `gorm:"type:bytea"`
+	ExpiresAt       time.Time `gorm:"not null;index"`
+	CompletedAt     *time.Time
+}
+
+func (Key) TableName() string {
+	return "idempotency_keys"
+}
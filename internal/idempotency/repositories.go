@@ -0,0 +1,67 @@
+package idempotency
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KeyRepository interface {
+	Claim(key *Key, abandonedBefore time.Time) (bool, error)
+	Get(scope, key string) (*Key, error)
+	Complete(key *Key) error
+	Release(key *Key) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type keyRepository struct {
+	DB *gorm.DB
+}
+
+func NewKeyRepository(db *gorm.DB) KeyRepository {
+	return &keyRepository{DB: db}
+}
+
+// Claim inserts the key unless another request already holds it. Keys whose request never
+// completed, e.g. because the replica crashed, can be taken over once they are old enough.
+func (r *keyRepository) Claim(key *Key, abandonedBefore time.Time) (bool, error) {
+	res := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if res.Error != nil || res.RowsAffected == 1 {
+		return res.Error == nil, res.Error
+	}
+
+	res = r.DB.Model(&Key{}).
+		Where("scope = ? AND key = ? AND request_hash = ?", key.Scope, key.Key, key.RequestHash).
+		Where("completed_at IS NULL AND updated_at < ?", abandonedBefore).
+		Update("updated_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *keyRepository) Get(scope, key string) (*Key, error) {
+	var k Key
+	if err := r.DB.First(&k, "scope = ? AND key = ?", scope, key).Error; err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *keyRepository) Complete(key *Key) error {
+	return r.DB.Model(&Key{}).
+		Where("scope = ? AND key = ?", key.Scope, key.Key).
+		Updates(map[string]any{
+			"status_code":      key.StatusCode,
+			"response_headers": key.ResponseHeaders,
+			"response_body":    key.ResponseBody,
+			"completed_at":     key.CompletedAt,
+		}).Error
+}
+
+func (r *keyRepository) Release(key *Key) error {
+	return r.DB.Delete(&Key{}, "scope = ? AND key = ? AND completed_at IS NULL", key.Scope, key.Key).Error
+}
+
+func (r *keyRepository) DeleteExpired(now time.Time) (int64, error) {
+	res := r.DB.Delete(&Key{}, "expires_at < ?", now)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"gorm.io/gorm"
+)
+
+// abandonAfter is how long an unfinished request holds its key before a retry may take it over.
+const abandonAfter = time.Minute
+
+// replayedHeaders are the response headers stored with the snapshot.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+type IdempotencyService struct {
+	Repo KeyRepository
+	TTL  time.Duration
+}
+
+func NewIdempotencyService(repo KeyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		Repo: repo,
+		TTL:  ttl,
+	}
+}
+
+func RequestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims the key for a new request. If the key was already used, the stored response is
+// returned for replay, or an error when the request differs or the first one is still running.
+func (s *IdempotencyService) Begin(scope, key, requestHash string) (*Key, *Key, error) {
+	now := time.Now()
+	k := &Key{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(s.TTL),
+	}
+
+	claimed, err := s.Repo.Claim(k, now.Add(-abandonAfter))
+	if err != nil {
+		return nil, nil, err
+	}
+	if claimed {
+		return k, nil, nil
+	}
+
+	existing, err := s.Repo.Get(scope, key)
+	if err != nil {
+		// The key expired or was released between the two queries.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, common.Conflict("Request with this idempotency key is being retried, try again!")
+		}
+		return nil, nil, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, nil, common.UnprocessableEntity("Idempotency key was already used with a different request!")
+	}
+
+	if existing.CompletedAt == nil {
+		return nil, nil, &common.ApiError{
+			StatusCode: http.StatusConflict,
+			Message:    "Request with this idempotency key is still being processed!",
+			RetryAfter: 1,
+		}
+	}
+	return nil, existing, nil
+}
+
+// Finish stores the response of a claimed key. Server errors release the key, so the client can retry.
+func (s *IdempotencyService) Finish(k *Key, status int, header http.Header, body []byte) error {
+	if status >= http.StatusInternalServerError {
+		return s.Repo.Release(k)
+	}
+
+	headers := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if v := header.Get(name); v != "" {
+			headers[name] = v
+		}
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	k.StatusCode = status
+	k.ResponseHeaders = string(encoded)
+	k.ResponseBody = body
+	k.CompletedAt = &now
+	return s.Repo.Complete(k)
+}
+
+func (s *IdempotencyService) DeleteExpired() error {
+	_, err := s.Repo.DeleteExpired(time.Now())
+	return err
+}
@@ -0,0 +1,202 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// keyRepositoryMock keeps keys in memory with the same claim semantics as the database.
+type keyRepositoryMock struct {
+	mu   sync.Mutex
+	keys map[string]*Key
+}
+
+func newKeyRepositoryMock() *keyRepositoryMock {
+	return &keyRepositoryMock{keys: make(map[string]*Key)}
+}
+
+func (m *keyRepositoryMock) Claim(key *Key, abandonedBefore time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.keys[key.Scope+"/"+key.Key]
+	if !ok {
+		key.UpdatedAt = time.Now()
+		m.keys[key.Scope+"/"+key.Key] = key
+		return true, nil
+	}
+	if existing.RequestHash == key.RequestHash && existing.CompletedAt == nil && existing.UpdatedAt.Before(abandonedBefore) {
+		existing.UpdatedAt = time.Now()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *keyRepositoryMock) Get(scope, key string) (*Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[scope+"/"+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *k
+	return &copied, nil
+}
+
+func (m *keyRepositoryMock) Complete(key *Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.Scope+"/"+key.Key] = key
+	return nil
+}
+
+func (m *keyRepositoryMock) Release(key *Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key.Scope+"/"+key.Key)
+	return nil
+}
+
+func (m *keyRepositoryMock) DeleteExpired(now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for id, k := range m.keys {
+		if k.ExpiresAt.Before(now) {
+			delete(m.keys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type countingHandler struct {
+	calls  int
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/teams/1")
+	w.WriteHeader(h.status)
+	_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `}`))
+}
+
+func setupMiddlewareTest(status int) (http.Handler, *countingHandler, *keyRepositoryMock) {
+	repo := newKeyRepositoryMock()
+	handler := &countingHandler{status: status}
+	service := NewIdempotencyService(repo, time.Hour)
+	return Middleware(service, "/auth")(handler), handler, repo
+}
+
+var testUserID = uuid.New()
+
+// post sends the request as the test user.
+func post(h http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req = req.WithContext(common.WithPrincipal(req.Context(), &common.Principal{UserID: testUserID}))
+	return send(h, req, key)
+}
+
+func send(h http.Handler, req *http.Request, key string) *httptest.ResponseRecorder {
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	h, handler, _ := setupMiddlewareTest(http.StatusCreated)
+
+	first := post(h, "/teams", "key-1", `{"name":"Team"}`)
+	second := post(h, "/teams", "key-1", `{"name":"Team"}`)
+
+	assert.Equal(t, 1, handler.calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "/teams/1", second.Header().Get("Location"))
+	assert.Equal(t, "true", second.Header().Get(HeaderReplayed))
+	assert.Empty(t, first.Header().Get(HeaderReplayed))
+}
+
+func TestMiddleware_DifferentBodyIsRejected(t *testing.T) {
+	h, handler, _ := setupMiddlewareTest(http.StatusCreated)
+
+	post(h, "/teams", "key-1", `{"name":"Team"}`)
+	rec := post(h, "/teams", "key-1", `{"name":"Other"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 1, handler.calls)
+}
+
+func TestMiddleware_ServerErrorReleasesKey(t *testing.T) {
+	h, handler, repo := setupMiddlewareTest(http.StatusInternalServerError)
+
+	post(h, "/teams", "key-1", `{}`)
+	assert.Empty(t, repo.keys)
+
+	post(h, "/teams", "key-1", `{}`)
+	assert.Equal(t, 2, handler.calls)
+}
+
+func TestMiddleware_InProgressIsConflict(t *testing.T) {
+	h, handler, repo := setupMiddlewareTest(http.StatusCreated)
+	req := httptest.NewRequest(http.MethodPost, "/teams", strings.NewReader(`{}`))
+	repo.keys[testUserID.String()+"/key-1"] = &Key{
+		Scope:       testUserID.String(),
+		Key:         "key-1",
+		RequestHash: RequestHash(req, []byte(`{}`)),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	repo.keys[testUserID.String()+"/key-1"].UpdatedAt = time.Now()
+
+	rec := post(h, "/teams", "key-1", `{}`)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, 0, handler.calls)
+}
+
+func TestMiddleware_PassesThrough(t *testing.T) {
+	h, handler, repo := setupMiddlewareTest(http.StatusCreated)
+
+	post(h, "/teams", "", `{}`)
+	post(h, "/auth/tokens", "key-1", `{}`)
+	post(h, "/auth/tokens", "key-1", `{}`)
+
+	assert.Equal(t, 3, handler.calls)
+	assert.Empty(t, repo.keys)
+}
+
+func TestMiddleware_AnonymousPassesThrough(t *testing.T) {
+	h, handler, repo := setupMiddlewareTest(http.StatusCreated)
+
+	send(h, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`)), "key-1")
+	send(h, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`)), "key-1")
+
+	assert.Equal(t, 2, handler.calls)
+	assert.Empty(t, repo.keys)
+}
+
+func TestIdempotencyService_DeleteExpired(t *testing.T) {
+	repo := newKeyRepositoryMock()
+	service := NewIdempotencyService(repo, time.Hour)
+	repo.keys["a/old"] = &Key{ExpiresAt: time.Now().Add(-time.Minute)}
+	repo.keys["a/new"] = &Key{ExpiresAt: time.Now().Add(time.Minute)}
+
+	assert.NoError(t, service.DeleteExpired())
+	assert.Len(t, repo.keys, 1)
+	assert.Contains(t, repo.keys, "a/new")
+}
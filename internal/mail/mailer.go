@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/StefanShivarov/gollab-backend/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	// HTML is an optional alternative to the plain text body.
+	HTML    string
+	Headers map[string]string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+func NewMailer(cfg config.Config) Mailer {
+	if cfg.SMTPHost == "" {
+		return &LogMailer{}
+	}
+	return &SMTPMailer{
+		Host: cfg.SMTPHost,
+		Port: cfg.SMTPPort,
+		User: cfg.SMTPUser,
+		Pass: cfg.SMTPPass,
+		From: cfg.MailFrom,
+	}
+}
+
+type SMTPMailer struct {
+	Host string
+	Port int
+	User string
+	Pass string
+	From string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.User != "" {
+		auth = smtp.PlainAuth("", m.User, m.Pass, m.Host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	for k, v := range msg.Headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+		b.WriteString(msg.Body)
+	} else if err := writeAlternative(&b, msg); err != nil {
+		return err
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	return smtp.SendMail(addr, auth, m.From, []string{msg.To}, []byte(b.String()))
+}
+
+// writeAlternative writes the text and HTML bodies as a multipart/alternative message, so
+// clients that can't render HTML fall back to the text.
+func writeAlternative(b *strings.Builder, msg Message) error {
+	w := multipart.NewWriter(b)
+	fmt.Fprintf(b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", w.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Body},
+		{"text/html", msg.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {part.contentType + "; charset=\"utf-8\""},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write([]byte(part.body)); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// LogMailer is used when no SMTP server is configured, e.g. for local development.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// CaptureMailer keeps the messages instead of sending them, so tests can inspect them.
+type CaptureMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *CaptureMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (m *CaptureMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io/fs"
+	texttemplate "text/template"
+)
+
+// Templates renders the bodies of a message from a pair of templates, "<name>.html.tmpl" for
+// the HTML body and "<name>.txt.tmpl" for the text one.
+type Templates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+func ParseTemplates(fsys fs.FS) (*Templates, error) {
+	html, err := htmltemplate.ParseFS(fsys, "*.html.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.ParseFS(fsys, "*.txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return &Templates{html: html, text: text}, nil
+}
+
+// Render fills in the message's text and HTML bodies.
+func (t *Templates) Render(msg *Message, name string, data any) error {
+	var text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&text, name+".txt.tmpl", data); err != nil {
+		return err
+	}
+	if err := t.html.ExecuteTemplate(&html, name+".html.tmpl", data); err != nil {
+		return err
+	}
+	msg.Body = text.String()
+	msg.HTML = html.String()
+	return nil
+}
@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationResponse struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      NotificationType `json:"type"`
+	Title     string           `json:"title"`
+	ActorID   *uuid.UUID       `json:"actorId,omitempty"`
+	TeamID    *uuid.UUID       `json:"teamId,omitempty"`
+	BoardID   *uuid.UUID       `json:"boardId,omitempty"`
+	ItemID    *uuid.UUID       `json:"itemId,omitempty"`
+	CommentID *uuid.UUID       `json:"commentId,omitempty"`
+	Read      bool             `json:"read"`
+	ReadAt    *time.Time       `json:"readAt,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func ToNotificationResponse(n *Notification) *NotificationResponse {
+	return &NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		Title:     n.Title,
+		ActorID:   n.ActorID,
+		TeamID:    n.TeamID,
+		BoardID:   n.BoardID,
+		ItemID:    n.ItemID,
+		CommentID: n.CommentID,
+		Read:      n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+type UnreadCountResponse struct {
+	Count int `json:"count"`
+}
+
+type MarkAllReadResponse struct {
+	Updated int `json:"updated"`
+}
+
+type PreferenceRequest struct {
+	Type    NotificationType `json:"type" validate:"required,oneof=item_assigned mentioned team_added item_due_soon"`
+	Enabled *bool            `json:"enabled" validate:"required"`
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences []PreferenceRequest `json:"preferences" validate:"required,min=1,dive"`
+}
+
+type PreferenceResponse struct {
+	Type    NotificationType `json:"type"`
+	Enabled bool             `json:"enabled"`
+}
+
+type EmailSettingsRequest struct {
+	Delivery EmailDelivery `json:"delivery" validate:"required,oneof=immediate hourly daily off"`
+}
+
+type EmailSettingsResponse struct {
+	Delivery EmailDelivery `json:"delivery"`
+}
+
+type UnsubscribeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,168 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+type NotificationHandler struct {
+	Service *NotificationService
+}
+
+func NewNotificationHandler(service *NotificationService) *NotificationHandler {
+	return &NotificationHandler{Service: service}
+}
+
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if size <= 0 {
+		size = 10
+	}
+
+	unreadOnly, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.List(principal.UserID, unreadOnly, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.UnreadCount(principal.UserID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "notificationId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.MarkRead(principal.UserID, id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) MarkUnread(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "notificationId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.MarkUnread(principal.UserID, id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.MarkAllRead(principal.UserID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.GetPreferences(principal.UserID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var req UpdatePreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.UpdatePreferences(principal.UserID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) GetEmailSettings(w http.ResponseWriter, r *http.Request) {
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.GetEmailSettings(principal.UserID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) UpdateEmailSettings(w http.ResponseWriter, r *http.Request) {
+	var req EmailSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.UpdateEmailSettings(principal.UserID, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	var req UnsubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	if err := h.Service.Unsubscribe(req); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
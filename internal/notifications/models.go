@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	ItemAssigned NotificationType = "item_assigned"
+	Mentioned    NotificationType = "mentioned"
+	TeamAdded    NotificationType = "team_added"
+	ItemDueSoon  NotificationType = "item_due_soon"
+)
+
+var Types = []NotificationType{ItemAssigned, Mentioned, TeamAdded, ItemDueSoon}
+
+type Notification struct {
+	common.BaseEntity
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index"`
+	Type      NotificationType `gorm:"type:varchar(30);not null"`
+	Title     string           `gorm:"type:varchar(255);not null"`
+	ActorID   *uuid.UUID       `gorm:"type:uuid"`
+	TeamID    *uuid.UUID       `gorm:"type:uuid"`
+	BoardID   *uuid.UUID       `gorm:"type:uuid"`
+	ItemID    *uuid.UUID       `gorm:"type:uuid"`
+	CommentID *uuid.UUID       `gorm:"type:uuid"`
+	// DedupKey keeps workers from sending the same reminder twice, e.g. from both replicas.
+	DedupKey *string `gorm:"type:varchar(255)"`
+	ReadAt   *time.Time
+	// EmailPending marks notifications the email worker hasn't sent yet.
+	EmailPending bool `gorm:"not null;default:false"`
+}
+
+// Preference turns a notification type off or back on for a user. Types without a row are enabled.
+type Preference struct {
+	UserID  uuid.UUID        `gorm:"type:uuid;primaryKey"`
+	Type    NotificationType `gorm:"type:varchar(30);primaryKey"`
+	Enabled bool             `gorm:"not null"`
+}
+
+func (Preference) TableName() string {
+	return "notification_preferences"
+}
+
+type EmailDelivery string
+
+const (
+	EmailImmediate EmailDelivery = "immediate"
+	EmailHourly    EmailDelivery = "hourly"
+	EmailDaily     EmailDelivery = "daily"
+	EmailOff       EmailDelivery = "off"
+)
+
+// DigestInterval is how often a digest is sent for the delivery. It's zero for the others.
+func (d EmailDelivery) DigestInterval() time.Duration {
+	switch d {
+	case EmailHourly:
+		return time.Hour
+	case EmailDaily:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// EmailSettings controls how a user gets their notifications by email. Users without a row
+// get them immediately.
+type EmailSettings struct {
+	UserID       uuid.UUID     `gorm:"type:uuid;primaryKey"`
+	Delivery     EmailDelivery `gorm:"type:varchar(20);not null"`
+	LastDigestAt *time.Time
+}
+
+func (EmailSettings) TableName() string {
+	return "notification_email_settings"
+}
+
+// Recipient is the user an email goes to.
+type Recipient struct {
+	ID       uuid.UUID
+	Name     string
+	Email    string
+	Delivery EmailDelivery
+}
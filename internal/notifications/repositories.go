@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	Create(n *Notification) error
+	GetByID(id uuid.UUID) (*Notification, error)
+	List(userID uuid.UUID, unreadOnly bool, offset, limit int) ([]Notification, int, error)
+	CountUnread(userID uuid.UUID) (int, error)
+	SetReadAt(id uuid.UUID, readAt *time.Time) error
+	MarkAllRead(userID uuid.UUID, readAt time.Time) (int64, error)
+	IsEnabled(userID uuid.UUID, t NotificationType) (bool, error)
+	ListPreferences(userID uuid.UUID) ([]Preference, error)
+	SavePreferences(prefs []Preference) error
+	GetEmailSettings(userID uuid.UUID) (*EmailSettings, error)
+	SaveEmailSettings(settings *EmailSettings) error
+	ListEmailRecipients(now time.Time, limit int) ([]Recipient, error)
+	ClaimEmails(userID uuid.UUID, now time.Time, limit int, send func(notifications []Notification) error) (int, error)
+}
+
+type notificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{DB: db}
+}
+
+// Create skips notifications whose dedup key the user already got.
+func (r *notificationRepository) Create(n *Notification) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(n).Error
+}
+
+func (r *notificationRepository) GetByID(id uuid.UUID) (*Notification, error) {
+	var n Notification
+	if err := r.DB.First(&n, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *notificationRepository) List(userID uuid.UUID, unreadOnly bool, offset, limit int) ([]Notification, int, error) {
+	var notifications []Notification
+	var total int64
+
+	query := r.DB.Model(&Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query.Count(&total)
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, int(total), nil
+}
+
+func (r *notificationRepository) CountUnread(userID uuid.UUID) (int, error) {
+	var count int64
+	err := r.DB.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return int(count), err
+}
+
+func (r *notificationRepository) SetReadAt(id uuid.UUID, readAt *time.Time) error {
+	return r.DB.Model(&Notification{}).Where("id = ?", id).Update("read_at", readAt).Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID uuid.UUID, readAt time.Time) (int64, error) {
+	res := r.DB.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", readAt)
+	return res.RowsAffected, res.Error
+}
+
+func (r *notificationRepository) IsEnabled(userID uuid.UUID, t NotificationType) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Preference{}).Where("user_id = ? AND type = ? AND NOT enabled", userID, t).Count(&count).Error
+	return count == 0, err
+}
+
+func (r *notificationRepository) ListPreferences(userID uuid.UUID) ([]Preference, error) {
+	var prefs []Preference
+	err := r.DB.Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *notificationRepository) SavePreferences(prefs []Preference) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&prefs).Error
+}
+
+func (r *notificationRepository) GetEmailSettings(userID uuid.UUID) (*EmailSettings, error) {
+	var settings EmailSettings
+	if err := r.DB.First(&settings, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveEmailSettings stores the user's delivery. Turning email off drops the emails still pending.
+func (r *notificationRepository) SaveEmailSettings(settings *EmailSettings) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"delivery"}),
+		}).Create(settings).Error; err != nil {
+			return err
+		}
+		if settings.Delivery != EmailOff {
+			return nil
+		}
+		return tx.Model(&Notification{}).
+			Where("user_id = ? AND email_pending", settings.UserID).
+			Update("email_pending", false).Error
+	})
+}
+
+// ListEmailRecipients returns the active users with pending emails whose delivery is due:
+// immediately, or once their last digest is older than the digest interval.
+func (r *notificationRepository) ListEmailRecipients(now time.Time, limit int) ([]Recipient, error) {
+	var recipients []Recipient
+	err := r.DB.Table("users u").
+		Select("u.id, u.name, u.email, COALESCE(s.delivery, ?) AS delivery", EmailImmediate).
+		Joins("LEFT JOIN notification_email_settings s ON s.user_id = u.id").
+		Where("u.deactivated_at IS NULL").
+		Where("EXISTS (SELECT 1 FROM notifications n WHERE n.user_id = u.id AND n.email_pending)").
+		Where(r.DB.
+			Where("s.user_id IS NULL OR s.delivery = ?", EmailImmediate).
+			Or("s.delivery = ? AND (s.last_digest_at IS NULL OR s.last_digest_at <= ?)", EmailHourly, now.Add(-EmailHourly.DigestInterval())).
+			Or("s.delivery = ? AND (s.last_digest_at IS NULL OR s.last_digest_at <= ?)", EmailDaily, now.Add(-EmailDaily.DigestInterval()))).
+		Order("u.id").
+		Limit(limit).
+		Scan(&recipients).Error
+	return recipients, err
+}
+
+// ClaimEmails hands the user's oldest pending emails, up to limit or all of them if it's zero,
+// to send and marks them as sent if it succeeds. The rows stay locked meanwhile, and SKIP LOCKED
+// keeps the other replicas from sending them too. It returns how many emails were claimed.
+func (r *notificationRepository) ClaimEmails(userID uuid.UUID, now time.Time, limit int, send func(notifications []Notification) error) (int, error) {
+	var notifications []Notification
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("user_id = ? AND email_pending", userID).
+			Order("created_at")
+		if limit > 0 {
+			query = query.Limit(limit)
+		}
+		if err := query.Find(&notifications).Error; err != nil {
+			return err
+		}
+		if len(notifications) == 0 {
+			return nil
+		}
+
+		if err := send(notifications); err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(notifications))
+		for _, n := range notifications {
+			ids = append(ids, n.ID)
+		}
+		if err := tx.Model(&Notification{}).Where("id IN ?", ids).Update("email_pending", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&EmailSettings{}).Where("user_id = ?", userID).Update("last_digest_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(notifications), nil
+}
@@ -0,0 +1,26 @@
+package notifications
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func NotificationRoutes(r chi.Router, handler *NotificationHandler) {
+	r.Route("/notifications", func(r chi.Router) {
+		// Unsubscribe links are followed from emails, so the signed token stands in for a session.
+		r.Post("/unsubscribe", handler.Unsubscribe)
+
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireAuth)
+			r.Get("/", handler.List)
+			r.Get("/unread-count", handler.UnreadCount)
+			r.Post("/read-all", handler.MarkAllRead)
+			r.Post("/{notificationId}/read", handler.MarkRead)
+			r.Post("/{notificationId}/unread", handler.MarkUnread)
+			r.Get("/preferences", handler.GetPreferences)
+			r.Put("/preferences", handler.UpdatePreferences)
+			r.Get("/email", handler.GetEmailSettings)
+			r.Put("/email", handler.UpdateEmailSettings)
+		})
+	})
+}
@@ -0,0 +1,330 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/mail"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// emailBatchSize is how many recipients the email worker handles per run.
+const emailBatchSize = 100
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = mustParseTemplates()
+
+func mustParseTemplates() *mail.Templates {
+	fsys, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+	t, err := mail.ParseTemplates(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+type NotificationService struct {
+	Repo      NotificationRepository
+	Mailer    mail.Mailer
+	Validator *validator.Validate
+	BaseURL   string
+	// UnsubscribeSecret signs the unsubscribe links, so they work without logging in.
+	UnsubscribeSecret []byte
+}
+
+func NewNotificationService(
+	repo NotificationRepository,
+	mailer mail.Mailer,
+	validator *validator.Validate,
+	baseURL string,
+	unsubscribeSecret string,
+) *NotificationService {
+	return &NotificationService{
+		Repo:              repo,
+		Mailer:            mailer,
+		Validator:         validator,
+		BaseURL:           baseURL,
+		UnsubscribeSecret: []byte(unsubscribeSecret),
+	}
+}
+
+// Notify stores the notifications whose recipients haven't turned their type off.
+func (s *NotificationService) Notify(notifications ...*Notification) error {
+	for _, n := range notifications {
+		enabled, err := s.Repo.IsEnabled(n.UserID, n.Type)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			continue
+		}
+		delivery, err := s.emailDelivery(n.UserID)
+		if err != nil {
+			return err
+		}
+		n.EmailPending = delivery != EmailOff
+		if err := s.Repo.Create(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send notifies the recipients about something the actor did. It runs after the change has been
+// committed, so failures are only logged. Nobody is notified about their own actions.
+func (s *NotificationService) Send(actorID *uuid.UUID, notifications ...*Notification) {
+	for _, n := range notifications {
+		if actorID != nil && n.UserID == *actorID {
+			continue
+		}
+		n.ActorID = actorID
+		if err := s.Notify(n); err != nil {
+			log.Printf("notifying user %s failed: %v", n.UserID, err)
+		}
+	}
+}
+
+func (s *NotificationService) List(userID uuid.UUID, unreadOnly bool, page, size int) (*common.PaginatedResponse[NotificationResponse], error) {
+	offset := (page - 1) * size
+	notifications, total, err := s.Repo.List(userID, unreadOnly, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]NotificationResponse, 0, len(notifications))
+	for _, n := range notifications {
+		res = append(res, *ToNotificationResponse(&n))
+	}
+
+	return &common.PaginatedResponse[NotificationResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+func (s *NotificationService) UnreadCount(userID uuid.UUID) (*UnreadCountResponse, error) {
+	count, err := s.Repo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &UnreadCountResponse{Count: count}, nil
+}
+
+func (s *NotificationService) MarkRead(userID, id uuid.UUID) (*NotificationResponse, error) {
+	now := time.Now()
+	return s.setReadAt(userID, id, &now)
+}
+
+func (s *NotificationService) MarkUnread(userID, id uuid.UUID) (*NotificationResponse, error) {
+	return s.setReadAt(userID, id, nil)
+}
+
+func (s *NotificationService) setReadAt(userID, id uuid.UUID, readAt *time.Time) (*NotificationResponse, error) {
+	n, err := s.Repo.GetByID(id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	// Other users' notifications are reported as missing rather than forbidden.
+	if err != nil || n.UserID != userID {
+		return nil, common.NotFound(fmt.Sprintf("Notification with id %s was not found!", id))
+	}
+
+	if err := s.Repo.SetReadAt(id, readAt); err != nil {
+		return nil, err
+	}
+	n.ReadAt = readAt
+	return ToNotificationResponse(n), nil
+}
+
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (*MarkAllReadResponse, error) {
+	updated, err := s.Repo.MarkAllRead(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &MarkAllReadResponse{Updated: int(updated)}, nil
+}
+
+func (s *NotificationService) GetPreferences(userID uuid.UUID) ([]PreferenceResponse, error) {
+	prefs, err := s.Repo.ListPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make(map[NotificationType]bool, len(prefs))
+	for _, p := range prefs {
+		enabled[p.Type] = p.Enabled
+	}
+
+	res := make([]PreferenceResponse, 0, len(Types))
+	for _, t := range Types {
+		e, ok := enabled[t]
+		res = append(res, PreferenceResponse{Type: t, Enabled: e || !ok})
+	}
+	return res, nil
+}
+
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, req UpdatePreferencesRequest) ([]PreferenceResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	prefs := make([]Preference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		prefs = append(prefs, Preference{UserID: userID, Type: p.Type, Enabled: *p.Enabled})
+	}
+	if err := s.Repo.SavePreferences(prefs); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(userID)
+}
+
+func (s *NotificationService) emailDelivery(userID uuid.UUID) (EmailDelivery, error) {
+	settings, err := s.Repo.GetEmailSettings(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return EmailImmediate, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return settings.Delivery, nil
+}
+
+func (s *NotificationService) GetEmailSettings(userID uuid.UUID) (*EmailSettingsResponse, error) {
+	delivery, err := s.emailDelivery(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &EmailSettingsResponse{Delivery: delivery}, nil
+}
+
+func (s *NotificationService) UpdateEmailSettings(userID uuid.UUID, req EmailSettingsRequest) (*EmailSettingsResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if err := s.Repo.SaveEmailSettings(&EmailSettings{UserID: userID, Delivery: req.Delivery}); err != nil {
+		return nil, err
+	}
+	return &EmailSettingsResponse{Delivery: req.Delivery}, nil
+}
+
+// Unsubscribe turns off the emails of the user the token was issued for.
+func (s *NotificationService) Unsubscribe(req UnsubscribeRequest) error {
+	if err := s.Validator.Struct(req); err != nil {
+		return common.BadRequest(err.Error())
+	}
+
+	userID, ok := s.verifyUnsubscribeToken(req.Token)
+	if !ok {
+		return common.BadRequest("Invalid unsubscribe token!")
+	}
+	return s.Repo.SaveEmailSettings(&EmailSettings{UserID: userID, Delivery: EmailOff})
+}
+
+// UnsubscribeToken is the user's id with its signature. It doesn't expire, since it only
+// ever turns emails off.
+func (s *NotificationService) UnsubscribeToken(userID uuid.UUID) string {
+	return userID.String() + "." + s.sign(userID)
+}
+
+func (s *NotificationService) verifyUnsubscribeToken(token string) (uuid.UUID, bool) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, hmac.Equal([]byte(signature), []byte(s.sign(userID)))
+}
+
+func (s *NotificationService) sign(userID uuid.UUID) string {
+	mac := hmac.New(sha256.New, s.UnsubscribeSecret)
+	mac.Write([]byte("unsubscribe:" + userID.String()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type emailData struct {
+	Name             string
+	Delivery         EmailDelivery
+	Notifications    []Notification
+	NotificationsURL string
+	UnsubscribeURL   string
+}
+
+// SendEmails sends the pending emails of the users whose delivery is due, each notification on
+// its own for immediate delivery and a single digest otherwise. A failed recipient is retried
+// on the next run.
+func (s *NotificationService) SendEmails() error {
+	now := time.Now()
+	recipients, err := s.Repo.ListEmailRecipients(now, emailBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, rcpt := range recipients {
+		if err := s.emailRecipient(rcpt, now); err != nil {
+			log.Printf("emailing notifications to user %s failed: %v", rcpt.ID, err)
+		}
+	}
+	return nil
+}
+
+// emailRecipient sends the recipient's pending emails. Immediate emails are claimed one at a
+// time, so a failed send leaves only its own notification pending.
+func (s *NotificationService) emailRecipient(rcpt Recipient, now time.Time) error {
+	if rcpt.Delivery.DigestInterval() > 0 {
+		_, err := s.Repo.ClaimEmails(rcpt.ID, now, 0, func(notifications []Notification) error {
+			return s.sendEmail(rcpt, "digest", fmt.Sprintf("Your %s Gollab digest", rcpt.Delivery), notifications)
+		})
+		return err
+	}
+
+	for {
+		claimed, err := s.Repo.ClaimEmails(rcpt.ID, now, 1, func(notifications []Notification) error {
+			return s.sendEmail(rcpt, "notification", notifications[0].Title, notifications)
+		})
+		if err != nil || claimed == 0 {
+			return err
+		}
+	}
+}
+
+func (s *NotificationService) sendEmail(rcpt Recipient, template, subject string, notifications []Notification) error {
+	unsubscribeURL := fmt.Sprintf("%s/unsubscribe?token=%s", s.BaseURL, url.QueryEscape(s.UnsubscribeToken(rcpt.ID)))
+	msg := mail.Message{
+		To:      rcpt.Email,
+		Subject: subject,
+		Headers: map[string]string{"List-Unsubscribe": "<" + unsubscribeURL + ">"},
+	}
+	if err := templates.Render(&msg, template, emailData{
+		Name:             rcpt.Name,
+		Delivery:         rcpt.Delivery,
+		Notifications:    notifications,
+		NotificationsURL: s.BaseURL + "/notifications",
+		UnsubscribeURL:   unsubscribeURL,
+	}); err != nil {
+		return err
+	}
+	return s.Mailer.Send(msg)
+}
@@ -0,0 +1,263 @@
+package notifications
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/mail"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type notificationRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *notificationRepositoryMock) Create(n *Notification) error {
+	return m.Called(n).Error(0)
+}
+
+func (m *notificationRepositoryMock) GetByID(id uuid.UUID) (*Notification, error) {
+	args := m.Called(id)
+	if n := args.Get(0); n != nil {
+		return n.(*Notification), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *notificationRepositoryMock) List(userID uuid.UUID, unreadOnly bool, offset, limit int) ([]Notification, int, error) {
+	args := m.Called(userID, unreadOnly, offset, limit)
+	return args.Get(0).([]Notification), args.Int(1), args.Error(2)
+}
+
+func (m *notificationRepositoryMock) CountUnread(userID uuid.UUID) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *notificationRepositoryMock) SetReadAt(id uuid.UUID, readAt *time.Time) error {
+	return m.Called(id, readAt).Error(0)
+}
+
+func (m *notificationRepositoryMock) MarkAllRead(userID uuid.UUID, readAt time.Time) (int64, error) {
+	args := m.Called(userID, readAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *notificationRepositoryMock) IsEnabled(userID uuid.UUID, t NotificationType) (bool, error) {
+	args := m.Called(userID, t)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *notificationRepositoryMock) ListPreferences(userID uuid.UUID) ([]Preference, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Preference), args.Error(1)
+}
+
+func (m *notificationRepositoryMock) SavePreferences(prefs []Preference) error {
+	return m.Called(prefs).Error(0)
+}
+
+func (m *notificationRepositoryMock) GetEmailSettings(userID uuid.UUID) (*EmailSettings, error) {
+	args := m.Called(userID)
+	if s := args.Get(0); s != nil {
+		return s.(*EmailSettings), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *notificationRepositoryMock) SaveEmailSettings(settings *EmailSettings) error {
+	return m.Called(settings).Error(0)
+}
+
+func (m *notificationRepositoryMock) ListEmailRecipients(now time.Time, limit int) ([]Recipient, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]Recipient), args.Error(1)
+}
+
+// ClaimEmails hands the notifications the mock was set up with to send.
+func (m *notificationRepositoryMock) ClaimEmails(userID uuid.UUID, now time.Time, limit int, send func(notifications []Notification) error) (int, error) {
+	args := m.Called(userID, now, limit, send)
+	if err := args.Error(1); err != nil {
+		return 0, err
+	}
+	notifications := args.Get(0).([]Notification)
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+	return len(notifications), send(notifications)
+}
+
+func setupNotificationServiceTest() (*NotificationService, *notificationRepositoryMock, *mail.CaptureMailer) {
+	repo := &notificationRepositoryMock{}
+	mailer := &mail.CaptureMailer{}
+	return NewNotificationService(repo, mailer, validator.New(), "http://localhost:3000", "secret"), repo, mailer
+}
+
+func TestNotificationService_Notify_SkipsDisabledTypes(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	enabled := &Notification{UserID: uuid.New(), Type: ItemAssigned}
+	disabled := &Notification{UserID: uuid.New(), Type: ItemAssigned}
+	repo.On("IsEnabled", enabled.UserID, ItemAssigned).Return(true, nil)
+	repo.On("IsEnabled", disabled.UserID, ItemAssigned).Return(false, nil)
+	repo.On("GetEmailSettings", enabled.UserID).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("Create", enabled).Return(nil)
+
+	err := service.Notify(enabled, disabled)
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "Create", disabled)
+	repo.AssertExpectations(t)
+	assert.True(t, enabled.EmailPending)
+}
+
+func TestNotificationService_Notify_EmailOff(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	n := &Notification{UserID: uuid.New(), Type: ItemAssigned}
+	repo.On("IsEnabled", n.UserID, ItemAssigned).Return(true, nil)
+	repo.On("GetEmailSettings", n.UserID).Return(&EmailSettings{UserID: n.UserID, Delivery: EmailOff}, nil)
+	repo.On("Create", n).Return(nil)
+
+	err := service.Notify(n)
+
+	assert.NoError(t, err)
+	assert.False(t, n.EmailPending)
+}
+
+func TestNotificationService_Send_SkipsActor(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	actorID := uuid.New()
+	other := &Notification{UserID: uuid.New(), Type: TeamAdded}
+	self := &Notification{UserID: actorID, Type: TeamAdded}
+	repo.On("IsEnabled", other.UserID, TeamAdded).Return(true, nil)
+	repo.On("GetEmailSettings", other.UserID).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("Create", other).Return(nil)
+
+	service.Send(&actorID, other, self)
+
+	assert.Equal(t, actorID, *other.ActorID)
+	repo.AssertNotCalled(t, "IsEnabled", actorID, TeamAdded)
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationService_MarkRead(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	n := &Notification{BaseEntity: common.BaseEntity{ID: uuid.New()}, UserID: uuid.New()}
+	repo.On("GetByID", n.ID).Return(n, nil)
+	repo.On("SetReadAt", n.ID, mock.AnythingOfType("*time.Time")).Return(nil)
+
+	resp, err := service.MarkRead(n.UserID, n.ID)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.ReadAt)
+}
+
+func TestNotificationService_MarkRead_OtherUser(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	n := &Notification{BaseEntity: common.BaseEntity{ID: uuid.New()}, UserID: uuid.New()}
+	repo.On("GetByID", n.ID).Return(n, nil)
+
+	resp, err := service.MarkRead(uuid.New(), n.ID)
+
+	assert.Nil(t, resp)
+	var apiErr *common.ApiError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	repo.AssertNotCalled(t, "SetReadAt", mock.Anything, mock.Anything)
+}
+
+func TestNotificationService_GetPreferences_DefaultsToEnabled(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	userID := uuid.New()
+	repo.On("ListPreferences", userID).Return([]Preference{{UserID: userID, Type: Mentioned, Enabled: false}}, nil)
+
+	resp, err := service.GetPreferences(userID)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp, len(Types))
+	for _, p := range resp {
+		assert.Equal(t, p.Type != Mentioned, p.Enabled, p.Type)
+	}
+}
+
+func TestNotificationService_UpdatePreferences_InvalidType(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	enabled := false
+
+	_, err := service.UpdatePreferences(uuid.New(), UpdatePreferencesRequest{
+		Preferences: []PreferenceRequest{{Type: "unknown", Enabled: &enabled}},
+	})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "SavePreferences", mock.Anything)
+}
+
+func TestNotificationService_SendEmails_Immediate(t *testing.T) {
+	service, repo, mailer := setupNotificationServiceTest()
+	rcpt := Recipient{ID: uuid.New(), Name: "Jane", Email: "jane@example.com", Delivery: EmailImmediate}
+	pending := []Notification{{Title: "You were assigned to Login page"}, {Title: "You were added to the team Core"}}
+	repo.On("ListEmailRecipients", mock.AnythingOfType("time.Time"), emailBatchSize).Return([]Recipient{rcpt}, nil)
+	for _, n := range pending {
+		repo.On("ClaimEmails", rcpt.ID, mock.AnythingOfType("time.Time"), 1, mock.Anything).Return([]Notification{n}, nil).Once()
+	}
+	repo.On("ClaimEmails", rcpt.ID, mock.AnythingOfType("time.Time"), 1, mock.Anything).Return([]Notification{}, nil).Once()
+
+	err := service.SendEmails()
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	sent := mailer.Messages()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "jane@example.com", sent[0].To)
+	assert.Equal(t, "You were assigned to Login page", sent[0].Subject)
+	assert.Contains(t, sent[0].Body, "Hi Jane")
+	assert.Contains(t, sent[0].HTML, "You were assigned to Login page")
+	assert.Contains(t, sent[0].Headers["List-Unsubscribe"], "/unsubscribe?token=")
+}
+
+func TestNotificationService_SendEmails_Digest(t *testing.T) {
+	service, repo, mailer := setupNotificationServiceTest()
+	rcpt := Recipient{ID: uuid.New(), Name: "Jane", Email: "jane@example.com", Delivery: EmailDaily}
+	pending := []Notification{{Title: "First"}, {Title: "Second"}}
+	repo.On("ListEmailRecipients", mock.AnythingOfType("time.Time"), emailBatchSize).Return([]Recipient{rcpt}, nil)
+	repo.On("ClaimEmails", rcpt.ID, mock.AnythingOfType("time.Time"), 0, mock.Anything).Return(pending, nil)
+
+	err := service.SendEmails()
+
+	assert.NoError(t, err)
+	sent := mailer.Messages()
+	assert.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Body, "- First")
+	assert.Contains(t, sent[0].Body, "- Second")
+	assert.Contains(t, sent[0].HTML, "<li>Second")
+}
+
+func TestNotificationService_Unsubscribe(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	userID := uuid.New()
+	repo.On("SaveEmailSettings", &EmailSettings{UserID: userID, Delivery: EmailOff}).Return(nil)
+
+	err := service.Unsubscribe(UnsubscribeRequest{Token: service.UnsubscribeToken(userID)})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationService_Unsubscribe_ForgedToken(t *testing.T) {
+	service, repo, _ := setupNotificationServiceTest()
+	token := service.UnsubscribeToken(uuid.New())
+	_, signature, _ := strings.Cut(token, ".")
+
+	err := service.Unsubscribe(UnsubscribeRequest{Token: uuid.New().String() + "." + signature})
+
+	var apiErr *common.ApiError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	repo.AssertNotCalled(t, "SaveEmailSettings", mock.Anything)
+}
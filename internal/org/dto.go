@@ -1,28 +1,64 @@
 package org
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CreateUserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Name     string `json:"username" validate:"required,min=2,max=50"`
-	Password string `json:"password" validate:"required,min=8,max=150"`
+	Password string `json:"password" validate:"required,max=150"`
 }
 
 type UpdateUserRequest struct {
 	Name string `json:"username" validate:"omitempty,min=2"`
 }
 
+// UserDocument holds the user fields a JSON merge patch can change.
+type UserDocument struct {
+	Name string `json:"username" validate:"required,min=2,max=50"`
+}
+
+func ToUserDocument(user *User) *UserDocument {
+	return &UserDocument{Name: user.Name}
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,max=150"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,max=150"`
+}
+
 type UserResponse struct {
-	ID    uuid.UUID `json:"id"`
-	Email string    `json:"email"`
-	Name  string    `json:"username"`
+	ID            uuid.UUID  `json:"id"`
+	Email         string     `json:"email"`
+	Name          string     `json:"username"`
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+	Version       int        `json:"version"`
+}
+
+// RedactedFields keeps the user's email and name out of the audit log, which erasure can't reach.
+func (*UserResponse) RedactedFields() []string {
+	return []string{"email", "username"}
 }
 
 func ToUserResponse(user *User) *UserResponse {
 	return &UserResponse{
-		ID:    user.ID,
-		Email: user.Email,
-		Name:  user.Name,
+		ID:            user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		DeactivatedAt: user.DeactivatedAt,
+		Version:       user.Version,
 	}
 }
 
@@ -36,10 +72,24 @@ type UpdateTeamRequest struct {
 	Description string `json:"description"`
 }
 
+// TeamDocument holds the team fields a JSON merge patch can change.
+type TeamDocument struct {
+	Name        string `json:"name" validate:"required,min=2,max=50"`
+	Description string `json:"description"`
+}
+
+func ToTeamDocument(team *Team) *TeamDocument {
+	return &TeamDocument{
+		Name:        team.Name,
+		Description: team.Description,
+	}
+}
+
 type TeamResponse struct {
 	ID          uuid.UUID `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	Version     int       `json:"version"`
 }
 
 func ToTeamResponse(team *Team) *TeamResponse {
@@ -47,6 +97,7 @@ func ToTeamResponse(team *Team) *TeamResponse {
 		ID:          team.ID,
 		Name:        team.Name,
 		Description: team.Description,
+		Version:     team.Version,
 	}
 }
 
@@ -61,6 +112,13 @@ type DeleteMembershipRequest struct {
 	UserID uuid.UUID `json:"userId" validate:"required,uuid"`
 }
 
+// MembershipPayload is sent to webhooks when members join or leave a team.
+type MembershipPayload struct {
+	TeamID uuid.UUID `json:"teamId"`
+	UserID uuid.UUID `json:"userId"`
+	Role   TeamRole  `json:"role,omitempty"`
+}
+
 type MemberResponse struct {
 	ID    uuid.UUID `json:"id"`
 	Name  string    `json:"username"`
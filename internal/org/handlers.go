@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
 	"github.com/StefanShivarov/gollab-backend/internal/common"
 	"github.com/go-chi/chi/v5"
 )
@@ -29,7 +30,13 @@ func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
 		size = 10
 	}
 
-	resp, err := h.Service.List(page, size)
+	// Deactivated users are hidden from everyone but admins.
+	includeDeactivated := false
+	if p, ok := common.PrincipalFromContext(r.Context()); ok && p.IsAdmin {
+		includeDeactivated, _ = strconv.ParseBool(r.URL.Query().Get("includeDeactivated"))
+	}
+
+	resp, err := h.Service.List(page, size, includeDeactivated)
 	if err != nil {
 		common.WriteError(w, err)
 		return
@@ -51,6 +58,7 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	common.SetETag(w, resp.Version)
 	common.WriteJSON(w, http.StatusOK, resp)
 }
 
@@ -61,12 +69,13 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.Service.Create(req)
+	resp, err := h.Service.Create(audit.ActorFromRequest(r), req)
 	if err != nil {
 		common.WriteError(w, err)
 		return
 	}
 
+	common.SetETag(w, resp.Version)
 	common.WriteJSON(w, http.StatusCreated, resp)
 }
 
@@ -83,15 +92,61 @@ func (h *UserHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.Service.UpdateByID(id, req)
+	resp, err := h.Service.UpdateByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), req)
 	if err != nil {
 		common.WriteError(w, err)
 		return
 	}
 
+	common.SetETag(w, resp.Version)
 	common.WriteJSON(w, http.StatusOK, resp)
 }
 
+func (h *UserHandler) PatchByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	patch, err := common.ReadMergePatch(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.PatchByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), patch)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	if err := h.Service.ChangePassword(audit.ActorFromRequest(r), principal, id, req); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *UserHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 	id, err := common.ParseUUID(chi.URLParam(r, "userId"))
 	if err != nil {
@@ -99,7 +154,39 @@ func (h *UserHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.Service.DeleteByID(id); err != nil {
+	if err := h.Service.Deactivate(audit.ActorFromRequest(r), id, common.ParseIfMatch(r)); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.Reactivate(audit.ActorFromRequest(r), id, common.ParseIfMatch(r))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *UserHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if err := h.Service.Purge(audit.ActorFromRequest(r), id); err != nil {
 		common.WriteError(w, err)
 		return
 	}
@@ -127,11 +214,12 @@ func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
 		common.WriteError(w, err)
 		return
 	}
-	resp, err := h.Service.Create(creatorId, req)
+	resp, err := h.Service.Create(audit.ActorFromRequest(r), creatorId, req)
 	if err != nil {
 		common.WriteError(w, err)
 		return
 	}
+	common.SetETag(w, resp.Version)
 	common.WriteJSON(w, http.StatusCreated, resp)
 }
 
@@ -167,6 +255,7 @@ func (h *TeamHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		common.WriteError(w, err)
 		return
 	}
+	common.SetETag(w, resp.Version)
 	common.WriteJSON(w, http.StatusOK, resp)
 }
 
@@ -183,12 +272,36 @@ func (h *TeamHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.Service.UpdateByID(id, req)
+	resp, err := h.Service.UpdateByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.SetETag(w, resp.Version)
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *TeamHandler) PatchByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "teamId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	patch, err := common.ReadMergePatch(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.PatchByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r), patch)
 	if err != nil {
 		common.WriteError(w, err)
 		return
 	}
 
+	common.SetETag(w, resp.Version)
 	common.WriteJSON(w, http.StatusOK, resp)
 }
 
@@ -199,7 +312,7 @@ func (h *TeamHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.Service.DeleteByID(id); err != nil {
+	if err := h.Service.DeleteByID(audit.ActorFromRequest(r), id, common.ParseIfMatch(r)); err != nil {
 		common.WriteError(w, err)
 		return
 	}
@@ -229,7 +342,7 @@ func (h *TeamHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.Service.AddMembership(req); err != nil {
+	if err := h.Service.AddMembership(audit.ActorFromRequest(r), req); err != nil {
 		common.WriteError(w, err)
 		return
 	}
@@ -244,7 +357,45 @@ func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.Service.RemoveMembership(req.TeamID, req.UserID); err != nil {
+	if err := h.Service.RemoveMembership(audit.ActorFromRequest(r), req.TeamID, req.UserID); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type PasswordResetHandler struct {
+	Service *PasswordResetService
+}
+
+func NewPasswordResetHandler(service *PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{Service: service}
+}
+
+func (h *PasswordResetHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	if err := h.Service.RequestReset(req); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *PasswordResetHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	if err := h.Service.ResetPassword(audit.ActorFromRequest(r), req); err != nil {
 		common.WriteError(w, err)
 		return
 	}
@@ -1,6 +1,8 @@
 package org
 
 import (
+	"time"
+
 	"github.com/StefanShivarov/gollab-backend/internal/common"
 	"github.com/google/uuid"
 )
@@ -14,10 +16,15 @@ const (
 
 type User struct {
 	common.BaseEntity
-	Email        string   `gorm:"type:varchar(50);uniqueIndex;not null"`
-	Name         string   `gorm:"type:varchar(50);uniqueIndex;not null"`
-	PasswordHash string   `gorm:"type:varchar(150);not null"`
-	Role         UserRole `gorm:"type:varchar(20);not null;default:'standard';check:role IN ('admin', 'standard')"`
+	Email         string     `gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name          string     `gorm:"type:varchar(50);uniqueIndex;not null"`
+	PasswordHash  string     `gorm:"type:varchar(150);not null"`
+	Role          UserRole   `gorm:"type:varchar(20);not null;default:'standard';check:role IN ('admin', 'standard')"`
+	DeactivatedAt *time.Time `gorm:"index"`
+}
+
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
 }
 
 type TeamRole string
@@ -41,3 +48,12 @@ type Membership struct {
 	Team   Team      `gorm:"foreignKey:TeamID"`
 	Role   TeamRole  `gorm:"type:varchar(20);not null;default:'developer';check: role IN ('project_manager', 'developer')"`
 }
+
+type PasswordResetToken struct {
+	common.BaseEntity
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	User      User      `gorm:"foreignKey:UserID"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
@@ -0,0 +1,64 @@
+package org
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+)
+
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:    8,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+	}
+}
+
+func (p PasswordPolicy) Validate(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if len([]rune(password)) < p.MinLength {
+		missing = append(missing, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+
+	if len(missing) > 0 {
+		return common.BadRequest(fmt.Sprintf("Password must contain %s!", strings.Join(missing, ", ")))
+	}
+	return nil
+}
@@ -1,16 +1,25 @@
 package org
 
 import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
 	GetByID(id uuid.UUID) (*User, error)
-	Create(user *User) error
-	Update(user *User) error
-	DeleteByID(id uuid.UUID) error
-	List(offset, limit int) ([]User, int, error)
+	GetByEmail(email string) (*User, error)
+	Create(user *User, event *audit.Event) error
+	Update(user *User, event *audit.Event) error
+	ChangePassword(user *User, keepSessionID *uuid.UUID, event *audit.Event) error
+	DeleteByID(id uuid.UUID, event *audit.Event) error
+	List(offset, limit int, includeDeactivated bool) ([]User, int, error)
 }
 
 type userRepository struct {
@@ -29,23 +38,71 @@ func (r *userRepository) GetByID(id uuid.UUID) (*User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) Create(user *User) error {
-	return r.DB.Create(user).Error
+func (r *userRepository) GetByEmail(email string) (*User, error) {
+	var user User
+	if err := r.DB.First(&user, "email = ?", email).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Create(user *User, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
 }
 
-func (r *userRepository) Update(user *User) error {
-	return r.DB.Save(user).Error
+func (r *userRepository) Update(user *User, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, user, &user.BaseEntity); err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+// ChangePassword saves the user's new password hash and ends all their sessions but the one
+// the change was made from.
+func (r *userRepository) ChangePassword(user *User, keepSessionID *uuid.UUID, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, user, &user.BaseEntity); err != nil {
+			return err
+		}
+
+		query := "DELETE FROM sessions WHERE user_id = ?"
+		args := []any{user.ID}
+		if keepSessionID != nil {
+			query += " AND id <> ?"
+			args = append(args, *keepSessionID)
+		}
+		if err := tx.Exec(query, args...).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
 }
 
-func (r *userRepository) DeleteByID(id uuid.UUID) error {
-	return r.DB.Delete(&User{}, "id = ?", id).Error
+func (r *userRepository) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&User{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
 }
 
-func (r *userRepository) List(offset, limit int) ([]User, int, error) {
+func (r *userRepository) List(offset, limit int, includeDeactivated bool) ([]User, int, error) {
 	var users []User
 	var total int64
-	r.DB.Model(&User{}).Count(&total)
-	if err := r.DB.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	query := r.DB.Model(&User{})
+	if !includeDeactivated {
+		query = query.Where("deactivated_at IS NULL")
+	}
+	query.Count(&total)
+	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 	return users, int(total), nil
@@ -53,15 +110,21 @@ func (r *userRepository) List(offset, limit int) ([]User, int, error) {
 
 type TeamRepository interface {
 	GetByID(id uuid.UUID) (*Team, error)
-	Update(team *Team) error
-	DeleteByID(id uuid.UUID) error
+	Update(team *Team, event *audit.Event) error
+	DeleteByID(id uuid.UUID, event *audit.Event) error
 	List(offset, limit int) ([]Team, int, error)
-	CreateTeamWithOwner(team *Team, creatorId uuid.UUID) error
-	AddMembership(membership *Membership) error
-	DeleteMembershipByTeamIDAndUserID(teamID uuid.UUID, userID uuid.UUID) error
+	CreateTeamWithOwner(team *Team, creatorId uuid.UUID, event *audit.Event) error
+	AddMembership(membership *Membership, event *audit.Event, message *outbox.Message) error
+	DeleteMembershipByTeamIDAndUserID(teamID uuid.UUID, userID uuid.UUID, event *audit.Event, message *outbox.Message) (bool, error)
 	ListMembers(teamID uuid.UUID) ([]MemberResponse, error)
+	IsMember(teamID, userID uuid.UUID) (bool, error)
+	FindMembersByName(teamID uuid.UUID, names []string) ([]User, error)
+	HasRole(teamID, userID uuid.UUID, role TeamRole) (bool, error)
 }
 
+// errNotMember rolls back the removal of a membership that doesn't exist.
+var errNotMember = errors.New("user is not a member of the team")
+
 type teamRepository struct {
 	DB *gorm.DB
 }
@@ -78,17 +141,25 @@ func (r *teamRepository) GetByID(id uuid.UUID) (*Team, error) {
 	return &team, nil
 }
 
-func (r *teamRepository) Update(team *Team) error {
-	return r.DB.Save(team).Error
+func (r *teamRepository) Update(team *Team, event *audit.Event) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := common.SaveVersioned(tx, team, &team.BaseEntity); err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
 }
 
-func (r *teamRepository) DeleteByID(id uuid.UUID) error {
+func (r *teamRepository) DeleteByID(id uuid.UUID, event *audit.Event) error {
 	return r.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Delete(&Membership{}, "team_id = ?", id).Error; err != nil {
 			return err
 		}
 
-		return tx.Delete(&Team{}, "id = ?", id).Error
+		if err := tx.Delete(&Team{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
 	})
 }
 
@@ -102,12 +173,38 @@ func (r *teamRepository) List(offset, limit int) ([]Team, int, error) {
 	return teams, int(total), nil
 }
 
-func (r *teamRepository) AddMembership(m *Membership) error {
-	return r.DB.Create(m).Error
+func (r *teamRepository) AddMembership(m *Membership, event *audit.Event, message *outbox.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, event); err != nil {
+			return err
+		}
+		return outbox.Record(tx, message)
+	})
 }
 
-func (r *teamRepository) DeleteMembershipByTeamIDAndUserID(teamID, userID uuid.UUID) error {
-	return r.DB.Delete(&Membership{}, "team_id = ? AND user_id = ?", teamID, userID).Error
+// DeleteMembershipByTeamIDAndUserID reports false when the user wasn't a member, in which case
+// neither the event nor the message is recorded.
+func (r *teamRepository) DeleteMembershipByTeamIDAndUserID(teamID, userID uuid.UUID, event *audit.Event, message *outbox.Message) (bool, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&Membership{}, "team_id = ? AND user_id = ?", teamID, userID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errNotMember
+		}
+		if err := audit.Record(tx, event); err != nil {
+			return err
+		}
+		return outbox.Record(tx, message)
+	})
+	if errors.Is(err, errNotMember) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 func (r *teamRepository) ListMembers(teamID uuid.UUID) ([]MemberResponse, error) {
@@ -116,12 +213,39 @@ func (r *teamRepository) ListMembers(teamID uuid.UUID) ([]MemberResponse, error)
 		Table("memberships").
 		Select("users.id as user_id, users.name, users.email, memberships.role").
 		Joins("JOIN users ON users.id = memberships.user_id").
-		Where("memberships.team_id = ?", teamID).
+		Where("memberships.team_id = ? AND users.deactivated_at IS NULL", teamID).
 		Scan(&res).Error
 	return res, err
 }
 
-func (r *teamRepository) CreateTeamWithOwner(team *Team, creatorID uuid.UUID) error {
+func (r *teamRepository) IsMember(teamID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Membership{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// FindMembersByName returns the team's active members with any of the names, ignoring case.
+func (r *teamRepository) FindMembersByName(teamID uuid.UUID, names []string) ([]User, error) {
+	lowered := make([]string, 0, len(names))
+	for _, n := range names {
+		lowered = append(lowered, strings.ToLower(n))
+	}
+
+	var users []User
+	err := r.DB.
+		Joins("JOIN memberships ON memberships.user_id = users.id").
+		Where("memberships.team_id = ? AND users.deactivated_at IS NULL AND LOWER(users.name) IN ?", teamID, lowered).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *teamRepository) HasRole(teamID, userID uuid.UUID, role TeamRole) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Membership{}).Where("team_id = ? AND user_id = ? AND role = ?", teamID, userID, role).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *teamRepository) CreateTeamWithOwner(team *Team, creatorID uuid.UUID, event *audit.Event) error {
 	return r.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(team).Error; err != nil {
 			return err
@@ -131,6 +255,81 @@ func (r *teamRepository) CreateTeamWithOwner(team *Team, creatorID uuid.UUID) er
 			UserID: creatorID,
 			Role:   ProjectManager,
 		}
-		return tx.Create(m).Error
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
+	})
+}
+
+type PasswordResetRepository interface {
+	Create(token *PasswordResetToken) error
+	GetByTokenHash(hash string) (*PasswordResetToken, error)
+	ResetPassword(token *PasswordResetToken, passwordHash string, event *audit.Event) (bool, error)
+}
+
+// errTokenUsed rolls back a reset whose token was used by a concurrent request.
+var errTokenUsed = errors.New("password reset token was already used")
+
+type passwordResetRepository struct {
+	DB *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{DB: db}
+}
+
+func (r *passwordResetRepository) Create(token *PasswordResetToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *passwordResetRepository) GetByTokenHash(hash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	if err := r.DB.First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ResetPassword reports false when the token was already used. Marking it used is the first
+// write of the transaction, so of two concurrent resets with the same token only one goes through.
+func (r *passwordResetRepository) ResetPassword(token *PasswordResetToken, passwordHash string, event *audit.Event) (bool, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errTokenUsed
+		}
+
+		if err := tx.Model(&User{}).
+			Where("id = ?", token.UserID).
+			Update("password_hash", passwordHash).Error; err != nil {
+			return err
+		}
+
+		// Consumes any other outstanding tokens for the user as well.
+		if err := tx.Model(&PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		// Whoever took over the account may have created access tokens, so they go with the sessions.
+		if err := tx.Exec("DELETE FROM sessions WHERE user_id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM personal_access_tokens WHERE user_id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, event)
 	})
+	if errors.Is(err, errTokenUsed) {
+		return false, nil
+	}
+	return err == nil, err
 }
@@ -1,34 +1,61 @@
 package org
 
-import "github.com/go-chi/chi/v5"
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
 
 func UserRoutes(r chi.Router, handler *UserHandler) {
 	r.Route("/users", func(r chi.Router) {
-		r.Get("/", handler.List)
+		r.With(common.RequireScope(common.ScopeUsersRead)).Get("/", handler.List)
 		r.Post("/", handler.Create)
 
 		r.Route("/{userId}", func(r chi.Router) {
-			r.Get("/", handler.GetByID)
-			r.Put("/", handler.UpdateByID)
-			r.Delete("/", handler.DeleteByID)
+			r.With(common.RequireScope(common.ScopeUsersRead)).Get("/", handler.GetByID)
+
+			r.Group(func(r chi.Router) {
+				r.Use(common.RequireScope(common.ScopeUsersWrite))
+				r.Put("/", handler.UpdateByID)
+				r.Patch("/", handler.PatchByID)
+				r.Delete("/", handler.DeleteByID)
+				r.With(common.RequireAuth).Put("/password", handler.ChangePassword)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(common.RequireAdmin)
+				r.Post("/reactivate", handler.Reactivate)
+				r.Delete("/purge", handler.Purge)
+			})
 		})
 	})
 }
 
+func PasswordResetRoutes(r chi.Router, handler *PasswordResetHandler) {
+	r.Route("/password", func(r chi.Router) {
+		r.Post("/forgot", handler.Forgot)
+		r.Post("/reset", handler.Reset)
+	})
+}
+
 func TeamRoutes(r chi.Router, handler *TeamHandler) {
 	r.Route("/teams", func(r chi.Router) {
-		r.Get("/", handler.List)
-		r.Post("/", handler.Create)
+		r.With(common.RequireScope(common.ScopeTeamsRead)).Get("/", handler.List)
+		r.With(common.RequireScope(common.ScopeTeamsWrite)).Post("/", handler.Create)
 
 		r.Route("/{teamId}", func(r chi.Router) {
-			r.Get("/", handler.GetByID)
-			r.Put("/", handler.UpdateByID)
-			r.Delete("/", handler.DeleteByID)
-
-			r.Route("/members", func(r chi.Router) {
-				r.Get("/", handler.ListTeamMembers)
-				r.Post("/", handler.AddMember)
-				r.Delete("/", handler.RemoveMember)
+			r.Group(func(r chi.Router) {
+				r.Use(common.RequireScope(common.ScopeTeamsRead))
+				r.Get("/", handler.GetByID)
+				r.Get("/members", handler.ListTeamMembers)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(common.RequireScope(common.ScopeTeamsWrite))
+				r.Put("/", handler.UpdateByID)
+				r.Patch("/", handler.PatchByID)
+				r.Delete("/", handler.DeleteByID)
+				r.Post("/members", handler.AddMember)
+				r.Delete("/members", handler.RemoveMember)
 			})
 		})
 	})
@@ -3,50 +3,85 @@ package org
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
 	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/mail"
+	"github.com/StefanShivarov/gollab-backend/internal/notifications"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// PasswordGuard throttles password checks, sharing the per-account and per-IP failure counters
+// of the login lockout.
+type PasswordGuard interface {
+	Check(userID *uuid.UUID, ip string) error
+	RecordFailure(userID *uuid.UUID, ip string) error
+	RecordSuccess(userID uuid.UUID) error
+}
+
 type UserService struct {
 	Repo      UserRepository
 	Validator *validator.Validate
+	Policy    PasswordPolicy
+	// Guard is set once the lockout service exists, which itself depends on the user service.
+	Guard PasswordGuard
 }
 
-func NewUserService(repo UserRepository, validator *validator.Validate) *UserService {
+func NewUserService(repo UserRepository, validator *validator.Validate, policy PasswordPolicy) *UserService {
 	return &UserService{
 		Repo:      repo,
 		Validator: validator,
+		Policy:    policy,
 	}
 }
 
-func (s *UserService) Create(req CreateUserRequest) (*UserResponse, error) {
+func (s *UserService) Create(actor audit.Actor, req CreateUserRequest) (*UserResponse, error) {
 	if err := s.Validator.Struct(req); err != nil {
 		return nil, err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hash, err := s.hashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &User{
+		BaseEntity:   common.BaseEntity{ID: uuid.New()},
 		Email:        req.Email,
 		Name:         req.Name,
-		PasswordHash: string(hash),
+		PasswordHash: hash,
 		Role:         Standard,
 	}
 
-	if err := s.Repo.Create(user); err != nil {
+	event, err := audit.NewEvent(actor, audit.UserCreated, audit.TargetUser, user.ID, nil, ToUserResponse(user))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(user, event); err != nil {
 		return nil, err
 	}
 
 	return ToUserResponse(user), nil
 }
 
+func (s *UserService) hashPassword(password string) (string, error) {
+	if err := s.Policy.Validate(password); err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
 func (s *UserService) findByID(id uuid.UUID) (*User, error) {
 	user, err := s.Repo.GetByID(id)
 	if err != nil {
@@ -66,38 +101,229 @@ func (s *UserService) GetByID(id uuid.UUID) (*UserResponse, error) {
 	return ToUserResponse(user), nil
 }
 
-func (s *UserService) UpdateByID(id uuid.UUID, req UpdateUserRequest) (*UserResponse, error) {
+func (s *UserService) UpdateByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, req UpdateUserRequest) (*UserResponse, error) {
 	user, err := s.findByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ifMatch.Check(user.Version); err != nil {
+		return nil, err
+	}
+
 	if err := s.Validator.Struct(req); err != nil {
 		return nil, common.BadRequest(err.Error())
 	}
 
+	before := ToUserResponse(user)
 	if req.Name != "" {
 		user.Name = req.Name
 	}
 
-	if err := s.Repo.Update(user); err != nil {
+	event, err := audit.NewEvent(actor, audit.UserUpdated, audit.TargetUser, user.ID, before, ToUserResponse(user))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Update(user, event); err != nil {
+		return nil, err
+	}
+
+	return ToUserResponse(user), nil
+}
+
+// PatchByID applies a JSON merge patch to the user and validates the result.
+func (s *UserService) PatchByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, patch []byte) (*UserResponse, error) {
+	user, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(user.Version); err != nil {
+		return nil, err
+	}
+
+	var doc UserDocument
+	if err := common.MergePatch(ToUserDocument(user), patch, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validator.Struct(doc); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	before := ToUserResponse(user)
+	user.Name = doc.Name
+
+	event, err := audit.NewEvent(actor, audit.UserUpdated, audit.TargetUser, user.ID, before, ToUserResponse(user))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Update(user, event); err != nil {
+		return nil, err
+	}
+
+	return ToUserResponse(user), nil
+}
+
+func (s *UserService) Authenticate(email, password string) (*User, error) {
+	user, err := s.Repo.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.Unauthorized("Invalid email or password!")
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, common.Unauthorized("Invalid email or password!")
+	}
+
+	if !user.IsActive() {
+		return nil, common.Forbidden("Account is deactivated!")
+	}
+	return user, nil
+}
+
+func (s *UserService) VerifyPassword(id uuid.UUID, password, ip string) (*User, error) {
+	user, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkPassword(user, password, ip)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, common.BadRequest("Password is incorrect!")
+	}
+	return user, nil
+}
+
+// checkPassword compares the password with the user's hash. Wrong passwords count towards the
+// lockout like failed logins, so no endpoint can be used to guess passwords unthrottled.
+func (s *UserService) checkPassword(user *User, password, ip string) (bool, error) {
+	if err := s.Guard.Check(&user.ID, ip); err != nil {
+		return false, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return false, s.Guard.RecordFailure(&user.ID, ip)
+	}
+	return true, s.Guard.RecordSuccess(user.ID)
+}
+
+// ChangePassword lets users change their own password. Their other sessions are ended, so a
+// session opened with the old password doesn't outlive it.
+func (s *UserService) ChangePassword(actor audit.Actor, p *common.Principal, id uuid.UUID, req ChangePasswordRequest) error {
+	if p.UserID != id {
+		return common.Forbidden("Users can only change their own password!")
+	}
+
+	if err := s.Validator.Struct(req); err != nil {
+		return common.BadRequest(err.Error())
+	}
+
+	user, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.checkPassword(user, req.CurrentPassword, actor.IP)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return common.BadRequest("Current password is incorrect!")
+	}
+
+	hash, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	event, err := audit.NewEvent(actor, audit.UserPasswordChanged, audit.TargetUser, user.ID, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	return s.Repo.ChangePassword(user, p.SessionID, event)
+}
+
+func (s *UserService) Deactivate(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition) error {
+	user, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := ifMatch.Check(user.Version); err != nil {
+		return err
+	}
+
+	if !user.IsActive() {
+		return nil
+	}
+
+	before := ToUserResponse(user)
+	now := time.Now()
+	user.DeactivatedAt = &now
+
+	event, err := audit.NewEvent(actor, audit.UserDeactivated, audit.TargetUser, user.ID, before, ToUserResponse(user))
+	if err != nil {
+		return err
+	}
+	return s.Repo.Update(user, event)
+}
+
+func (s *UserService) Reactivate(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition) (*UserResponse, error) {
+	user, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(user.Version); err != nil {
+		return nil, err
+	}
+
+	before := ToUserResponse(user)
+	user.DeactivatedAt = nil
+
+	event, err := audit.NewEvent(actor, audit.UserReactivated, audit.TargetUser, user.ID, before, ToUserResponse(user))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Update(user, event); err != nil {
 		return nil, err
 	}
 
 	return ToUserResponse(user), nil
 }
 
-func (s *UserService) DeleteByID(id uuid.UUID) error {
-	_, err := s.findByID(id)
+// Purge hard-deletes a deactivated user. The database cascades the delete to everything they authored.
+func (s *UserService) Purge(actor audit.Actor, id uuid.UUID) error {
+	user, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+
+	if user.IsActive() {
+		return common.Conflict("Only deactivated users can be purged!")
+	}
+
+	event, err := audit.NewEvent(actor, audit.UserPurged, audit.TargetUser, user.ID, ToUserResponse(user), nil)
 	if err != nil {
 		return err
 	}
-	return s.Repo.DeleteByID(id)
+	return s.Repo.DeleteByID(id, event)
 }
 
-func (s *UserService) List(page, size int) (*common.PaginatedResponse[UserResponse], error) {
+func (s *UserService) List(page, size int, includeDeactivated bool) (*common.PaginatedResponse[UserResponse], error) {
 	offset := (page - 1) * size
-	users, total, err := s.Repo.List(offset, size)
+	users, total, err := s.Repo.List(offset, size, includeDeactivated)
 	if err != nil {
 		return nil, err
 	}
@@ -116,16 +342,23 @@ func (s *UserService) List(page, size int) (*common.PaginatedResponse[UserRespon
 }
 
 type TeamService struct {
-	Repo        TeamRepository
-	UserService *UserService
-	Validator   *validator.Validate
+	Repo                TeamRepository
+	UserService         *UserService
+	NotificationService *notifications.NotificationService
+	Validator           *validator.Validate
 }
 
-func NewTeamService(repo TeamRepository, userService *UserService, validator *validator.Validate) *TeamService {
+func NewTeamService(
+	repo TeamRepository,
+	userService *UserService,
+	notificationService *notifications.NotificationService,
+	validator *validator.Validate,
+) *TeamService {
 	return &TeamService{
-		Repo:        repo,
-		UserService: userService,
-		Validator:   validator,
+		Repo:                repo,
+		UserService:         userService,
+		NotificationService: notificationService,
+		Validator:           validator,
 	}
 }
 
@@ -149,33 +382,44 @@ func (s *TeamService) List(page, size int) (*common.PaginatedResponse[TeamRespon
 	}, nil
 }
 
-func (s *TeamService) Create(creatorID uuid.UUID, req CreateTeamRequest) (*TeamResponse, error) {
+func (s *TeamService) Create(actor audit.Actor, creatorID uuid.UUID, req CreateTeamRequest) (*TeamResponse, error) {
 	if err := s.Validator.Struct(req); err != nil {
 		return nil, common.BadRequest(err.Error())
 	}
 
 	team := &Team{
+		BaseEntity:  common.BaseEntity{ID: uuid.New()},
 		Name:        req.Name,
 		Description: req.Description,
 	}
 
-	if err := s.Repo.CreateTeamWithOwner(team, creatorID); err != nil {
+	event, err := audit.NewEvent(actor, audit.TeamCreated, audit.TargetTeam, team.ID, nil, ToTeamResponse(team))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.CreateTeamWithOwner(team, creatorID, event); err != nil {
 		return nil, err
 	}
 
 	return ToTeamResponse(team), nil
 }
 
-func (s *TeamService) UpdateByID(id uuid.UUID, req UpdateTeamRequest) (*TeamResponse, error) {
+func (s *TeamService) UpdateByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, req UpdateTeamRequest) (*TeamResponse, error) {
 	team, err := s.findByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ifMatch.Check(team.Version); err != nil {
+		return nil, err
+	}
+
 	if err := s.Validator.Struct(req); err != nil {
 		return nil, common.BadRequest(err.Error())
 	}
 
+	before := ToTeamResponse(team)
 	if req.Name != "" {
 		team.Name = req.Name
 	}
@@ -184,19 +428,69 @@ func (s *TeamService) UpdateByID(id uuid.UUID, req UpdateTeamRequest) (*TeamResp
 		team.Description = req.Description
 	}
 
-	if err := s.Repo.Update(team); err != nil {
+	event, err := audit.NewEvent(actor, audit.TeamUpdated, audit.TargetTeam, team.ID, before, ToTeamResponse(team))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Update(team, event); err != nil {
 		return nil, err
 	}
 
 	return ToTeamResponse(team), nil
 }
 
-func (s *TeamService) DeleteByID(id uuid.UUID) error {
-	_, err := s.findByID(id)
+// PatchByID applies a JSON merge patch to the team, so unlike UpdateByID it can clear the description.
+func (s *TeamService) PatchByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition, patch []byte) (*TeamResponse, error) {
+	team, err := s.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ifMatch.Check(team.Version); err != nil {
+		return nil, err
+	}
+
+	var doc TeamDocument
+	if err := common.MergePatch(ToTeamDocument(team), patch, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validator.Struct(doc); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	before := ToTeamResponse(team)
+	team.Name = doc.Name
+	team.Description = doc.Description
+
+	event, err := audit.NewEvent(actor, audit.TeamUpdated, audit.TargetTeam, team.ID, before, ToTeamResponse(team))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Update(team, event); err != nil {
+		return nil, err
+	}
+
+	return ToTeamResponse(team), nil
+}
+
+func (s *TeamService) DeleteByID(actor audit.Actor, id uuid.UUID, ifMatch common.Precondition) error {
+	team, err := s.findByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := ifMatch.Check(team.Version); err != nil {
+		return err
+	}
+
+	event, err := audit.NewEvent(actor, audit.TeamDeleted, audit.TargetTeam, team.ID, ToTeamResponse(team), nil)
 	if err != nil {
 		return err
 	}
-	return s.Repo.DeleteByID(id)
+	return s.Repo.DeleteByID(id, event)
 }
 
 func (s *TeamService) GetByID(id uuid.UUID) (*TeamResponse, error) {
@@ -218,29 +512,55 @@ func (s *TeamService) findByID(id uuid.UUID) (*Team, error) {
 	return team, nil
 }
 
-func (s *TeamService) AddMembership(request CreateMembershipRequest) error {
+func (s *TeamService) AddMembership(actor audit.Actor, request CreateMembershipRequest) error {
 	if err := s.Validator.Struct(request); err != nil {
 		return common.BadRequest(err.Error())
 	}
 
-	if _, err := s.findByID(request.TeamID); err != nil {
+	team, err := s.findByID(request.TeamID)
+	if err != nil {
 		return err
 	}
 
-	if _, err := s.UserService.findByID(request.UserID); err != nil {
+	user, err := s.UserService.findByID(request.UserID)
+	if err != nil {
 		return err
 	}
 
+	if !user.IsActive() {
+		return common.BadRequest("Deactivated users can't be added to teams!")
+	}
+
 	m := &Membership{
 		TeamID: request.TeamID,
 		UserID: request.UserID,
 		Role:   request.Role,
 	}
 
-	return s.Repo.AddMembership(m)
+	event, err := audit.NewEvent(actor, audit.MemberAdded, audit.TargetTeam, m.TeamID, nil, memberSnapshot{UserID: m.UserID, Role: m.Role})
+	if err != nil {
+		return err
+	}
+
+	message, err := outbox.NewMessage(m.TeamID, outbox.MemberAdded, MembershipPayload{TeamID: m.TeamID, UserID: m.UserID, Role: m.Role})
+	if err != nil {
+		return err
+	}
+
+	if err := s.Repo.AddMembership(m, event, message); err != nil {
+		return err
+	}
+
+	s.NotificationService.Send(actor.UserID, &notifications.Notification{
+		UserID: m.UserID,
+		Type:   notifications.TeamAdded,
+		Title:  fmt.Sprintf("You were added to the team %s", team.Name),
+		TeamID: &m.TeamID,
+	})
+	return nil
 }
 
-func (s *TeamService) RemoveMembership(teamID, userID uuid.UUID) error {
+func (s *TeamService) RemoveMembership(actor audit.Actor, teamID, userID uuid.UUID) error {
 	if _, err := s.findByID(teamID); err != nil {
 		return err
 	}
@@ -248,7 +568,30 @@ func (s *TeamService) RemoveMembership(teamID, userID uuid.UUID) error {
 	if _, err := s.UserService.findByID(userID); err != nil {
 		return err
 	}
-	return s.Repo.DeleteMembershipByTeamIDAndUserID(teamID, userID)
+
+	event, err := audit.NewEvent(actor, audit.MemberRemoved, audit.TargetTeam, teamID, memberSnapshot{UserID: userID}, nil)
+	if err != nil {
+		return err
+	}
+
+	message, err := outbox.NewMessage(teamID, outbox.MemberRemoved, MembershipPayload{TeamID: teamID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	deleted, err := s.Repo.DeleteMembershipByTeamIDAndUserID(teamID, userID, event, message)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return common.NotFound(fmt.Sprintf("User with id %s is not a member of team %s!", userID, teamID))
+	}
+	return nil
+}
+
+// memberSnapshot is how membership changes appear in the audit log of the team.
+type memberSnapshot struct {
+	UserID uuid.UUID `json:"memberId"`
+	Role   TeamRole  `json:"memberRole,omitempty"`
 }
 
 func (s *TeamService) ListMembers(teamID uuid.UUID) ([]MemberResponse, error) {
@@ -259,3 +602,126 @@ func (s *TeamService) ListMembers(teamID uuid.UUID) ([]MemberResponse, error) {
 
 	return memberships, nil
 }
+
+func (s *TeamService) IsMember(teamID, userID uuid.UUID) (bool, error) {
+	return s.Repo.IsMember(teamID, userID)
+}
+
+func (s *TeamService) FindMembersByName(teamID uuid.UUID, names []string) ([]User, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return s.Repo.FindMembersByName(teamID, names)
+}
+
+func (s *TeamService) IsManager(teamID, userID uuid.UUID) (bool, error) {
+	return s.Repo.HasRole(teamID, userID, ProjectManager)
+}
+
+type PasswordResetService struct {
+	Repo        PasswordResetRepository
+	UserService *UserService
+	Mailer      mail.Mailer
+	Validator   *validator.Validate
+	TokenTTL    time.Duration
+	BaseURL     string
+}
+
+func NewPasswordResetService(
+	repo PasswordResetRepository,
+	userService *UserService,
+	mailer mail.Mailer,
+	validator *validator.Validate,
+	tokenTTL time.Duration,
+	baseURL string,
+) *PasswordResetService {
+	return &PasswordResetService{
+		Repo:        repo,
+		UserService: userService,
+		Mailer:      mailer,
+		Validator:   validator,
+		TokenTTL:    tokenTTL,
+		BaseURL:     baseURL,
+	}
+}
+
+func (s *PasswordResetService) RequestReset(req ForgotPasswordRequest) error {
+	if err := s.Validator.Struct(req); err != nil {
+		return common.BadRequest(err.Error())
+	}
+
+	user, err := s.UserService.Repo.GetByEmail(req.Email)
+	if err != nil {
+		// Unknown emails are not reported, so the endpoint can't be used to probe for accounts.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if !user.IsActive() {
+		return nil
+	}
+
+	raw, err := common.NewRandomToken()
+	if err != nil {
+		return err
+	}
+
+	token := &PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: common.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.TokenTTL),
+	}
+	if err := s.Repo.Create(token); err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Gollab password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to reset your password. It expires in %s.\n\n%s/reset-password?token=%s\n\nIf you didn't request a reset, you can ignore this email.\n",
+			user.Name, s.TokenTTL, s.BaseURL, raw,
+		),
+	})
+}
+
+func (s *PasswordResetService) ResetPassword(actor audit.Actor, req ResetPasswordRequest) error {
+	if err := s.Validator.Struct(req); err != nil {
+		return common.BadRequest(err.Error())
+	}
+
+	token, err := s.Repo.GetByTokenHash(common.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.BadRequest("Invalid or expired reset token!")
+		}
+		return err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return common.BadRequest("Invalid or expired reset token!")
+	}
+
+	hash, err := s.UserService.hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// The caller is anonymous, so the token's owner is recorded as the actor.
+	actor.UserID = &token.UserID
+	event, err := audit.NewEvent(actor, audit.UserPasswordReset, audit.TargetUser, token.UserID, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	reset, err := s.Repo.ResetPassword(token, hash, event)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return common.BadRequest("Invalid or expired reset token!")
+	}
+	return nil
+}
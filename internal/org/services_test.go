@@ -1,13 +1,20 @@
 package org
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
 	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/mail"
+	"github.com/StefanShivarov/gollab-backend/internal/notifications"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -23,27 +30,69 @@ func (m *userRepositoryMock) GetByID(id uuid.UUID) (*User, error) {
 	return nil, args.Error(1)
 }
 
-func (m *userRepositoryMock) Create(user *User) error {
-	return m.Called(user).Error(0)
+func (m *userRepositoryMock) GetByEmail(email string) (*User, error) {
+	args := m.Called(email)
+	if u := args.Get(0); u != nil {
+		return u.(*User), args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
-func (m *userRepositoryMock) Update(user *User) error {
-	return m.Called(user).Error(0)
+func (m *userRepositoryMock) Create(user *User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
 }
 
-func (m *userRepositoryMock) DeleteByID(id uuid.UUID) error {
-	return m.Called(id).Error(0)
+func (m *userRepositoryMock) Update(user *User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
 }
 
-func (m *userRepositoryMock) List(offset, limit int) ([]User, int, error) {
-	args := m.Called(offset, limit)
+func (m *userRepositoryMock) ChangePassword(user *User, keepSessionID *uuid.UUID, event *audit.Event) error {
+	return m.Called(user, keepSessionID, event).Error(0)
+}
+
+func (m *userRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return m.Called(id, event).Error(0)
+}
+
+func (m *userRepositoryMock) List(offset, limit int, includeDeactivated bool) ([]User, int, error) {
+	args := m.Called(offset, limit, includeDeactivated)
 	return args.Get(0).([]User), args.Int(1), args.Error(2)
 }
 
+type passwordGuardMock struct {
+	mock.Mock
+}
+
+func (m *passwordGuardMock) Check(userID *uuid.UUID, ip string) error {
+	return m.Called(userID, ip).Error(0)
+}
+
+func (m *passwordGuardMock) RecordFailure(userID *uuid.UUID, ip string) error {
+	return m.Called(userID, ip).Error(0)
+}
+
+func (m *passwordGuardMock) RecordSuccess(userID uuid.UUID) error {
+	return m.Called(userID).Error(0)
+}
+
+var testActor = audit.Actor{IP: "127.0.0.1", RequestID: "test-request"}
+
+// auditEvent matches the event a repository is asked to record alongside a change.
+func auditEvent(action string) any {
+	return mock.MatchedBy(func(e *audit.Event) bool {
+		return e.Action == action && e.IP == testActor.IP && e.RequestID == testActor.RequestID
+	})
+}
+
 func setupUserServiceTest() (*UserService, *userRepositoryMock, *validator.Validate) {
 	mockRepo := &userRepositoryMock{}
 	v := validator.New()
-	service := NewUserService(mockRepo, v)
+	service := NewUserService(mockRepo, v, DefaultPasswordPolicy())
+	guard := &passwordGuardMock{}
+	guard.On("Check", mock.Anything, mock.Anything).Return(nil).Maybe()
+	guard.On("RecordFailure", mock.Anything, mock.Anything).Return(nil).Maybe()
+	guard.On("RecordSuccess", mock.Anything).Return(nil).Maybe()
+	service.Guard = guard
 	return service, mockRepo, v
 }
 
@@ -56,9 +105,9 @@ func TestUserService_Create(t *testing.T) {
 		Password: "testPass123",
 	}
 
-	repo.On("Create", mock.AnythingOfType("*org.User")).Return(nil)
+	repo.On("Create", mock.AnythingOfType("*org.User"), auditEvent(audit.UserCreated)).Return(nil)
 
-	res, err := service.Create(req)
+	res, err := service.Create(testActor, req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
@@ -77,12 +126,28 @@ func TestUserService_Create_InvalidRequest(t *testing.T) {
 		Password: "short",
 	}
 
-	res, err := service.Create(req)
+	res, err := service.Create(testActor, req)
 
 	assert.Nil(t, res)
 	assert.Error(t, err)
 }
 
+func TestUserService_Create_WeakPassword(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+
+	req := CreateUserRequest{
+		Name:     "testUser",
+		Email:    "test@test.com",
+		Password: "alllowercase",
+	}
+
+	res, err := service.Create(testActor, req)
+
+	assert.Nil(t, res)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestUserService_GetByID(t *testing.T) {
 	service, repo, _ := setupUserServiceTest()
 	id := uuid.New()
@@ -105,7 +170,7 @@ func TestUserService_GetByID(t *testing.T) {
 
 func TestUserService_GetByID_NotFound(t *testing.T) {
 	repo := new(userRepositoryMock)
-	service := NewUserService(repo, validator.New())
+	service := NewUserService(repo, validator.New(), DefaultPasswordPolicy())
 
 	id := uuid.New()
 	repo.On("GetByID", id).Return(nil, gorm.ErrRecordNotFound)
@@ -123,39 +188,218 @@ func TestUserService_UpdateByID(t *testing.T) {
 	user := &User{BaseEntity: common.BaseEntity{ID: id}, Name: "Old"}
 
 	repo.On("GetByID", id).Return(user, nil)
-	repo.On("Update", user).Return(nil)
+	repo.On("Update", user, auditEvent(audit.UserUpdated)).Return(nil)
 
-	resp, err := service.UpdateByID(id, UpdateUserRequest{Name: "New"})
+	resp, err := service.UpdateByID(testActor, id, nil, UpdateUserRequest{Name: "New"})
 
 	assert.NoError(t, err)
 	assert.Equal(t, "New", resp.Name)
 }
 
+func TestUserService_UpdateByID_StaleVersion(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	user := &User{BaseEntity: common.BaseEntity{ID: id, Version: 3}, Name: "Old"}
+	repo.On("GetByID", id).Return(user, nil)
+
+	resp, err := service.UpdateByID(testActor, id, common.Precondition{2}, UpdateUserRequest{Name: "New"})
+
+	assert.Nil(t, resp)
+	var apiErr *common.ApiError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusPreconditionFailed, apiErr.StatusCode)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserService_UpdateByID_ConcurrentWrite(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	user := &User{BaseEntity: common.BaseEntity{ID: id, Version: 3}, Name: "Old"}
+	repo.On("GetByID", id).Return(user, nil)
+	repo.On("Update", user, auditEvent(audit.UserUpdated)).Return(common.ErrVersionConflict)
+
+	resp, err := service.UpdateByID(testActor, id, common.Precondition{3}, UpdateUserRequest{Name: "New"})
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, common.ErrVersionConflict)
+}
+
 func TestUserService_UpdateByID_ValidationError(t *testing.T) {
 	service, repo, _ := setupUserServiceTest()
 	id := uuid.New()
 	user := &User{BaseEntity: common.BaseEntity{ID: id}, Name: "Old"}
 	repo.On("GetByID", id).Return(user, nil)
-	repo.On("Update", user).Return(nil)
+	repo.On("Update", user, auditEvent(audit.UserUpdated)).Return(nil)
 
 	req := UpdateUserRequest{Name: ""} // invalid: empty string
 
 	// Should still pass validation because "omitempty,min=2" allows empty
-	resp, err := service.UpdateByID(id, req)
+	resp, err := service.UpdateByID(testActor, id, nil, req)
 	assert.NoError(t, err)
 	assert.Equal(t, "Old", resp.Name)
 }
 
-func TestUserService_DeleteByID(t *testing.T) {
+func TestUserService_Authenticate(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Pass1234"), bcrypt.MinCost)
+	user := &User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Email: "test@test.com", PasswordHash: string(hash)}
+	repo.On("GetByEmail", user.Email).Return(user, nil)
+	repo.On("GetByEmail", "unknown@test.com").Return(nil, gorm.ErrRecordNotFound)
+
+	res, err := service.Authenticate(user.Email, "Pass1234")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, res.ID)
+
+	_, err = service.Authenticate(user.Email, "WrongPass1")
+	assert.Error(t, err)
+
+	_, err = service.Authenticate("unknown@test.com", "Pass1234")
+	assert.Error(t, err)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
 	service, repo, _ := setupUserServiceTest()
 	id := uuid.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("OldPass123"), bcrypt.MinCost)
+	user := &User{BaseEntity: common.BaseEntity{ID: id}, PasswordHash: string(hash)}
 
-	repo.On("GetByID", id).Return(&User{BaseEntity: common.BaseEntity{ID: id}}, nil)
-	repo.On("DeleteByID", id).Return(nil)
+	sessionID := uuid.New()
+	p := &common.Principal{UserID: id, SessionID: &sessionID}
 
-	err := service.DeleteByID(id)
+	repo.On("GetByID", id).Return(user, nil)
+	repo.On("ChangePassword", user, &sessionID, auditEvent(audit.UserPasswordChanged)).Return(nil)
+
+	err := service.ChangePassword(testActor, p, id, ChangePasswordRequest{CurrentPassword: "OldPass123", NewPassword: "NewPass456"})
 
 	assert.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("NewPass456")))
+	repo.AssertExpectations(t)
+}
+
+func TestUserService_ChangePassword_WrongCurrentPassword(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("OldPass123"), bcrypt.MinCost)
+	user := &User{BaseEntity: common.BaseEntity{ID: id}, PasswordHash: string(hash)}
+
+	repo.On("GetByID", id).Return(user, nil)
+
+	err := service.ChangePassword(testActor, &common.Principal{UserID: id}, id, ChangePasswordRequest{CurrentPassword: "WrongPass123", NewPassword: "NewPass456"})
+
+	assert.Error(t, err)
+	service.Guard.(*passwordGuardMock).AssertCalled(t, "RecordFailure", &id, testActor.IP)
+	repo.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_ChangePassword_LockedOut(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("OldPass123"), bcrypt.MinCost)
+	user := &User{BaseEntity: common.BaseEntity{ID: id}, PasswordHash: string(hash)}
+	guard := &passwordGuardMock{}
+	guard.On("Check", &id, testActor.IP).Return(common.Locked("Account is temporarily locked!", time.Minute))
+	service.Guard = guard
+
+	repo.On("GetByID", id).Return(user, nil)
+
+	err := service.ChangePassword(testActor, &common.Principal{UserID: id}, id, ChangePasswordRequest{CurrentPassword: "OldPass123", NewPassword: "NewPass456"})
+
+	assert.Equal(t, http.StatusLocked, err.(*common.ApiError).StatusCode)
+	guard.AssertNotCalled(t, "RecordSuccess", mock.Anything)
+	repo.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_ChangePassword_AnotherUser(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+
+	err := service.ChangePassword(testActor, &common.Principal{UserID: uuid.New()}, uuid.New(), ChangePasswordRequest{CurrentPassword: "OldPass123", NewPassword: "NewPass456"})
+
+	assert.Equal(t, http.StatusForbidden, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestUserService_Deactivate(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	user := &User{BaseEntity: common.BaseEntity{ID: id}}
+
+	repo.On("GetByID", id).Return(user, nil)
+	repo.On("Update", user, auditEvent(audit.UserDeactivated)).Return(nil)
+
+	err := service.Deactivate(testActor, id, nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user.DeactivatedAt)
+	repo.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Reactivate(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	deactivatedAt := time.Now()
+	user := &User{BaseEntity: common.BaseEntity{ID: id}, DeactivatedAt: &deactivatedAt}
+
+	repo.On("GetByID", id).Return(user, nil)
+	repo.On("Update", user, auditEvent(audit.UserReactivated)).Return(nil)
+
+	resp, err := service.Reactivate(testActor, id, nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, resp.DeactivatedAt)
+}
+
+func TestUserService_Reactivate_StaleVersion(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	deactivatedAt := time.Now()
+	user := &User{BaseEntity: common.BaseEntity{ID: id, Version: 2}, DeactivatedAt: &deactivatedAt}
+
+	repo.On("GetByID", id).Return(user, nil)
+
+	resp, err := service.Reactivate(testActor, id, common.Precondition{1})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, http.StatusPreconditionFailed, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Purge(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+	deactivatedAt := time.Now()
+
+	repo.On("GetByID", id).Return(&User{BaseEntity: common.BaseEntity{ID: id}, DeactivatedAt: &deactivatedAt}, nil)
+	repo.On("DeleteByID", id, auditEvent(audit.UserPurged)).Return(nil)
+
+	err := service.Purge(testActor, id)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestUserService_Purge_ActiveUser(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	id := uuid.New()
+
+	repo.On("GetByID", id).Return(&User{BaseEntity: common.BaseEntity{ID: id}}, nil)
+
+	err := service.Purge(testActor, id)
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Authenticate_Deactivated(t *testing.T) {
+	service, repo, _ := setupUserServiceTest()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Pass1234"), bcrypt.MinCost)
+	deactivatedAt := time.Now()
+	user := &User{Email: "test@test.com", PasswordHash: string(hash), DeactivatedAt: &deactivatedAt}
+	repo.On("GetByEmail", user.Email).Return(user, nil)
+
+	res, err := service.Authenticate(user.Email, "Pass1234")
+
+	assert.Nil(t, res)
+	assert.Error(t, err)
 }
 
 func TestUserService_List(t *testing.T) {
@@ -165,9 +409,9 @@ func TestUserService_List(t *testing.T) {
 		{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Alice", Email: "alice@test.com"},
 		{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Bob", Email: "bob@test.com"},
 	}
-	repoMock.On("List", 0, 2).Return(users, 2, nil)
+	repoMock.On("List", 0, 2, false).Return(users, 2, nil)
 
-	resp, err := service.List(1, 2)
+	resp, err := service.List(1, 2, false)
 	assert.NoError(t, err)
 	assert.Len(t, resp.Items, 2)
 	assert.Equal(t, "Alice", resp.Items[0].Name)
@@ -187,24 +431,30 @@ func (m *teamRepositoryMock) GetByID(id uuid.UUID) (*Team, error) {
 	return nil, args.Error(1)
 }
 
-func (m *teamRepositoryMock) Update(team *Team) error {
-	return m.Called(team).Error(0)
+func (m *teamRepositoryMock) Update(team *Team, event *audit.Event) error {
+	return m.Called(team, event).Error(0)
 }
 
-func (m *teamRepositoryMock) DeleteByID(id uuid.UUID) error {
-	return m.Called(id).Error(0)
+func (m *teamRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return m.Called(id, event).Error(0)
 }
 
-func (m *teamRepositoryMock) CreateTeamWithOwner(team *Team, creatorID uuid.UUID) error {
-	return m.Called(team, creatorID).Error(0)
+func (m *teamRepositoryMock) CreateTeamWithOwner(team *Team, creatorID uuid.UUID, event *audit.Event) error {
+	return m.Called(team, creatorID, event).Error(0)
 }
 
-func (m *teamRepositoryMock) AddMembership(mem *Membership) error {
-	return m.Called(mem).Error(0)
+func (m *teamRepositoryMock) AddMembership(mem *Membership, event *audit.Event, message *outbox.Message) error {
+	return m.Called(mem, event, message).Error(0)
 }
 
-func (m *teamRepositoryMock) DeleteMembershipByTeamIDAndUserID(teamID, userID uuid.UUID) error {
-	return m.Called(teamID, userID).Error(0)
+func (m *teamRepositoryMock) DeleteMembershipByTeamIDAndUserID(teamID, userID uuid.UUID, event *audit.Event, message *outbox.Message) (bool, error) {
+	args := m.Called(teamID, userID, event, message)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *teamRepositoryMock) HasRole(teamID, userID uuid.UUID, role TeamRole) (bool, error) {
+	args := m.Called(teamID, userID, role)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *teamRepositoryMock) ListMembers(teamID uuid.UUID) ([]MemberResponse, error) {
@@ -212,6 +462,16 @@ func (m *teamRepositoryMock) ListMembers(teamID uuid.UUID) ([]MemberResponse, er
 	return args.Get(0).([]MemberResponse), args.Error(1)
 }
 
+func (m *teamRepositoryMock) IsMember(teamID, userID uuid.UUID) (bool, error) {
+	args := m.Called(teamID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *teamRepositoryMock) FindMembersByName(teamID uuid.UUID, names []string) ([]User, error) {
+	args := m.Called(teamID, names)
+	return args.Get(0).([]User), args.Error(1)
+}
+
 func (m *teamRepositoryMock) List(offset, limit int) ([]Team, int, error) {
 	args := m.Called(offset, limit)
 	teams, _ := args.Get(0).([]Team)
@@ -219,24 +479,76 @@ func (m *teamRepositoryMock) List(offset, limit int) ([]Team, int, error) {
 	return teams, total, args.Error(2)
 }
 
+type notificationRepositoryMock struct {
+	mock.Mock
+	notifications.NotificationRepository
+}
+
+func (m *notificationRepositoryMock) IsEnabled(userID uuid.UUID, t notifications.NotificationType) (bool, error) {
+	args := m.Called(userID, t)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *notificationRepositoryMock) Create(n *notifications.Notification) error {
+	return m.Called(n).Error(0)
+}
+
+func (m *notificationRepositoryMock) GetEmailSettings(userID uuid.UUID) (*notifications.EmailSettings, error) {
+	args := m.Called(userID)
+	if s := args.Get(0); s != nil {
+		return s.(*notifications.EmailSettings), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func setupTeamServiceTest() (*TeamService, *teamRepositoryMock, *UserService, *userRepositoryMock, *validator.Validate) {
 	v := validator.New()
 	userRepoMock := &userRepositoryMock{}
-	userService := NewUserService(userRepoMock, v)
+	userService := NewUserService(userRepoMock, v, DefaultPasswordPolicy())
 	teamRepoMock := &teamRepositoryMock{}
-	teamService := NewTeamService(teamRepoMock, userService, v)
+	notificationRepoMock := &notificationRepositoryMock{}
+	notificationRepoMock.On("IsEnabled", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+	notificationRepoMock.On("Create", mock.Anything).Return(nil).Maybe()
+	notificationRepoMock.On("GetEmailSettings", mock.Anything).Return(nil, gorm.ErrRecordNotFound).Maybe()
+	notificationService := notifications.NewNotificationService(notificationRepoMock, nil, v, "", "")
+	teamService := NewTeamService(teamRepoMock, userService, notificationService, v)
 	return teamService, teamRepoMock, userService, userRepoMock, v
 }
 
+func TestTeamService_PatchByID_ClearsDescription(t *testing.T) {
+	service, repo, _, _, _ := setupTeamServiceTest()
+	team := &Team{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Team", Description: "Old"}
+	repo.On("GetByID", team.ID).Return(team, nil)
+	repo.On("Update", team, auditEvent(audit.TeamUpdated)).Return(nil)
+
+	resp, err := service.PatchByID(testActor, team.ID, nil, []byte(`{"description": null}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Team", resp.Name)
+	assert.Empty(t, resp.Description)
+}
+
+func TestTeamService_PatchByID_InvalidResult(t *testing.T) {
+	service, repo, _, _, _ := setupTeamServiceTest()
+	team := &Team{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Team"}
+	repo.On("GetByID", team.ID).Return(team, nil)
+
+	resp, err := service.PatchByID(testActor, team.ID, nil, []byte(`{"name": null}`))
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
 func TestTeamService_Create(t *testing.T) {
 	service, repo, _, _, _ := setupTeamServiceTest()
 
 	creatorID := uuid.New()
 	req := CreateTeamRequest{Name: "Team A"}
 
-	repo.On("CreateTeamWithOwner", mock.AnythingOfType("*org.Team"), creatorID).Return(nil)
+	repo.On("CreateTeamWithOwner", mock.AnythingOfType("*org.Team"), creatorID, auditEvent(audit.TeamCreated)).Return(nil)
 
-	resp, err := service.Create(creatorID, req)
+	resp, err := service.Create(testActor, creatorID, req)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "Team A", resp.Name)
@@ -247,7 +559,7 @@ func TestTeamService_Create_ValidationError(t *testing.T) {
 	creatorID := uuid.New()
 	req := CreateTeamRequest{Name: ""} // invalid: required
 
-	resp, err := service.Create(creatorID, req)
+	resp, err := service.Create(testActor, creatorID, req)
 	assert.Nil(t, resp)
 	assert.Error(t, err)
 }
@@ -277,10 +589,10 @@ func TestTeamService_UpdateByID(t *testing.T) {
 	service, repoMock, _, _, _ := setupTeamServiceTest()
 	team := &Team{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "OldName", Description: "OldDesc"}
 	repoMock.On("GetByID", team.ID).Return(team, nil)
-	repoMock.On("Update", team).Return(nil)
+	repoMock.On("Update", team, auditEvent(audit.TeamUpdated)).Return(nil)
 
 	req := UpdateTeamRequest{Name: "NewName", Description: "NewDesc"}
-	resp, err := service.UpdateByID(team.ID, req)
+	resp, err := service.UpdateByID(testActor, team.ID, nil, req)
 	assert.NoError(t, err)
 	assert.Equal(t, "NewName", resp.Name)
 	assert.Equal(t, "NewDesc", resp.Description)
@@ -291,9 +603,9 @@ func TestTeamService_DeleteByID(t *testing.T) {
 	service, repoMock, _, _, _ := setupTeamServiceTest()
 	team := &Team{BaseEntity: common.BaseEntity{ID: uuid.New()}}
 	repoMock.On("GetByID", team.ID).Return(team, nil)
-	repoMock.On("DeleteByID", team.ID).Return(nil)
+	repoMock.On("DeleteByID", team.ID, auditEvent(audit.TeamDeleted)).Return(nil)
 
-	err := service.DeleteByID(team.ID)
+	err := service.DeleteByID(testActor, team.ID, nil)
 	assert.NoError(t, err)
 	repoMock.AssertExpectations(t)
 }
@@ -320,17 +632,23 @@ func TestTeamService_AddMembership(t *testing.T) {
 	teamID := uuid.New()
 	userID := uuid.New()
 
-	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
+	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}, Name: "Team"}, nil)
 	userRepo.On("GetByID", userID).Return(&User{BaseEntity: common.BaseEntity{ID: userID}}, nil)
-	teamRepo.On("AddMembership", mock.AnythingOfType("*org.Membership")).Return(nil)
+	teamRepo.On("AddMembership", mock.AnythingOfType("*org.Membership"), auditEvent(audit.MemberAdded), mock.MatchedBy(func(m *outbox.Message) bool {
+		return m.Event == outbox.MemberAdded && m.TeamID == teamID
+	})).Return(nil)
 
-	err := service.AddMembership(CreateMembershipRequest{
+	err := service.AddMembership(testActor, CreateMembershipRequest{
 		TeamID: teamID,
 		UserID: userID,
 		Role:   Developer,
 	})
 
 	assert.NoError(t, err)
+	notificationRepo := service.NotificationService.Repo.(*notificationRepositoryMock)
+	notificationRepo.AssertCalled(t, "Create", mock.MatchedBy(func(n *notifications.Notification) bool {
+		return n.UserID == userID && n.Type == notifications.TeamAdded && *n.TeamID == teamID && n.Title == "You were added to the team Team"
+	}))
 }
 
 func TestTeamService_AddMembership_TeamNotFound(t *testing.T) {
@@ -341,7 +659,7 @@ func TestTeamService_AddMembership_TeamNotFound(t *testing.T) {
 	teamRepo.On("GetByID", teamID).Return(nil, gorm.ErrRecordNotFound)
 	userRepo.On("GetByID", userID).Return(&User{BaseEntity: common.BaseEntity{ID: userID}}, nil)
 
-	err := service.AddMembership(CreateMembershipRequest{
+	err := service.AddMembership(testActor, CreateMembershipRequest{
 		TeamID: teamID,
 		UserID: userID,
 		Role:   Developer,
@@ -357,7 +675,7 @@ func TestTeamService_AddMembership_UserNotFound(t *testing.T) {
 	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
 	userRepo.On("GetByID", userID).Return(nil, gorm.ErrRecordNotFound)
 
-	err := service.AddMembership(CreateMembershipRequest{
+	err := service.AddMembership(testActor, CreateMembershipRequest{
 		TeamID: teamID,
 		UserID: userID,
 		Role:   Developer,
@@ -365,11 +683,29 @@ func TestTeamService_AddMembership_UserNotFound(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestTeamService_AddMembership_DeactivatedUser(t *testing.T) {
+	service, teamRepo, _, userRepo, _ := setupTeamServiceTest()
+	teamID := uuid.New()
+	userID := uuid.New()
+	deactivatedAt := time.Now()
+
+	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
+	userRepo.On("GetByID", userID).Return(&User{BaseEntity: common.BaseEntity{ID: userID}, DeactivatedAt: &deactivatedAt}, nil)
+
+	err := service.AddMembership(testActor, CreateMembershipRequest{
+		TeamID: teamID,
+		UserID: userID,
+		Role:   Developer,
+	})
+	assert.Error(t, err)
+	teamRepo.AssertNotCalled(t, "AddMembership", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestTeamService_AddMembership_ValidationError(t *testing.T) {
 	service, _, _, _, _ := setupTeamServiceTest()
 	req := CreateMembershipRequest{}
 
-	err := service.AddMembership(req)
+	err := service.AddMembership(testActor, req)
 	assert.Error(t, err)
 }
 
@@ -380,20 +716,34 @@ func TestTeamService_RemoveMembership(t *testing.T) {
 
 	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
 	userRepo.On("GetByID", userID).Return(&User{BaseEntity: common.BaseEntity{ID: userID}}, nil)
-	teamRepo.On("DeleteMembershipByTeamIDAndUserID", teamID, userID).Return(nil)
+	teamRepo.On("DeleteMembershipByTeamIDAndUserID", teamID, userID, auditEvent(audit.MemberRemoved), mock.AnythingOfType("*outbox.Message")).Return(true, nil)
 
-	err := service.RemoveMembership(teamID, userID)
+	err := service.RemoveMembership(testActor, teamID, userID)
 
 	assert.NoError(t, err)
 }
 
+func TestTeamService_RemoveMembership_NotAMember(t *testing.T) {
+	service, teamRepo, _, userRepo, _ := setupTeamServiceTest()
+	teamID := uuid.New()
+	userID := uuid.New()
+
+	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
+	userRepo.On("GetByID", userID).Return(&User{BaseEntity: common.BaseEntity{ID: userID}}, nil)
+	teamRepo.On("DeleteMembershipByTeamIDAndUserID", teamID, userID, auditEvent(audit.MemberRemoved), mock.AnythingOfType("*outbox.Message")).Return(false, nil)
+
+	err := service.RemoveMembership(testActor, teamID, userID)
+
+	assert.Equal(t, http.StatusNotFound, err.(*common.ApiError).StatusCode)
+}
+
 func TestTeamService_RemoveMembership_TeamNotFound(t *testing.T) {
 	service, teamRepo, _, _, _ := setupTeamServiceTest()
 	teamID := uuid.New()
 	userID := uuid.New()
 	teamRepo.On("GetByID", teamID).Return(nil, gorm.ErrRecordNotFound)
 
-	err := service.RemoveMembership(teamID, userID)
+	err := service.RemoveMembership(testActor, teamID, userID)
 	assert.Error(t, err)
 }
 
@@ -404,7 +754,7 @@ func TestTeamService_RemoveMembership_UserNotFound(t *testing.T) {
 	teamRepo.On("GetByID", teamID).Return(&Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
 	userRepo.On("GetByID", userID).Return(nil, gorm.ErrRecordNotFound)
 
-	err := service.RemoveMembership(teamID, userID)
+	err := service.RemoveMembership(testActor, teamID, userID)
 	assert.Error(t, err)
 }
 
@@ -424,3 +774,134 @@ func TestTeamService_ListMembers(t *testing.T) {
 	assert.Len(t, resp, 1)
 	assert.Equal(t, "Alice", resp[0].Name)
 }
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+
+	assert.NoError(t, policy.Validate("Str0ng!Password"))
+	assert.Error(t, policy.Validate("Sh0rt!"))
+	assert.Error(t, policy.Validate("n0uppercase!"))
+	assert.Error(t, policy.Validate("NoDigitsHere!"))
+	assert.Error(t, policy.Validate("N0SymbolsHere"))
+}
+
+type passwordResetRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *passwordResetRepositoryMock) Create(token *PasswordResetToken) error {
+	return m.Called(token).Error(0)
+}
+
+func (m *passwordResetRepositoryMock) GetByTokenHash(hash string) (*PasswordResetToken, error) {
+	args := m.Called(hash)
+	if t := args.Get(0); t != nil {
+		return t.(*PasswordResetToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *passwordResetRepositoryMock) ResetPassword(token *PasswordResetToken, passwordHash string, event *audit.Event) (bool, error) {
+	args := m.Called(token, passwordHash, event)
+	return args.Bool(0), args.Error(1)
+}
+
+type mailerMock struct {
+	mock.Mock
+}
+
+func (m *mailerMock) Send(msg mail.Message) error {
+	return m.Called(msg).Error(0)
+}
+
+func setupPasswordResetServiceTest() (*PasswordResetService, *passwordResetRepositoryMock, *userRepositoryMock, *mailerMock) {
+	v := validator.New()
+	userRepoMock := &userRepositoryMock{}
+	userService := NewUserService(userRepoMock, v, DefaultPasswordPolicy())
+	resetRepoMock := &passwordResetRepositoryMock{}
+	mailer := &mailerMock{}
+	service := NewPasswordResetService(resetRepoMock, userService, mailer, v, 30*time.Minute, "http://localhost:3000")
+	return service, resetRepoMock, userRepoMock, mailer
+}
+
+func TestPasswordResetService_RequestReset(t *testing.T) {
+	service, resetRepo, userRepo, mailer := setupPasswordResetServiceTest()
+	user := &User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Email: "test@test.com", Name: "testUser"}
+
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	resetRepo.On("Create", mock.AnythingOfType("*org.PasswordResetToken")).Return(nil)
+	mailer.On("Send", mock.MatchedBy(func(msg mail.Message) bool {
+		return msg.To == user.Email
+	})).Return(nil)
+
+	err := service.RequestReset(ForgotPasswordRequest{Email: user.Email})
+
+	assert.NoError(t, err)
+	token := resetRepo.Calls[0].Arguments.Get(0).(*PasswordResetToken)
+	assert.Equal(t, user.ID, token.UserID)
+	assert.NotContains(t, mailer.Calls[0].Arguments.Get(0).(mail.Message).Body, token.TokenHash)
+	resetRepo.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestPasswordResetService_RequestReset_UnknownEmail(t *testing.T) {
+	service, resetRepo, userRepo, mailer := setupPasswordResetServiceTest()
+
+	userRepo.On("GetByEmail", "unknown@test.com").Return(nil, gorm.ErrRecordNotFound)
+
+	err := service.RequestReset(ForgotPasswordRequest{Email: "unknown@test.com"})
+
+	assert.NoError(t, err)
+	resetRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mailer.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	service, resetRepo, _, _ := setupPasswordResetServiceTest()
+	token := &PasswordResetToken{UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute)}
+
+	resetRepo.On("GetByTokenHash", common.HashToken("raw-token")).Return(token, nil)
+	resetRepo.On("ResetPassword", token, mock.AnythingOfType("string"), auditEvent(audit.UserPasswordReset)).Return(true, nil)
+
+	err := service.ResetPassword(testActor, ResetPasswordRequest{Token: "raw-token", NewPassword: "NewPass456"})
+
+	assert.NoError(t, err)
+	resetRepo.AssertExpectations(t)
+}
+
+func TestPasswordResetService_ResetPassword_TokenUsedConcurrently(t *testing.T) {
+	service, resetRepo, _, _ := setupPasswordResetServiceTest()
+	token := &PasswordResetToken{UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute)}
+
+	resetRepo.On("GetByTokenHash", common.HashToken("raw-token")).Return(token, nil)
+	resetRepo.On("ResetPassword", token, mock.AnythingOfType("string"), auditEvent(audit.UserPasswordReset)).Return(false, nil)
+
+	err := service.ResetPassword(testActor, ResetPasswordRequest{Token: "raw-token", NewPassword: "NewPass456"})
+
+	assert.Equal(t, http.StatusBadRequest, err.(*common.ApiError).StatusCode)
+}
+
+func TestPasswordResetService_ResetPassword_ExpiredToken(t *testing.T) {
+	service, resetRepo, _, _ := setupPasswordResetServiceTest()
+	token := &PasswordResetToken{UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	resetRepo.On("GetByTokenHash", common.HashToken("raw-token")).Return(token, nil)
+
+	err := service.ResetPassword(testActor, ResetPasswordRequest{Token: "raw-token", NewPassword: "NewPass456"})
+
+	assert.Error(t, err)
+	resetRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordResetService_ResetPassword_UsedToken(t *testing.T) {
+	service, resetRepo, _, _ := setupPasswordResetServiceTest()
+	usedAt := time.Now()
+	token := &PasswordResetToken{UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt}
+
+	resetRepo.On("GetByTokenHash", common.HashToken("raw-token")).Return(token, nil)
+
+	err := service.ResetPassword(testActor, ResetPasswordRequest{Token: "raw-token", NewPassword: "NewPass456"})
+
+	assert.Error(t, err)
+	resetRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	MemberAdded       = "member.added"
+	MemberRemoved     = "member.removed"
+	ItemCreated       = "item.created"
+	ItemUpdated       = "item.updated"
+	ItemStatusChanged = "item.status_changed"
+	ItemDeleted       = "item.deleted"
+	CommentCreated    = "comment.created"
+)
+
+var Events = []string{
+	MemberAdded,
+	MemberRemoved,
+	ItemCreated,
+	ItemUpdated,
+	ItemStatusChanged,
+	ItemDeleted,
+	CommentCreated,
+}
+
+// Message is a domain event that is written in the same transaction as the change it describes
+// and picked up later by background workers, so no event is lost or sent for a rolled back change.
+type Message struct {
+	common.BaseEntity
+	TeamID uuid.UUID `gorm:"type:uuid;not null;index"`
+	// BoardID is set for changes on a board, which are also streamed to its viewers.
+	BoardID *uuid.UUID `gorm:"type:uuid"`
+	// Seq is assigned by the database and orders the messages.
+	Seq int64 `gorm:"->"`
+	// BoardSeq numbers the messages of a board in commit order, e.g. for SSE event ids.
+	BoardSeq     int64  `gorm:"->"`
+	Event        string `gorm:"type:varchar(50);not null"`
+	Payload      string `gorm:"type:jsonb;not null"`
+	DispatchedAt *time.Time
+}
+
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+func NewMessage(teamID uuid.UUID, event string, data any) (*Message, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		TeamID:     teamID,
+		Event:      event,
+		Payload:    string(payload),
+	}, nil
+}
+
+func NewBoardMessage(teamID, boardID uuid.UUID, event string, data any) (*Message, error) {
+	m, err := NewMessage(teamID, event, data)
+	if err != nil {
+		return nil, err
+	}
+	m.BoardID = &boardID
+	return m, nil
+}
+
+// Record writes the messages with the given transaction.
+func Record(tx *gorm.DB, messages ...*Message) error {
+	for _, m := range messages {
+		if m == nil {
+			continue
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
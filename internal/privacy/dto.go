@@ -0,0 +1,135 @@
+package privacy
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/google/uuid"
+)
+
+type DataRequestResponse struct {
+	ID            uuid.UUID     `json:"id"`
+	UserID        uuid.UUID     `json:"userId"`
+	RequestedByID uuid.UUID     `json:"requestedById"`
+	Type          RequestType   `json:"type"`
+	Status        RequestStatus `json:"status"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	CompletedAt   *time.Time    `json:"completedAt"`
+}
+
+func ToDataRequestResponse(req *DataRequest) *DataRequestResponse {
+	return &DataRequestResponse{
+		ID:            req.ID,
+		UserID:        req.UserID,
+		RequestedByID: req.RequestedByID,
+		Type:          req.Type,
+		Status:        req.Status,
+		CreatedAt:     req.CreatedAt,
+		CompletedAt:   req.CompletedAt,
+	}
+}
+
+type UserData struct {
+	User          org.User
+	Memberships   []org.Membership
+	AuthoredItems []backlog.Item
+	AssignedItems []backlog.Item
+	Comments      []backlog.Comment
+}
+
+type ProfileExport struct {
+	ID            uuid.UUID    `json:"id"`
+	Email         string       `json:"email"`
+	Name          string       `json:"username"`
+	Role          org.UserRole `json:"role"`
+	CreatedAt     time.Time    `json:"createdAt"`
+	DeactivatedAt *time.Time   `json:"deactivatedAt,omitempty"`
+}
+
+type MembershipExport struct {
+	TeamID    uuid.UUID    `json:"teamId"`
+	TeamName  string       `json:"teamName"`
+	Role      org.TeamRole `json:"role"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type ItemExport struct {
+	ID          uuid.UUID          `json:"id"`
+	BoardID     uuid.UUID          `json:"boardId"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Status      backlog.ItemStatus `json:"status"`
+	DueDate     *time.Time         `json:"dueDate"`
+	CreatedAt   time.Time          `json:"createdAt"`
+}
+
+type CommentExport struct {
+	ID        uuid.UUID `json:"id"`
+	ItemID    uuid.UUID `json:"itemId"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UserExport struct {
+	ExportedAt    time.Time          `json:"exportedAt"`
+	Profile       ProfileExport      `json:"profile"`
+	Memberships   []MembershipExport `json:"memberships"`
+	AuthoredItems []ItemExport       `json:"authoredItems"`
+	AssignedItems []ItemExport       `json:"assignedItems"`
+	Comments      []CommentExport    `json:"comments"`
+}
+
+func ToUserExport(data *UserData, exportedAt time.Time) *UserExport {
+	export := &UserExport{
+		ExportedAt: exportedAt,
+		Profile: ProfileExport{
+			ID:            data.User.ID,
+			Email:         data.User.Email,
+			Name:          data.User.Name,
+			Role:          data.User.Role,
+			CreatedAt:     data.User.CreatedAt,
+			DeactivatedAt: data.User.DeactivatedAt,
+		},
+		Memberships:   make([]MembershipExport, 0, len(data.Memberships)),
+		AuthoredItems: toItemExports(data.AuthoredItems),
+		AssignedItems: toItemExports(data.AssignedItems),
+		Comments:      make([]CommentExport, 0, len(data.Comments)),
+	}
+
+	for _, m := range data.Memberships {
+		export.Memberships = append(export.Memberships, MembershipExport{
+			TeamID:    m.TeamID,
+			TeamName:  m.Team.Name,
+			Role:      m.Role,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+
+	for _, c := range data.Comments {
+		export.Comments = append(export.Comments, CommentExport{
+			ID:        c.ID,
+			ItemID:    c.ItemID,
+			Content:   c.Content,
+			CreatedAt: c.CreatedAt,
+		})
+	}
+
+	return export
+}
+
+func toItemExports(items []backlog.Item) []ItemExport {
+	res := make([]ItemExport, 0, len(items))
+	for _, i := range items {
+		res = append(res, ItemExport{
+			ID:          i.ID,
+			BoardID:     i.BoardID,
+			Title:       i.Title,
+			Description: i.Description,
+			Status:      i.Status,
+			DueDate:     i.DueDate,
+			CreatedAt:   i.CreatedAt,
+		})
+	}
+	return res
+}
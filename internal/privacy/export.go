@@ -0,0 +1,42 @@
+package privacy
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+)
+
+// WriteZip writes each section of the export as a separate JSON file.
+func WriteZip(w io.Writer, export *UserExport) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"memberships.json", export.Memberships},
+		{"authored_items.json", export.AuthoredItems},
+		{"assigned_items.json", export.AssignedItems},
+		{"comments.json", export.Comments},
+	}
+
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
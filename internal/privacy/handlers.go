@@ -0,0 +1,123 @@
+package privacy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type PrivacyHandler struct {
+	Service *PrivacyService
+}
+
+func NewPrivacyHandler(service *PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{Service: service}
+}
+
+// authorize lets users manage their own data and admins manage anyone's.
+func authorize(r *http.Request, userID uuid.UUID) (*common.Principal, error) {
+	p, _ := common.PrincipalFromContext(r.Context())
+	if p.UserID != userID && !(p.IsAdmin && p.HasScope(common.ScopeAdmin)) {
+		return nil, common.Forbidden("You can only access your own data!")
+	}
+	return p, nil
+}
+
+func (h *PrivacyHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, err := authorize(r, userID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	export, err := h.Service.Export(principal.UserID, userID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"gollab-export-%s.json\"", userID))
+		common.WriteJSON(w, http.StatusOK, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"gollab-export-%s.zip\"", userID))
+	w.WriteHeader(http.StatusOK)
+	_ = WriteZip(w, export)
+}
+
+func (h *PrivacyHandler) RequestErasure(w http.ResponseWriter, r *http.Request) {
+	userID, err := common.ParseUUID(chi.URLParam(r, "userId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, err := authorize(r, userID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.RequestErasure(principal.UserID, userID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusAccepted, resp)
+}
+
+func (h *PrivacyHandler) GetRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "requestId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.GetByID(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	if _, err := authorize(r, resp.UserID); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *PrivacyHandler) ListRequests(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if size <= 0 {
+		size = 10
+	}
+
+	resp, err := h.Service.List(page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
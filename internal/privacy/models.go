@@ -0,0 +1,32 @@
+package privacy
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+)
+
+type RequestType string
+
+const (
+	ExportRequest  RequestType = "export"
+	ErasureRequest RequestType = "erasure"
+)
+
+type RequestStatus string
+
+const (
+	Pending   RequestStatus = "pending"
+	Completed RequestStatus = "completed"
+)
+
+// DataRequest records every export and erasure. It has no foreign keys, so the trail outlives purged users.
+type DataRequest struct {
+	common.BaseEntity
+	UserID        uuid.UUID     `gorm:"type:uuid;not null;index"`
+	RequestedByID uuid.UUID     `gorm:"type:uuid;not null"`
+	Type          RequestType   `gorm:"type:varchar(20);not null;check:type IN ('export', 'erasure')"`
+	Status        RequestStatus `gorm:"type:varchar(20);not null;default:'pending';check:status IN ('pending', 'completed')"`
+	CompletedAt   *time.Time
+}
@@ -0,0 +1,196 @@
+package privacy
+
+import (
+	"errors"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/auth"
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DataRequestRepository interface {
+	Create(req *DataRequest) error
+	Update(req *DataRequest) error
+	GetByID(id uuid.UUID) (*DataRequest, error)
+	List(offset, limit int) ([]DataRequest, int, error)
+	LoadUserData(userID uuid.UUID) (*UserData, error)
+	EraseNext(anonymise func(user *org.User)) (*DataRequest, error)
+}
+
+type dataRequestRepository struct {
+	DB *gorm.DB
+}
+
+func NewDataRequestRepository(db *gorm.DB) DataRequestRepository {
+	return &dataRequestRepository{DB: db}
+}
+
+func (r *dataRequestRepository) Create(req *DataRequest) error {
+	return r.DB.Create(req).Error
+}
+
+func (r *dataRequestRepository) Update(req *DataRequest) error {
+	return r.DB.Save(req).Error
+}
+
+func (r *dataRequestRepository) GetByID(id uuid.UUID) (*DataRequest, error) {
+	var req DataRequest
+	if err := r.DB.First(&req, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *dataRequestRepository) List(offset, limit int) ([]DataRequest, int, error) {
+	var reqs []DataRequest
+	var total int64
+	r.DB.Model(&DataRequest{}).Count(&total)
+	if err := r.DB.Order("created_at DESC").Offset(offset).Limit(limit).Find(&reqs).Error; err != nil {
+		return nil, 0, err
+	}
+	return reqs, int(total), nil
+}
+
+func (r *dataRequestRepository) LoadUserData(userID uuid.UUID) (*UserData, error) {
+	var data UserData
+	if err := r.DB.First(&data.User, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.DB.Preload("Team").Where("user_id = ?", userID).Find(&data.Memberships).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.DB.Where("author_id = ?", userID).Order("created_at").Find(&data.AuthoredItems).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.DB.
+		Joins("JOIN items_assignees ON items_assignees.item_id = items.id").
+		Where("items_assignees.user_id = ?", userID).
+		Order("items.created_at").
+		Find(&data.AssignedItems).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at").Find(&data.Comments).Error; err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// EraseNext processes the oldest pending erasure. SKIP LOCKED lets both replicas run the job concurrently.
+func (r *dataRequestRepository) EraseNext(anonymise func(user *org.User)) (*DataRequest, error) {
+	var req DataRequest
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type = ? AND status = ?", ErasureRequest, Pending).
+			Order("created_at").
+			First(&req).Error; err != nil {
+			return err
+		}
+
+		var user org.User
+		err := tx.First(&user, "id = ?", req.UserID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// An already purged user has nothing left to anonymise.
+		if err == nil {
+			anonymise(&user)
+			if err := common.SaveVersioned(tx, &user, &user.BaseEntity); err != nil {
+				return err
+			}
+
+			if err := anonymiseMentions(tx, &user); err != nil {
+				return err
+			}
+
+			for _, model := range []any{
+				&org.Membership{},
+				&org.PasswordResetToken{},
+				&auth.PersonalAccessToken{},
+				&auth.Session{},
+				&auth.LoginChallenge{},
+				&auth.RecoveryCode{},
+				&auth.TwoFactorSecret{},
+			} {
+				if err := tx.Delete(model, "user_id = ?", user.ID).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Delete(&auth.LoginThrottle{}, "subject = ?", auth.UserThrottleSubject(user.ID)).Error; err != nil {
+				return err
+			}
+
+			event, err := audit.NewEvent(audit.Actor{UserID: &req.RequestedByID}, audit.UserErased, audit.TargetUser, user.ID, nil, nil)
+			if err != nil {
+				return err
+			}
+			if err := audit.Record(tx, event); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		req.Status = Completed
+		req.CompletedAt = &now
+		return tx.Save(&req).Error
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// anonymiseMentions puts the user's anonymised name in place of the name as it was written,
+// both in the mentions' handles and in the item descriptions and comments they were made in.
+func anonymiseMentions(tx *gorm.DB, user *org.User) error {
+	var mentions []backlog.Mention
+	if err := tx.Where("user_id = ?", user.ID).Find(&mentions).Error; err != nil {
+		return err
+	}
+
+	for _, m := range mentions {
+		var model any
+		var column string
+		var id uuid.UUID
+		switch {
+		case m.ItemID != nil:
+			model, column, id = &backlog.Item{}, "description", *m.ItemID
+		case m.CommentID != nil:
+			model, column, id = &backlog.Comment{}, "content", *m.CommentID
+		default:
+			continue
+		}
+
+		var texts []string
+		if err := tx.Model(model).Where("id = ?", id).Pluck(column, &texts).Error; err != nil {
+			return err
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		if err := tx.Model(model).Where("id = ?", id).Updates(map[string]any{
+			column:    backlog.ReplaceMention(texts[0], m.Handle, user.Name),
+			"version": gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&backlog.Mention{}).Where("user_id = ?", user.ID).Update("handle", user.Name).Error
+}
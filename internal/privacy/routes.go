@@ -0,0 +1,16 @@
+package privacy
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func PrivacyRoutes(r chi.Router, handler *PrivacyHandler) {
+	r.Route("/privacy", func(r chi.Router) {
+		r.Use(common.RequireAuth)
+		r.Get("/users/{userId}/export", handler.Export)
+		r.Post("/users/{userId}/erasure", handler.RequestErasure)
+		r.Get("/requests/{requestId}", handler.GetRequest)
+		r.With(common.RequireAdmin).Get("/requests", handler.ListRequests)
+	})
+}
@@ -0,0 +1,131 @@
+package privacy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PrivacyService struct {
+	Repo        DataRequestRepository
+	UserService *org.UserService
+}
+
+func NewPrivacyService(repo DataRequestRepository, userService *org.UserService) *PrivacyService {
+	return &PrivacyService{
+		Repo:        repo,
+		UserService: userService,
+	}
+}
+
+func (s *PrivacyService) Export(requestedBy, userID uuid.UUID) (*UserExport, error) {
+	data, err := s.Repo.LoadUserData(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("User with id %s was not found!", userID))
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	req := &DataRequest{
+		UserID:        userID,
+		RequestedByID: requestedBy,
+		Type:          ExportRequest,
+		Status:        Completed,
+		CompletedAt:   &now,
+	}
+	if err := s.Repo.Create(req); err != nil {
+		return nil, err
+	}
+
+	return ToUserExport(data, now), nil
+}
+
+func (s *PrivacyService) RequestErasure(requestedBy, userID uuid.UUID) (*DataRequestResponse, error) {
+	if _, err := s.UserService.GetByID(userID); err != nil {
+		return nil, err
+	}
+
+	req := &DataRequest{
+		UserID:        userID,
+		RequestedByID: requestedBy,
+		Type:          ErasureRequest,
+		Status:        Pending,
+	}
+	if err := s.Repo.Create(req); err != nil {
+		return nil, err
+	}
+
+	return ToDataRequestResponse(req), nil
+}
+
+// ProcessErasures anonymises users for all pending erasure requests.
+func (s *PrivacyService) ProcessErasures() error {
+	for {
+		req, err := s.Repo.EraseNext(anonymise)
+		if err != nil {
+			return err
+		}
+		if req == nil {
+			return nil
+		}
+	}
+}
+
+// anonymise strips personal data but keeps the row, so authored items and comments stay intact.
+func anonymise(user *org.User) {
+	id := strings.ReplaceAll(user.ID.String(), "-", "")
+	user.Name = "deleted-" + id
+	user.Email = id + "@erased.invalid"
+	user.Role = org.Standard
+
+	// Not a valid bcrypt hash, so no password can ever match it.
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	user.PasswordHash = "erased:" + hex.EncodeToString(b)
+
+	if user.DeactivatedAt == nil {
+		now := time.Now()
+		user.DeactivatedAt = &now
+	}
+}
+
+func (s *PrivacyService) GetByID(id uuid.UUID) (*DataRequestResponse, error) {
+	req, err := s.Repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Data request with id %s was not found!", id))
+		}
+		return nil, err
+	}
+	return ToDataRequestResponse(req), nil
+}
+
+func (s *PrivacyService) List(page, size int) (*common.PaginatedResponse[DataRequestResponse], error) {
+	offset := (page - 1) * size
+	reqs, total, err := s.Repo.List(offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DataRequestResponse, 0, len(reqs))
+	for _, r := range reqs {
+		res = append(res, *ToDataRequestResponse(&r))
+	}
+
+	return &common.PaginatedResponse[DataRequestResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
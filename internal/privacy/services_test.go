@@ -0,0 +1,212 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/audit"
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type dataRequestRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *dataRequestRepositoryMock) Create(req *DataRequest) error {
+	return m.Called(req).Error(0)
+}
+
+func (m *dataRequestRepositoryMock) Update(req *DataRequest) error {
+	return m.Called(req).Error(0)
+}
+
+func (m *dataRequestRepositoryMock) GetByID(id uuid.UUID) (*DataRequest, error) {
+	args := m.Called(id)
+	if r := args.Get(0); r != nil {
+		return r.(*DataRequest), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *dataRequestRepositoryMock) List(offset, limit int) ([]DataRequest, int, error) {
+	args := m.Called(offset, limit)
+	return args.Get(0).([]DataRequest), args.Int(1), args.Error(2)
+}
+
+func (m *dataRequestRepositoryMock) LoadUserData(userID uuid.UUID) (*UserData, error) {
+	args := m.Called(userID)
+	if d := args.Get(0); d != nil {
+		return d.(*UserData), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *dataRequestRepositoryMock) EraseNext(anonymise func(user *org.User)) (*DataRequest, error) {
+	args := m.Called(anonymise)
+	if r := args.Get(0); r != nil {
+		return r.(*DataRequest), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type userRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *userRepositoryMock) GetByID(id uuid.UUID) (*org.User, error) {
+	args := m.Called(id)
+	if u := args.Get(0); u != nil {
+		return u.(*org.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *userRepositoryMock) GetByEmail(email string) (*org.User, error) {
+	args := m.Called(email)
+	if u := args.Get(0); u != nil {
+		return u.(*org.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *userRepositoryMock) Create(user *org.User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
+}
+
+func (m *userRepositoryMock) Update(user *org.User, event *audit.Event) error {
+	return m.Called(user, event).Error(0)
+}
+
+func (m *userRepositoryMock) ChangePassword(user *org.User, keepSessionID *uuid.UUID, event *audit.Event) error {
+	return m.Called(user, keepSessionID, event).Error(0)
+}
+
+func (m *userRepositoryMock) DeleteByID(id uuid.UUID, event *audit.Event) error {
+	return m.Called(id, event).Error(0)
+}
+
+func (m *userRepositoryMock) List(offset, limit int, includeDeactivated bool) ([]org.User, int, error) {
+	args := m.Called(offset, limit, includeDeactivated)
+	return args.Get(0).([]org.User), args.Int(1), args.Error(2)
+}
+
+func setupPrivacyServiceTest() (*PrivacyService, *dataRequestRepositoryMock, *userRepositoryMock) {
+	userRepo := &userRepositoryMock{}
+	userService := org.NewUserService(userRepo, validator.New(), org.DefaultPasswordPolicy())
+	repo := &dataRequestRepositoryMock{}
+	return NewPrivacyService(repo, userService), repo, userRepo
+}
+
+func testUserData() *UserData {
+	userID := uuid.New()
+	itemID := uuid.New()
+	return &UserData{
+		User: org.User{BaseEntity: common.BaseEntity{ID: userID}, Email: "alice@test.com", Name: "alice", Role: org.Standard},
+		Memberships: []org.Membership{
+			{TeamID: uuid.New(), Team: org.Team{Name: "Team A"}, Role: org.Developer},
+		},
+		AuthoredItems: []backlog.Item{{BaseEntity: common.BaseEntity{ID: itemID}, Title: "Item", Status: backlog.ToDo}},
+		Comments:      []backlog.Comment{{ItemID: itemID, Content: "Looks good"}},
+	}
+}
+
+func TestPrivacyService_Export(t *testing.T) {
+	service, repo, _ := setupPrivacyServiceTest()
+	data := testUserData()
+	repo.On("LoadUserData", data.User.ID).Return(data, nil)
+	repo.On("Create", mock.MatchedBy(func(r *DataRequest) bool {
+		return r.Type == ExportRequest && r.Status == Completed && r.UserID == data.User.ID
+	})).Return(nil)
+
+	export, err := service.Export(data.User.ID, data.User.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@test.com", export.Profile.Email)
+	assert.Equal(t, "Team A", export.Memberships[0].TeamName)
+	assert.Len(t, export.AuthoredItems, 1)
+	assert.Empty(t, export.AssignedItems)
+	assert.Equal(t, "Looks good", export.Comments[0].Content)
+	repo.AssertExpectations(t)
+}
+
+func TestPrivacyService_Export_UserNotFound(t *testing.T) {
+	service, repo, _ := setupPrivacyServiceTest()
+	id := uuid.New()
+	repo.On("LoadUserData", id).Return(nil, gorm.ErrRecordNotFound)
+
+	export, err := service.Export(id, id)
+
+	assert.Nil(t, export)
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPrivacyService_RequestErasure(t *testing.T) {
+	service, repo, userRepo := setupPrivacyServiceTest()
+	userID, adminID := uuid.New(), uuid.New()
+	userRepo.On("GetByID", userID).Return(&org.User{BaseEntity: common.BaseEntity{ID: userID}}, nil)
+	repo.On("Create", mock.AnythingOfType("*privacy.DataRequest")).Return(nil)
+
+	resp, err := service.RequestErasure(adminID, userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ErasureRequest, resp.Type)
+	assert.Equal(t, Pending, resp.Status)
+	assert.Equal(t, adminID, resp.RequestedByID)
+}
+
+func TestPrivacyService_ProcessErasures(t *testing.T) {
+	service, repo, _ := setupPrivacyServiceTest()
+	repo.On("EraseNext", mock.Anything).Return(&DataRequest{}, nil).Twice()
+	repo.On("EraseNext", mock.Anything).Return(nil, nil).Once()
+
+	err := service.ProcessErasures()
+
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "EraseNext", 3)
+}
+
+func TestAnonymise(t *testing.T) {
+	user := &org.User{
+		BaseEntity:   common.BaseEntity{ID: uuid.New()},
+		Email:        "alice@test.com",
+		Name:         "alice",
+		PasswordHash: "hash",
+		Role:         org.Admin,
+	}
+
+	anonymise(user)
+
+	assert.NotContains(t, user.Email, "alice")
+	assert.NotContains(t, user.Name, "alice")
+	assert.LessOrEqual(t, len(user.Email), 50)
+	assert.LessOrEqual(t, len(user.Name), 50)
+	assert.NotEqual(t, "hash", user.PasswordHash)
+	assert.Equal(t, org.Standard, user.Role)
+	assert.NotNil(t, user.DeactivatedAt)
+}
+
+func TestWriteZip(t *testing.T) {
+	export := ToUserExport(testUserData(), time.Now())
+	var buf bytes.Buffer
+
+	err := WriteZip(&buf, export)
+
+	assert.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	names := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"profile.json", "memberships.json", "authored_items.json", "assigned_items.json", "comments.json"}, names)
+}
@@ -0,0 +1,39 @@
+package realtime
+
+import "github.com/google/uuid"
+
+const (
+	PresenceSnapshot = "snapshot"
+	PresenceJoined   = "joined"
+	PresenceLeft     = "left"
+	PresenceEditing  = "editing"
+)
+
+// ClientMessage is sent by viewers. An editing message without item id releases the soft lock.
+type ClientMessage struct {
+	Type   string     `json:"type"`
+	ItemID *uuid.UUID `json:"itemId"`
+}
+
+type Viewer struct {
+	ConnectionID uuid.UUID  `json:"connectionId"`
+	UserID       uuid.UUID  `json:"userId"`
+	Name         string     `json:"name"`
+	ItemID       *uuid.UUID `json:"itemId"`
+}
+
+func ToViewer(p *Presence) Viewer {
+	return Viewer{
+		ConnectionID: p.ID,
+		UserID:       p.UserID,
+		Name:         p.Name,
+		ItemID:       p.ItemID,
+	}
+}
+
+type PresenceEvent struct {
+	Type    string    `json:"type"`
+	BoardID uuid.UUID `json:"boardId"`
+	Viewer  *Viewer   `json:"viewer,omitempty"`
+	Viewers []Viewer  `json:"viewers,omitempty"`
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// reconnectDelay is how long the listener waits before reconnecting after losing the connection.
+const reconnectDelay = 5 * time.Second
+
+// Listener receives Postgres notifications and hands them to the handler of their channel.
+// NOTIFY reaches every replica, which is how events cross instances. It holds a dedicated
+// connection, since a pooled one can't block waiting for notifications.
+type Listener struct {
+	DSN      string
+	handlers map[string]func(payload string)
+}
+
+func NewListener(dsn string) *Listener {
+	return &Listener{
+		DSN:      dsn,
+		handlers: make(map[string]func(payload string)),
+	}
+}
+
+// Handle registers fn for a channel. It must be called before Run.
+func (l *Listener) Handle(channel string, fn func(payload string)) {
+	l.handlers[channel] = fn
+}
+
+// Run listens until ctx is cancelled, reconnecting whenever the connection drops.
+func (l *Listener) Run(ctx context.Context) {
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime listener disconnected: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	for channel := range l.handlers {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if fn, ok := l.handlers[n.Channel]; ok {
+			fn(n.Payload)
+		}
+	}
+}
+
+// Notify sends a notification to the listeners of every replica.
+func Notify(db *gorm.DB, channel, payload string) error {
+	return db.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
@@ -0,0 +1,23 @@
+package realtime
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Presence is one open WebSocket connection on a board. Rows live in the database, so every
+// replica sees the viewers connected to the others.
+type Presence struct {
+	ID      uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	BoardID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	UserID  uuid.UUID  `gorm:"type:uuid;not null"`
+	Name    string     `gorm:"type:varchar(50);not null"`
+	ItemID  *uuid.UUID `gorm:"type:uuid"`
+	// SeenAt is refreshed by the replica holding the connection, stale rows belong to a crashed one.
+	SeenAt time.Time `gorm:"not null"`
+}
+
+func (Presence) TableName() string {
+	return "board_presences"
+}
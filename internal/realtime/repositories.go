@@ -0,0 +1,128 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EventRepository interface {
+	GetBySeq(seq int64) (*outbox.Message, error)
+	ListSince(boardID uuid.UUID, afterSeq int64, limit int) ([]outbox.Message, error)
+	SeqRange(boardID uuid.UUID) (oldest, latest int64, err error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type eventRepository struct {
+	DB *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) EventRepository {
+	return &eventRepository{DB: db}
+}
+
+func (r *eventRepository) GetBySeq(seq int64) (*outbox.Message, error) {
+	var m outbox.Message
+	if err := r.DB.First(&m, "seq = ?", seq).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *eventRepository) ListSince(boardID uuid.UUID, afterSeq int64, limit int) ([]outbox.Message, error) {
+	var messages []outbox.Message
+	err := r.DB.Where("board_id = ? AND board_seq > ?", boardID, afterSeq).Order("board_seq").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// SeqRange returns the oldest board seq still kept and the latest one handed out. With no
+// events kept, the oldest is the one after the latest.
+func (r *eventRepository) SeqRange(boardID uuid.UUID) (oldest, latest int64, err error) {
+	var bounds struct {
+		Oldest *int64
+		Latest *int64
+	}
+	err = r.DB.Raw(`SELECT
+			(SELECT MIN(board_seq) FROM outbox_messages WHERE board_id = ?) AS oldest,
+			(SELECT seq FROM board_event_seqs WHERE board_id = ?) AS latest`, boardID, boardID).
+		Scan(&bounds).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	if bounds.Latest != nil {
+		latest = *bounds.Latest
+	}
+	oldest = latest + 1
+	if bounds.Oldest != nil {
+		oldest = *bounds.Oldest
+	}
+	return oldest, latest, nil
+}
+
+// DeleteExpired trims the event buffer. Messages the webhook worker hasn't dispatched yet are kept.
+func (r *eventRepository) DeleteExpired(before time.Time) (int64, error) {
+	res := r.DB.Delete(&outbox.Message{}, "dispatched_at IS NOT NULL AND created_at < ?", before)
+	return res.RowsAffected, res.Error
+}
+
+type PresenceRepository interface {
+	Create(presence *Presence) error
+	SetItem(id uuid.UUID, itemID *uuid.UUID) error
+	Delete(id uuid.UUID) error
+	ListByBoardID(boardID uuid.UUID) ([]Presence, error)
+	Touch(ids []uuid.UUID, now time.Time) error
+	DeleteStale(before time.Time) ([]Presence, error)
+	Publish(event *PresenceEvent) error
+}
+
+type presenceRepository struct {
+	DB *gorm.DB
+}
+
+func NewPresenceRepository(db *gorm.DB) PresenceRepository {
+	return &presenceRepository{DB: db}
+}
+
+func (r *presenceRepository) Create(presence *Presence) error {
+	return r.DB.Create(presence).Error
+}
+
+func (r *presenceRepository) SetItem(id uuid.UUID, itemID *uuid.UUID) error {
+	return r.DB.Model(&Presence{}).Where("id = ?", id).Update("item_id", itemID).Error
+}
+
+func (r *presenceRepository) Delete(id uuid.UUID) error {
+	return r.DB.Delete(&Presence{}, "id = ?", id).Error
+}
+
+func (r *presenceRepository) ListByBoardID(boardID uuid.UUID) ([]Presence, error) {
+	var presences []Presence
+	err := r.DB.Where("board_id = ?", boardID).Order("seen_at").Find(&presences).Error
+	return presences, err
+}
+
+func (r *presenceRepository) Touch(ids []uuid.UUID, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Model(&Presence{}).Where("id IN ?", ids).Update("seen_at", now).Error
+}
+
+func (r *presenceRepository) DeleteStale(before time.Time) ([]Presence, error) {
+	var stale []Presence
+	err := r.DB.Clauses(clause.Returning{}).Where("seen_at < ?", before).Delete(&stale).Error
+	return stale, err
+}
+
+// Publish broadcasts the event to the presence listeners of every replica.
+func (r *presenceRepository) Publish(event *PresenceEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return Notify(r.DB, PresenceChannel, string(payload))
+}
@@ -0,0 +1,491 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+)
+
+// BoardEventsChannel is notified by a trigger on outbox_messages with "<board id>:<seq>".
+const BoardEventsChannel = "board_events"
+
+const (
+	// subscriberBuffer is how many events a slow client may lag behind before it's disconnected.
+	// EventSource reconnects with Last-Event-ID and catches up from the buffer.
+	subscriberBuffer = 64
+	replayLimit      = 500
+	heartbeat        = 25 * time.Second
+)
+
+// ResetEvent tells clients that events since their Last-Event-ID are gone and the board has to be reloaded.
+const ResetEvent = "reset"
+
+type EventService struct {
+	Repo      EventRepository
+	Retention time.Duration
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan *outbox.Message]struct{}
+}
+
+func NewEventService(repo EventRepository, retention time.Duration) *EventService {
+	return &EventService{
+		Repo:        repo,
+		Retention:   retention,
+		subscribers: make(map[uuid.UUID]map[chan *outbox.Message]struct{}),
+	}
+}
+
+// Subscribe registers a local subscriber for the board. The returned func must be called once it's done.
+func (s *EventService) Subscribe(boardID uuid.UUID) (<-chan *outbox.Message, func()) {
+	ch := make(chan *outbox.Message, subscriberBuffer)
+
+	s.mu.Lock()
+	if s.subscribers[boardID] == nil {
+		s.subscribers[boardID] = make(map[chan *outbox.Message]struct{})
+	}
+	s.subscribers[boardID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[boardID][ch]; ok {
+			s.remove(boardID, ch)
+		}
+	}
+}
+
+// remove drops a subscriber. The caller holds the lock.
+func (s *EventService) remove(boardID uuid.UUID, ch chan *outbox.Message) {
+	delete(s.subscribers[boardID], ch)
+	close(ch)
+	if len(s.subscribers[boardID]) == 0 {
+		delete(s.subscribers, boardID)
+	}
+}
+
+func (s *EventService) hasSubscribers(boardID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers[boardID]) > 0
+}
+
+// Publish hands the message to the local subscribers of its board.
+func (s *EventService) Publish(m *outbox.Message) {
+	if m.BoardID == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[*m.BoardID] {
+		select {
+		case ch <- m:
+		default:
+			s.remove(*m.BoardID, ch)
+		}
+	}
+}
+
+// HandleNotification loads the notified message and publishes it, unless nobody on this replica watches the board.
+func (s *EventService) HandleNotification(payload string) {
+	board, seq, ok := strings.Cut(payload, ":")
+	boardID, err := uuid.Parse(board)
+	if !ok || err != nil {
+		log.Printf("invalid board event notification %q", payload)
+		return
+	}
+	if !s.hasSubscribers(boardID) {
+		return
+	}
+
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil {
+		log.Printf("invalid board event notification %q", payload)
+		return
+	}
+
+	m, err := s.Repo.GetBySeq(n)
+	if err != nil {
+		log.Printf("loading board event %d failed: %v", n, err)
+		return
+	}
+	s.Publish(m)
+}
+
+// Stream writes the board's events as Server-Sent Events until the client goes away. With a
+// Last-Event-ID the events the client missed are replayed first. Errors are only returned
+// before the stream has started; afterwards a failed write just ends it.
+func (s *EventService) Stream(w http.ResponseWriter, r *http.Request, boardID uuid.UUID) error {
+	var lastSeq int64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		seq, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return common.BadRequest("Last-Event-ID must be an event id of this stream!")
+		}
+		lastSeq = seq
+	}
+
+	// Subscribing before the replay means nothing committed in between is lost. Duplicates are
+	// skipped by seq, which is safe since a board's events commit, and so arrive, in seq order.
+	events, cancel := s.Subscribe(boardID)
+	defer cancel()
+
+	var missed []outbox.Message
+	reset := false
+	if lastSeq > 0 {
+		oldest, latest, err := s.Repo.SeqRange(boardID)
+		if err != nil {
+			return err
+		}
+		// An id past the latest one isn't from this board's stream.
+		reset = oldest > lastSeq+1 || lastSeq > latest
+
+		if !reset {
+			if missed, err = s.Repo.ListSince(boardID, lastSeq, replayLimit); err != nil {
+				return err
+			}
+			reset = len(missed) == replayLimit
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if reset {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", ResetEvent)
+		missed = nil
+	}
+	for i := range missed {
+		if err := writeEvent(w, &missed[i]); err != nil {
+			return nil
+		}
+		lastSeq = missed[i].BoardSeq
+	}
+	if err := rc.Flush(); err != nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case m, ok := <-events:
+			if !ok {
+				// Dropped for lagging behind, the client reconnects and resumes from its last event.
+				return nil
+			}
+			if m.BoardSeq <= lastSeq {
+				continue
+			}
+			if err := writeEvent(w, m); err != nil {
+				return nil
+			}
+			lastSeq = m.BoardSeq
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+func writeEvent(w io.Writer, m *outbox.Message) error {
+	var data bytes.Buffer
+	if err := json.Compact(&data, []byte(m.Payload)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.BoardSeq, m.Event, data.Bytes())
+	return err
+}
+
+func (s *EventService) DeleteExpired() error {
+	_, err := s.Repo.DeleteExpired(time.Now().Add(-s.Retention))
+	return err
+}
+
+// PresenceChannel carries presence events between replicas.
+const PresenceChannel = "board_presence"
+
+const (
+	// connectionBuffer is how many events a viewer may lag behind before it's disconnected.
+	connectionBuffer = 32
+	writeTimeout     = 10 * time.Second
+	maxClientMessage = 4096
+)
+
+type connection struct {
+	presence *Presence
+	send     chan []byte
+}
+
+// PresenceService tracks who is viewing a board and which item they are editing. Events go
+// through NOTIFY and reach the viewers once the listener of their replica hands them back.
+type PresenceService struct {
+	Repo           PresenceRepository
+	UserService    *org.UserService
+	Authenticate   func(token string) (*common.Principal, error)
+	Heartbeat      time.Duration
+	OriginPatterns []string
+
+	mu          sync.Mutex
+	connections map[uuid.UUID]map[*connection]struct{}
+}
+
+func NewPresenceService(
+	repo PresenceRepository,
+	userService *org.UserService,
+	authenticate func(token string) (*common.Principal, error),
+	heartbeat time.Duration,
+	originPatterns []string,
+) *PresenceService {
+	return &PresenceService{
+		Repo:           repo,
+		UserService:    userService,
+		Authenticate:   authenticate,
+		Heartbeat:      heartbeat,
+		OriginPatterns: originPatterns,
+		connections:    make(map[uuid.UUID]map[*connection]struct{}),
+	}
+}
+
+func (s *PresenceService) register(c *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connections[c.presence.BoardID] == nil {
+		s.connections[c.presence.BoardID] = make(map[*connection]struct{})
+	}
+	s.connections[c.presence.BoardID][c] = struct{}{}
+}
+
+func (s *PresenceService) unregister(c *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.connections[c.presence.BoardID][c]; ok {
+		s.remove(c)
+	}
+}
+
+// remove drops a connection. The caller holds the lock.
+func (s *PresenceService) remove(c *connection) {
+	boardID := c.presence.BoardID
+	delete(s.connections[boardID], c)
+	close(c.send)
+	if len(s.connections[boardID]) == 0 {
+		delete(s.connections, boardID)
+	}
+}
+
+// Principal resolves the caller. Browsers can't set headers on WebSocket requests, so the
+// session token may also be passed as the access_token query parameter.
+func (s *PresenceService) Principal(r *http.Request) (*common.Principal, error) {
+	if p, ok := common.PrincipalFromContext(r.Context()); ok {
+		return p, nil
+	}
+	if token := r.URL.Query().Get("access_token"); token != "" {
+		return s.Authenticate(token)
+	}
+	return nil, common.Unauthorized("Authentication required!")
+}
+
+// HandleNotification forwards a presence event to the viewers of its board on this replica.
+func (s *PresenceService) HandleNotification(payload string) {
+	var event PresenceEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("invalid presence notification %q", payload)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.connections[event.BoardID] {
+		select {
+		case c.send <- []byte(payload):
+		default:
+			s.remove(c)
+		}
+	}
+}
+
+// Serve upgrades the request to a WebSocket and keeps the user's presence on the board until it closes.
+func (s *PresenceService) Serve(w http.ResponseWriter, r *http.Request, boardID, userID uuid.UUID) error {
+	user, err := s.UserService.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: s.OriginPatterns})
+	if err != nil {
+		// Accept has already written the response.
+		return nil
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(maxClientMessage)
+
+	c := &connection{
+		presence: &Presence{
+			ID:      uuid.New(),
+			BoardID: boardID,
+			UserID:  user.ID,
+			Name:    user.Name,
+			SeenAt:  time.Now(),
+		},
+		send: make(chan []byte, connectionBuffer),
+	}
+	if err := s.Repo.Create(c.presence); err != nil {
+		conn.Close(websocket.StatusInternalError, "presence unavailable")
+		return nil
+	}
+
+	// Registering before the snapshot means no event in between is lost.
+	s.register(c)
+	defer s.leave(c)
+
+	viewers, err := s.Repo.ListByBoardID(boardID)
+	if err != nil {
+		conn.Close(websocket.StatusInternalError, "presence unavailable")
+		return nil
+	}
+	snapshot := &PresenceEvent{Type: PresenceSnapshot, BoardID: boardID, Viewers: make([]Viewer, 0, len(viewers))}
+	for i := range viewers {
+		snapshot.Viewers = append(snapshot.Viewers, ToViewer(&viewers[i]))
+	}
+	if err := writeJSON(r.Context(), conn, snapshot); err != nil {
+		return nil
+	}
+
+	s.publish(c, PresenceJoined)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go s.readLoop(ctx, cancel, conn, c)
+
+	ping := time.NewTicker(s.Heartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			pingCtx, done := context.WithTimeout(ctx, writeTimeout)
+			err := conn.Ping(pingCtx)
+			done()
+			if err != nil {
+				return nil
+			}
+		case msg, ok := <-c.send:
+			if !ok {
+				conn.Close(websocket.StatusPolicyViolation, "connection too slow")
+				return nil
+			}
+			writeCtx, done := context.WithTimeout(ctx, writeTimeout)
+			err := conn.Write(writeCtx, websocket.MessageText, msg)
+			done()
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *PresenceService) readLoop(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, c *connection) {
+	defer cancel()
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != PresenceEditing {
+			conn.Close(websocket.StatusUnsupportedData, "expected an editing message")
+			return
+		}
+
+		if err := s.Repo.SetItem(c.presence.ID, msg.ItemID); err != nil {
+			log.Printf("updating presence %s failed: %v", c.presence.ID, err)
+			continue
+		}
+		c.presence.ItemID = msg.ItemID
+		s.publish(c, PresenceEditing)
+	}
+}
+
+func (s *PresenceService) leave(c *connection) {
+	s.unregister(c)
+	if err := s.Repo.Delete(c.presence.ID); err != nil {
+		log.Printf("removing presence %s failed: %v", c.presence.ID, err)
+	}
+	s.publish(c, PresenceLeft)
+}
+
+func (s *PresenceService) publish(c *connection, eventType string) {
+	viewer := ToViewer(c.presence)
+	if err := s.Repo.Publish(&PresenceEvent{Type: eventType, BoardID: c.presence.BoardID, Viewer: &viewer}); err != nil {
+		log.Printf("publishing presence event failed: %v", err)
+	}
+}
+
+// RefreshPresence keeps the connections of this replica alive and removes the ones a crashed
+// replica left behind, telling their boards the viewers are gone.
+func (s *PresenceService) RefreshPresence() error {
+	s.mu.Lock()
+	var ids []uuid.UUID
+	for _, conns := range s.connections {
+		for c := range conns {
+			ids = append(ids, c.presence.ID)
+		}
+	}
+	s.mu.Unlock()
+
+	now := time.Now()
+	if err := s.Repo.Touch(ids, now); err != nil {
+		return err
+	}
+
+	stale, err := s.Repo.DeleteStale(now.Add(-3 * s.Heartbeat))
+	if err != nil {
+		return err
+	}
+	for i := range stale {
+		viewer := ToViewer(&stale[i])
+		if err := s.Repo.Publish(&PresenceEvent{Type: PresenceLeft, BoardID: stale[i].BoardID, Viewer: &viewer}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(ctx context.Context, conn *websocket.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, data)
+}
@@ -0,0 +1,371 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/coder/websocket"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type eventRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *eventRepositoryMock) GetBySeq(seq int64) (*outbox.Message, error) {
+	args := m.Called(seq)
+	if msg := args.Get(0); msg != nil {
+		return msg.(*outbox.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *eventRepositoryMock) ListSince(boardID uuid.UUID, afterSeq int64, limit int) ([]outbox.Message, error) {
+	args := m.Called(boardID, afterSeq, limit)
+	return args.Get(0).([]outbox.Message), args.Error(1)
+}
+
+func (m *eventRepositoryMock) SeqRange(boardID uuid.UUID) (int64, int64, error) {
+	args := m.Called(boardID)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *eventRepositoryMock) DeleteExpired(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func boardMessage(boardID uuid.UUID, seq int64, event string) *outbox.Message {
+	m, _ := outbox.NewBoardMessage(uuid.New(), boardID, event, map[string]any{"seq": seq})
+	m.BoardSeq = seq
+	return m
+}
+
+// openStream starts a stream on a test server and returns a reader over its lines.
+func openStream(t *testing.T, service *EventService, boardID uuid.UUID, lastEventID string) *bufio.Scanner {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = service.Stream(w, r, boardID)
+	}))
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewScanner(resp.Body)
+}
+
+// nextEvent reads lines up to the next blank line, skipping the retry hint.
+func nextEvent(s *bufio.Scanner) string {
+	var lines []string
+	for s.Scan() {
+		line := s.Text()
+		if line == "" {
+			if len(lines) > 0 && !strings.HasPrefix(lines[0], "retry:") {
+				return strings.Join(lines, "\n")
+			}
+			lines = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return ""
+}
+
+func TestEventService_Stream_ReplaysFromLastEventID(t *testing.T) {
+	repo := new(eventRepositoryMock)
+	service := NewEventService(repo, time.Minute)
+	boardID := uuid.New()
+	repo.On("SeqRange", boardID).Return(int64(1), int64(7), nil)
+	repo.On("ListSince", boardID, int64(4), replayLimit).Return([]outbox.Message{
+		*boardMessage(boardID, 5, outbox.ItemCreated),
+		*boardMessage(boardID, 7, outbox.CommentCreated),
+	}, nil)
+
+	stream := openStream(t, service, boardID, "4")
+
+	assert.Equal(t, "id: 5\nevent: item.created\ndata: {\"seq\":5}", nextEvent(stream))
+	assert.Equal(t, "id: 7\nevent: comment.created\ndata: {\"seq\":7}", nextEvent(stream))
+
+	// A live event that was already replayed is skipped.
+	service.Publish(boardMessage(boardID, 7, outbox.CommentCreated))
+	service.Publish(boardMessage(boardID, 8, outbox.ItemStatusChanged))
+	assert.Equal(t, "id: 8\nevent: item.status_changed\ndata: {\"seq\":8}", nextEvent(stream))
+}
+
+func TestEventService_Stream_ResetsWhenBufferExpired(t *testing.T) {
+	repo := new(eventRepositoryMock)
+	service := NewEventService(repo, time.Minute)
+	boardID := uuid.New()
+	repo.On("SeqRange", boardID).Return(int64(50), int64(60), nil)
+
+	stream := openStream(t, service, boardID, "10")
+
+	assert.Equal(t, "event: reset\ndata: {}", nextEvent(stream))
+	repo.AssertNotCalled(t, "ListSince", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEventService_Stream_ResetsOnUnknownLastEventID(t *testing.T) {
+	repo := new(eventRepositoryMock)
+	service := NewEventService(repo, time.Minute)
+	boardID := uuid.New()
+	repo.On("SeqRange", boardID).Return(int64(1), int64(5), nil)
+
+	stream := openStream(t, service, boardID, "900")
+
+	assert.Equal(t, "event: reset\ndata: {}", nextEvent(stream))
+	repo.AssertNotCalled(t, "ListSince", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEventService_HandleNotification_PublishesToBoardSubscribers(t *testing.T) {
+	repo := new(eventRepositoryMock)
+	service := NewEventService(repo, time.Minute)
+	boardID := uuid.New()
+	other := uuid.New()
+	message := boardMessage(boardID, 3, outbox.ItemUpdated)
+	repo.On("GetBySeq", int64(3)).Return(message, nil)
+
+	events, cancel := service.Subscribe(boardID)
+	defer cancel()
+
+	service.HandleNotification(other.String() + ":9")
+	service.HandleNotification(boardID.String() + ":3")
+
+	assert.Equal(t, message, <-events)
+	repo.AssertNotCalled(t, "GetBySeq", int64(9))
+}
+
+func TestEventService_Publish_DropsLaggingSubscriber(t *testing.T) {
+	service := NewEventService(new(eventRepositoryMock), time.Minute)
+	boardID := uuid.New()
+	events, cancel := service.Subscribe(boardID)
+	defer cancel()
+
+	for i := range subscriberBuffer + 1 {
+		service.Publish(boardMessage(boardID, int64(i+1), outbox.ItemUpdated))
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+	assert.False(t, service.hasSubscribers(boardID))
+}
+
+// presenceRepositoryMock keeps presences in memory and loops published events back like NOTIFY does.
+type presenceRepositoryMock struct {
+	mu        sync.Mutex
+	presences map[uuid.UUID]Presence
+	service   *PresenceService
+}
+
+func (m *presenceRepositoryMock) Create(presence *Presence) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.presences[presence.ID] = *presence
+	return nil
+}
+
+func (m *presenceRepositoryMock) SetItem(id uuid.UUID, itemID *uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.presences[id]
+	p.ItemID = itemID
+	m.presences[id] = p
+	return nil
+}
+
+func (m *presenceRepositoryMock) Delete(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.presences, id)
+	return nil
+}
+
+func (m *presenceRepositoryMock) ListByBoardID(boardID uuid.UUID) ([]Presence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []Presence
+	for _, p := range m.presences {
+		if p.BoardID == boardID {
+			res = append(res, p)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].SeenAt.Before(res[j].SeenAt) })
+	return res, nil
+}
+
+func (m *presenceRepositoryMock) Touch(ids []uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		p := m.presences[id]
+		p.SeenAt = now
+		m.presences[id] = p
+	}
+	return nil
+}
+
+func (m *presenceRepositoryMock) DeleteStale(before time.Time) ([]Presence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stale []Presence
+	for id, p := range m.presences {
+		if p.SeenAt.Before(before) {
+			stale = append(stale, p)
+			delete(m.presences, id)
+		}
+	}
+	return stale, nil
+}
+
+func (m *presenceRepositoryMock) Publish(event *PresenceEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	m.service.HandleNotification(string(payload))
+	return nil
+}
+
+type userRepositoryMock struct {
+	org.UserRepository
+	users map[uuid.UUID]*org.User
+}
+
+func (m *userRepositoryMock) GetByID(id uuid.UUID) (*org.User, error) {
+	if u, ok := m.users[id]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func setupPresenceTest(users ...*org.User) (*PresenceService, *presenceRepositoryMock) {
+	userRepo := &userRepositoryMock{users: make(map[uuid.UUID]*org.User)}
+	for _, u := range users {
+		userRepo.users[u.ID] = u
+	}
+	repo := &presenceRepositoryMock{presences: make(map[uuid.UUID]Presence)}
+	service := NewPresenceService(repo, org.NewUserService(userRepo, validator.New(), org.PasswordPolicy{}), nil, time.Minute, nil)
+	repo.service = service
+	return service, repo
+}
+
+// dialPresence connects the user to the board through a test server.
+func dialPresence(t *testing.T, service *PresenceService, boardID uuid.UUID, user *org.User) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = service.Serve(w, r, boardID, user.ID)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.Dial(context.Background(), server.URL, nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.CloseNow() })
+	return conn
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) PresenceEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, data, err := conn.Read(ctx)
+	assert.NoError(t, err)
+
+	var event PresenceEvent
+	assert.NoError(t, json.Unmarshal(data, &event))
+	return event
+}
+
+func TestPresenceService_BroadcastsJoinEditingAndLeave(t *testing.T) {
+	alice := &org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Alice"}
+	bob := &org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Bob"}
+	service, _ := setupPresenceTest(alice, bob)
+	boardID := uuid.New()
+
+	aliceConn := dialPresence(t, service, boardID, alice)
+	snapshot := readEvent(t, aliceConn)
+	assert.Equal(t, PresenceSnapshot, snapshot.Type)
+	assert.Len(t, snapshot.Viewers, 1)
+	assert.Equal(t, PresenceJoined, readEvent(t, aliceConn).Type)
+
+	bobConn := dialPresence(t, service, boardID, bob)
+	snapshot = readEvent(t, bobConn)
+	assert.Len(t, snapshot.Viewers, 2)
+	joined := readEvent(t, aliceConn)
+	assert.Equal(t, PresenceJoined, joined.Type)
+	assert.Equal(t, "Bob", joined.Viewer.Name)
+	assert.Equal(t, PresenceJoined, readEvent(t, bobConn).Type)
+
+	itemID := uuid.New()
+	msg, _ := json.Marshal(ClientMessage{Type: PresenceEditing, ItemID: &itemID})
+	assert.NoError(t, bobConn.Write(context.Background(), websocket.MessageText, msg))
+
+	editing := readEvent(t, aliceConn)
+	assert.Equal(t, PresenceEditing, editing.Type)
+	assert.Equal(t, bob.ID, editing.Viewer.UserID)
+	assert.Equal(t, &itemID, editing.Viewer.ItemID)
+
+	bobConn.Close(websocket.StatusNormalClosure, "")
+	left := readEvent(t, aliceConn)
+	assert.Equal(t, PresenceLeft, left.Type)
+	assert.Equal(t, bob.ID, left.Viewer.UserID)
+}
+
+func TestPresenceService_RefreshPresence_RemovesStaleViewers(t *testing.T) {
+	alice := &org.User{BaseEntity: common.BaseEntity{ID: uuid.New()}, Name: "Alice"}
+	service, repo := setupPresenceTest(alice)
+	boardID := uuid.New()
+
+	conn := dialPresence(t, service, boardID, alice)
+	readEvent(t, conn)
+	readEvent(t, conn)
+
+	// A viewer on a replica that crashed stops being refreshed.
+	stale := Presence{ID: uuid.New(), BoardID: boardID, UserID: uuid.New(), Name: "Carol", SeenAt: time.Now().Add(-time.Hour)}
+	assert.NoError(t, repo.Create(&stale))
+
+	assert.NoError(t, service.RefreshPresence())
+
+	left := readEvent(t, conn)
+	assert.Equal(t, PresenceLeft, left.Type)
+	assert.Equal(t, "Carol", left.Viewer.Name)
+	viewers, _ := repo.ListByBoardID(boardID)
+	assert.Len(t, viewers, 1)
+}
+
+func TestPresenceService_Principal_AcceptsQueryToken(t *testing.T) {
+	userID := uuid.New()
+	service, _ := setupPresenceTest()
+	service.Authenticate = func(token string) (*common.Principal, error) {
+		if token != "session-token" {
+			return nil, common.Unauthorized("Invalid token!")
+		}
+		return &common.Principal{UserID: userID}, nil
+	}
+
+	p, err := service.Principal(httptest.NewRequest(http.MethodGet, "/boards/x/presence?access_token=session-token", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, userID, p.UserID)
+
+	_, err = service.Principal(httptest.NewRequest(http.MethodGet, "/boards/x/presence", nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/google/uuid"
+)
+
+type BurndownPoint struct {
+	At time.Time `json:"at"`
+	// Remaining is nil for the part of the sprint that hasn't happened yet.
+	Remaining     *Amount `json:"remaining"`
+	IdealEstimate float64 `json:"idealEstimate"`
+	IdealItems    float64 `json:"idealItems"`
+}
+
+type BurndownResponse struct {
+	SprintID  uuid.UUID            `json:"sprintId"`
+	Unit      backlog.EstimateUnit `json:"unit"`
+	Committed Amount               `json:"committed"`
+	Points    []BurndownPoint      `json:"points"`
+}
+
+// CommitmentResponse compares what the sprint started with to what it finished. Added and
+// Removed are the scope changes in between.
+type CommitmentResponse struct {
+	SprintID   uuid.UUID            `json:"sprintId"`
+	Unit       backlog.EstimateUnit `json:"unit"`
+	Committed  Amount               `json:"committed"`
+	Added      Amount               `json:"added"`
+	Removed    Amount               `json:"removed"`
+	Completed  Amount               `json:"completed"`
+	Incomplete Amount               `json:"incomplete"`
+}
+
+type SprintVelocity struct {
+	SprintID  uuid.UUID  `json:"sprintId"`
+	Name      string     `json:"name"`
+	ClosedAt  *time.Time `json:"closedAt"`
+	Committed Amount     `json:"committed"`
+	Completed Amount     `json:"completed"`
+}
+
+type AverageVelocity struct {
+	Estimate float64 `json:"estimate"`
+	Items    float64 `json:"items"`
+}
+
+type VelocityResponse struct {
+	BoardID uuid.UUID            `json:"boardId"`
+	Unit    backlog.EstimateUnit `json:"unit"`
+	// Sprints are ordered oldest first.
+	Sprints []SprintVelocity `json:"sprints"`
+	Average AverageVelocity  `json:"average"`
+}
+
+type FlowFilter struct {
+	TagID      *uuid.UUID
+	AssigneeID *uuid.UUID
+	From       *time.Time
+	To         *time.Time
+}
+
+// Percentiles of a duration in days.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P85 float64 `json:"p85"`
+	P95 float64 `json:"p95"`
+}
+
+type ItemCycleTime struct {
+	ItemID      uuid.UUID  `json:"itemId"`
+	Title       string     `json:"title"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt"`
+	CompletedAt time.Time  `json:"completedAt"`
+	// CycleTimeDays is nil for items that went to done without being worked on.
+	CycleTimeDays *float64 `json:"cycleTimeDays"`
+	LeadTimeDays  float64  `json:"leadTimeDays"`
+}
+
+// CycleTimeResponse covers the items completed between From and To. The percentiles are nil
+// when no item qualifies.
+type CycleTimeResponse struct {
+	BoardID   uuid.UUID       `json:"boardId"`
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Items     []ItemCycleTime `json:"items"`
+	CycleTime *Percentiles    `json:"cycleTime"`
+	LeadTime  *Percentiles    `json:"leadTime"`
+}
+
+type FlowPoint struct {
+	At     time.Time                  `json:"at"`
+	Counts map[backlog.ItemStatus]int `json:"counts"`
+}
+
+type CumulativeFlowResponse struct {
+	BoardID uuid.UUID `json:"boardId"`
+	// Statuses lists the bands of the diagram from the bottom up.
+	Statuses []backlog.ItemStatus `json:"statuses"`
+	Points   []FlowPoint          `json:"points"`
+}
+
+type AgingItem struct {
+	ItemID  uuid.UUID          `json:"itemId"`
+	Title   string             `json:"title"`
+	Status  backlog.ItemStatus `json:"status"`
+	Since   time.Time          `json:"since"`
+	AgeDays float64            `json:"ageDays"`
+}
+
+type AgingResponse struct {
+	BoardID       uuid.UUID `json:"boardId"`
+	ThresholdDays float64   `json:"thresholdDays"`
+	// Items are ordered oldest first.
+	Items []AgingItem `json:"items"`
+}
+
+// ForecastRequest asks either how many items get done by Date, or when Items get done.
+// Seed makes the simulation repeatable; a random one is used when it's nil.
+type ForecastRequest struct {
+	Items      *int
+	Date       *time.Time
+	Confidence []int
+	Trials     int
+	Seed       *uint64
+}
+
+// ForecastResult holds, at the confidence level, the items done by the requested date or
+// the date the requested items are done by.
+type ForecastResult struct {
+	Confidence int        `json:"confidence"`
+	Items      *int       `json:"items,omitempty"`
+	Date       *time.Time `json:"date,omitempty"`
+}
+
+type ForecastResponse struct {
+	BoardID uuid.UUID `json:"boardId"`
+	// Throughput is the number of items done on each day of the history, oldest first.
+	Throughput  []int            `json:"throughput"`
+	HistoryFrom time.Time        `json:"historyFrom"`
+	HistoryTo   time.Time        `json:"historyTo"`
+	Trials      int              `json:"trials"`
+	Seed        uint64           `json:"seed"`
+	Items       *int             `json:"items,omitempty"`
+	Date        *time.Time       `json:"date,omitempty"`
+	Results     []ForecastResult `json:"results"`
+}
@@ -0,0 +1,70 @@
+package reports
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// maxForecastDays bounds how far a forecast looks ahead, both for the date asked about and for
+// a trial waiting on a number of items, so a board that rarely finishes anything can't keep a
+// simulation running. maxForecastItems bounds the number of items asked about.
+const (
+	maxForecastDays  = 10 * 365
+	maxForecastItems = 10000
+)
+
+// simulation replays a board's past daily throughput: every simulated day finishes as many
+// items as a randomly picked past day did.
+type simulation struct {
+	rng        *rand.Rand
+	throughput []int
+}
+
+func newSimulation(seed uint64, throughput []int) *simulation {
+	return &simulation{rng: rand.New(rand.NewPCG(seed, seed)), throughput: throughput}
+}
+
+func (s *simulation) day() int {
+	return s.throughput[s.rng.IntN(len(s.throughput))]
+}
+
+// itemsWithin returns, for every trial, how many items got done in the given number of days.
+// The result is sorted.
+func (s *simulation) itemsWithin(days, trials int) []int {
+	outcomes := make([]int, trials)
+	for i := range outcomes {
+		for range days {
+			outcomes[i] += s.day()
+		}
+	}
+	slices.Sort(outcomes)
+	return outcomes
+}
+
+// daysFor returns, for every trial, how many days it took to get the items done. The result
+// is sorted.
+func (s *simulation) daysFor(items, trials int) []int {
+	outcomes := make([]int, trials)
+	for i := range outcomes {
+		done := 0
+		for done < items && outcomes[i] < maxForecastDays {
+			done += s.day()
+			outcomes[i]++
+		}
+	}
+	slices.Sort(outcomes)
+	return outcomes
+}
+
+// atLeast returns the most items that confidence percent of the sorted outcomes reached.
+func atLeast(outcomes []int, confidence int) int {
+	i := len(outcomes) - int(math.Ceil(float64(confidence)/100*float64(len(outcomes))))
+	return outcomes[max(i, 0)]
+}
+
+// atMost returns the fewest days confidence percent of the sorted outcomes needed.
+func atMost(outcomes []int, confidence int) int {
+	i := int(math.Ceil(float64(confidence)/100*float64(len(outcomes)))) - 1
+	return outcomes[max(i, 0)]
+}
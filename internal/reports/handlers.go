@@ -0,0 +1,241 @@
+package reports
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	Service *ReportService
+}
+
+func NewReportHandler(service *ReportService) *ReportHandler {
+	return &ReportHandler{Service: service}
+}
+
+func parseFilter(r *http.Request) (FlowFilter, error) {
+	q := r.URL.Query()
+	var filter FlowFilter
+
+	for param, dst := range map[string]**uuid.UUID{"tagId": &filter.TagID, "assigneeId": &filter.AssigneeID} {
+		if v := q.Get(param); v != "" {
+			id, err := common.ParseUUID(v)
+			if err != nil {
+				return filter, err
+			}
+			*dst = &id
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, common.BadRequest("Parameter " + param + " must be an RFC 3339 timestamp!")
+			}
+			*dst = &t
+		}
+	}
+
+	return filter, nil
+}
+
+func (h *ReportHandler) Burndown(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.Burndown(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ReportHandler) Commitment(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "sprintId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.Commitment(id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ReportHandler) Velocity(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	count, _ := strconv.Atoi(r.URL.Query().Get("sprints"))
+	resp, err := h.Service.Velocity(boardID, count)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ReportHandler) CycleTime(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.CycleTime(boardID, filter)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ReportHandler) CumulativeFlow(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.CumulativeFlow(boardID, filter)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *ReportHandler) Aging(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	var threshold time.Duration
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		if threshold, err = time.ParseDuration(v); err != nil {
+			common.WriteError(w, common.BadRequest("Parameter threshold must be a duration like 72h!"))
+			return
+		}
+	}
+
+	resp, err := h.Service.Aging(boardID, filter, threshold)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func parseForecast(r *http.Request) (ForecastRequest, error) {
+	q := r.URL.Query()
+	var req ForecastRequest
+
+	if v := q.Get("items"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return req, common.BadRequest("Parameter items must be a number!")
+		}
+		req.Items = &n
+	}
+
+	if v := q.Get("date"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return req, common.BadRequest("Parameter date must be an RFC 3339 timestamp!")
+		}
+		req.Date = &t
+	}
+
+	if v := q.Get("confidence"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			c, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return req, common.BadRequest("Parameter confidence must be a comma separated list of percentages!")
+			}
+			req.Confidence = append(req.Confidence, c)
+		}
+	}
+
+	req.Trials, _ = strconv.Atoi(q.Get("trials"))
+
+	if v := q.Get("seed"); v != "" {
+		seed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return req, common.BadRequest("Parameter seed must be a non-negative number!")
+		}
+		req.Seed = &seed
+	}
+
+	return req, nil
+}
+
+func (h *ReportHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	boardID, err := common.ParseUUID(chi.URLParam(r, "boardId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	req, err := parseForecast(r)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	resp, err := h.Service.Forecast(boardID, filter, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,151 @@
+package reports
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/google/uuid"
+)
+
+// The item activity fields the reports replay.
+const (
+	fieldStatus   = "status"
+	fieldEstimate = "estimate"
+	fieldSprint   = "sprint"
+)
+
+// itemState is what an item looked like at some point in time.
+type itemState struct {
+	exists   bool
+	sprintID *uuid.UUID
+	status   backlog.ItemStatus
+	estimate *float64
+}
+
+func (s itemState) inSprint(sprintID uuid.UUID) bool {
+	return s.exists && s.sprintID != nil && *s.sprintID == sprintID
+}
+
+func (s itemState) done() bool {
+	return s.status == backlog.Done
+}
+
+func (s itemState) points() float64 {
+	if s.estimate == nil {
+		return 0
+	}
+	return *s.estimate
+}
+
+// itemHistory rebuilds past states of an item by undoing its activities, newest first,
+// starting from the item as it is now.
+type itemHistory struct {
+	item backlog.Item
+	// activities are ordered newest first.
+	activities []backlog.ItemActivity
+}
+
+// newHistories groups the activities, which are ordered oldest first, by item.
+func newHistories(items []backlog.Item, activities []backlog.ItemActivity) []itemHistory {
+	byItem := make(map[uuid.UUID][]backlog.ItemActivity, len(items))
+	for i := len(activities) - 1; i >= 0; i-- {
+		byItem[activities[i].ItemID] = append(byItem[activities[i].ItemID], activities[i])
+	}
+
+	histories := make([]itemHistory, 0, len(items))
+	for _, item := range items {
+		histories = append(histories, itemHistory{item: item, activities: byItem[item.ID]})
+	}
+	return histories
+}
+
+func (h itemHistory) at(t time.Time) (itemState, error) {
+	s := itemState{
+		exists:   !h.item.CreatedAt.After(t),
+		sprintID: h.item.SprintID,
+		status:   h.item.Status,
+		estimate: h.item.Estimate,
+	}
+	for _, a := range h.activities {
+		if !a.CreatedAt.After(t) {
+			break
+		}
+
+		var err error
+		switch a.Field {
+		case fieldStatus:
+			err = json.Unmarshal([]byte(a.OldValue), &s.status)
+		case fieldEstimate:
+			s.estimate = nil
+			err = json.Unmarshal([]byte(a.OldValue), &s.estimate)
+		case fieldSprint:
+			s.sprintID = nil
+			err = json.Unmarshal([]byte(a.OldValue), &s.sprintID)
+		}
+		if err != nil {
+			return itemState{}, err
+		}
+	}
+	return s, nil
+}
+
+// transition is an item entering a status.
+type transition struct {
+	status backlog.ItemStatus
+	at     time.Time
+}
+
+// transitions lists the statuses the item went through, oldest first, starting with the one
+// it was created in.
+func (h itemHistory) transitions() ([]transition, error) {
+	status := h.item.Status
+	var transitions []transition
+	for _, a := range h.activities {
+		if a.Field != fieldStatus {
+			continue
+		}
+		var entered backlog.ItemStatus
+		if err := json.Unmarshal([]byte(a.NewValue), &entered); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(a.OldValue), &status); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, transition{status: entered, at: a.CreatedAt})
+	}
+	transitions = append(transitions, transition{status: status, at: h.item.CreatedAt})
+	slices.Reverse(transitions)
+	return transitions, nil
+}
+
+// Amount is a quantity of work, both as the sum of the estimates and as a number of items.
+type Amount struct {
+	Estimate float64 `json:"estimate"`
+	Items    int     `json:"items"`
+}
+
+func (a *Amount) add(s itemState) {
+	a.Estimate += s.points()
+	a.Items++
+}
+
+// sprintScope sums up the items that were in the sprint at the time: all of them, and the
+// ones not done yet.
+func sprintScope(histories []itemHistory, sprintID uuid.UUID, t time.Time) (total, remaining Amount, err error) {
+	for _, h := range histories {
+		s, err := h.at(t)
+		if err != nil {
+			return Amount{}, Amount{}, err
+		}
+		if !s.inSprint(sprintID) {
+			continue
+		}
+		total.add(s)
+		if !s.done() {
+			remaining.add(s)
+		}
+	}
+	return total, remaining, nil
+}
@@ -0,0 +1,92 @@
+package reports
+
+import (
+	"fmt"
+
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReportRepository interface {
+	GetBoard(id uuid.UUID) (*backlog.Board, error)
+	GetSprint(id uuid.UUID) (*backlog.Sprint, error)
+	ListClosedSprints(boardID uuid.UUID, limit int) ([]backlog.Sprint, error)
+	ListSprintItems(sprintID uuid.UUID) ([]backlog.Item, error)
+	ListBoardItems(boardID uuid.UUID, filter FlowFilter, statuses ...backlog.ItemStatus) ([]backlog.Item, error)
+	ListActivities(itemIDs []uuid.UUID, fields ...string) ([]backlog.ItemActivity, error)
+}
+
+type reportRepository struct {
+	DB *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{DB: db}
+}
+
+func (r *reportRepository) GetBoard(id uuid.UUID) (*backlog.Board, error) {
+	var board backlog.Board
+	if err := r.DB.First(&board, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &board, nil
+}
+
+func (r *reportRepository) GetSprint(id uuid.UUID) (*backlog.Sprint, error) {
+	var sprint backlog.Sprint
+	if err := r.DB.Preload("Board").First(&sprint, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+// ListClosedSprints returns the board's most recently closed sprints, newest first.
+func (r *reportRepository) ListClosedSprints(boardID uuid.UUID, limit int) ([]backlog.Sprint, error) {
+	var sprints []backlog.Sprint
+	err := r.DB.Where("board_id = ? AND state = ?", boardID, backlog.SprintClosed).
+		Order("closed_at DESC").
+		Limit(limit).
+		Find(&sprints).Error
+	return sprints, err
+}
+
+// ListSprintItems returns the items in the sprint now and those that ever moved in or out of it.
+func (r *reportRepository) ListSprintItems(sprintID uuid.UUID) ([]backlog.Item, error) {
+	value := fmt.Sprintf("%q", sprintID)
+	moved := r.DB.Model(&backlog.ItemActivity{}).
+		Select("item_id").
+		Where("field = ? AND (old_value = ?::jsonb OR new_value = ?::jsonb)", "sprint", value, value)
+
+	var items []backlog.Item
+	err := r.DB.Where("sprint_id = ? OR id IN (?)", sprintID, moved).Find(&items).Error
+	return items, err
+}
+
+// ListBoardItems returns the board's items with the filter's tag and assignee, in any of the
+// statuses if some are given. The filter's date range is left to the caller.
+func (r *reportRepository) ListBoardItems(boardID uuid.UUID, filter FlowFilter, statuses ...backlog.ItemStatus) ([]backlog.Item, error) {
+	query := r.DB.Where("board_id = ?", boardID)
+	if filter.TagID != nil {
+		query = query.Where("id IN (?)", r.DB.Table("items_tags").Select("item_id").Where("tag_id = ?", *filter.TagID))
+	}
+	if filter.AssigneeID != nil {
+		query = query.Where("id IN (?)", r.DB.Table("items_assignees").Select("item_id").Where("user_id = ?", *filter.AssigneeID))
+	}
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	var items []backlog.Item
+	err := query.Order("created_at").Find(&items).Error
+	return items, err
+}
+
+func (r *reportRepository) ListActivities(itemIDs []uuid.UUID, fields ...string) ([]backlog.ItemActivity, error) {
+	var activities []backlog.ItemActivity
+	if len(itemIDs) == 0 {
+		return activities, nil
+	}
+	err := r.DB.Where("item_id IN ? AND field IN ?", itemIDs, fields).Order("created_at").Find(&activities).Error
+	return activities, err
+}
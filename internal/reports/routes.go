@@ -0,0 +1,19 @@
+package reports
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func ReportRoutes(r chi.Router, handler *ReportHandler) {
+	r.Route("/reports", func(r chi.Router) {
+		r.Use(common.RequireScope(common.ScopeItemsRead))
+		r.Get("/sprints/{sprintId}/burndown", handler.Burndown)
+		r.Get("/sprints/{sprintId}/commitment", handler.Commitment)
+		r.Get("/boards/{boardId}/velocity", handler.Velocity)
+		r.Get("/boards/{boardId}/cycle-time", handler.CycleTime)
+		r.Get("/boards/{boardId}/cumulative-flow", handler.CumulativeFlow)
+		r.Get("/boards/{boardId}/aging", handler.Aging)
+		r.Get("/boards/{boardId}/forecast", handler.Forecast)
+	})
+}
@@ -0,0 +1,611 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const day = 24 * time.Hour
+
+// The date range flow reports cover when none is given, and at most.
+const (
+	defaultFlowRange = 30 * day
+	maxFlowRange     = 366 * day
+)
+
+// defaultAgingThreshold is how long an item has to sit in a status to count as aging.
+const defaultAgingThreshold = 3 * day
+
+// startedStatuses are the statuses of work in progress. Cycle time starts when an item first
+// enters one of them.
+var startedStatuses = []backlog.ItemStatus{backlog.InProgress, backlog.OnHold, backlog.InReview}
+
+// flowStatuses are the bands of the cumulative flow diagram, from the bottom up.
+var flowStatuses = []backlog.ItemStatus{
+	backlog.Done,
+	backlog.InReview,
+	backlog.OnHold,
+	backlog.InProgress,
+	backlog.ToDo,
+	backlog.NotPlanned,
+}
+
+// The Monte Carlo forecast's trials when not given and at most, and its default confidence levels.
+const (
+	defaultForecastTrials = 10000
+	maxForecastTrials     = 100000
+)
+
+var defaultForecastConfidence = []int{50, 85, 95}
+
+// The number of closed sprints the velocity report looks back over, when not given, and at most.
+const (
+	defaultVelocitySprints = 5
+	maxVelocitySprints     = 50
+)
+
+type ReportService struct {
+	Repo ReportRepository
+}
+
+func NewReportService(repo ReportRepository) *ReportService {
+	return &ReportService{Repo: repo}
+}
+
+func (s *ReportService) findSprint(id uuid.UUID) (*backlog.Sprint, error) {
+	sprint, err := s.Repo.GetSprint(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Sprint with id %s was not found!", id))
+		}
+		return nil, err
+	}
+	return sprint, nil
+}
+
+func (s *ReportService) findBoard(id uuid.UUID) (*backlog.Board, error) {
+	board, err := s.Repo.GetBoard(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Board with id %s was not found!", id))
+		}
+		return nil, err
+	}
+	return board, nil
+}
+
+func (s *ReportService) histories(items []backlog.Item, fields ...string) ([]itemHistory, error) {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.ID)
+	}
+	activities, err := s.Repo.ListActivities(ids, fields...)
+	if err != nil {
+		return nil, err
+	}
+	return newHistories(items, activities), nil
+}
+
+func (s *ReportService) sprintHistories(sprintID uuid.UUID) ([]itemHistory, error) {
+	items, err := s.Repo.ListSprintItems(sprintID)
+	if err != nil {
+		return nil, err
+	}
+	return s.histories(items, fieldStatus, fieldEstimate, fieldSprint)
+}
+
+// sprintWindow returns when the sprint started and when it ended, which is now for the active one.
+func sprintWindow(sprint *backlog.Sprint, now time.Time) (time.Time, time.Time, error) {
+	if sprint.StartedAt == nil {
+		return time.Time{}, time.Time{}, common.Conflict(fmt.Sprintf("Sprint with id %s hasn't started yet!", sprint.ID))
+	}
+	if sprint.ClosedAt != nil {
+		return *sprint.StartedAt, *sprint.ClosedAt, nil
+	}
+	return *sprint.StartedAt, now, nil
+}
+
+// Burndown returns the work left in the sprint at its start and at the end of every day after,
+// together with the ideal line from the committed work down to zero at the sprint's end date.
+func (s *ReportService) Burndown(id uuid.UUID) (*BurndownResponse, error) {
+	sprint, err := s.findSprint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := sprintWindow(sprint, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	histories, err := s.sprintHistories(sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	committed, _, err := sprintScope(histories, sprint.ID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sprints closed after their end date are charted until they were closed.
+	last := sprint.EndDate
+	if sprint.ClosedAt != nil && sprint.ClosedAt.After(last) {
+		last = *sprint.ClosedAt
+	}
+	n := max(int(math.Ceil(days(last.Sub(start)))), 1)
+
+	points := make([]BurndownPoint, 0, n+1)
+	reachedEnd := false
+	for i := 0; i <= n; i++ {
+		at := start.Add(time.Duration(i) * day)
+		if at.After(last) {
+			at = last
+		}
+		progress := float64(i) / float64(n)
+		point := BurndownPoint{
+			At:            at,
+			IdealEstimate: committed.Estimate * (1 - progress),
+			IdealItems:    float64(committed.Items) * (1 - progress),
+		}
+
+		// The first point past the end shows where the sprint ended, the rest stay empty.
+		if at.After(end) {
+			if reachedEnd {
+				points = append(points, point)
+				continue
+			}
+			at = end
+			reachedEnd = true
+		}
+		_, remaining, err := sprintScope(histories, sprint.ID, at)
+		if err != nil {
+			return nil, err
+		}
+		point.Remaining = &remaining
+		points = append(points, point)
+	}
+
+	return &BurndownResponse{
+		SprintID:  sprint.ID,
+		Unit:      sprint.Board.EstimateUnit,
+		Committed: committed,
+		Points:    points,
+	}, nil
+}
+
+func (s *ReportService) Commitment(id uuid.UUID) (*CommitmentResponse, error) {
+	sprint, err := s.findSprint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	histories, err := s.sprintHistories(sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+	return commitment(sprint, histories, time.Now())
+}
+
+func commitment(sprint *backlog.Sprint, histories []itemHistory, now time.Time) (*CommitmentResponse, error) {
+	start, end, err := sprintWindow(sprint, now)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CommitmentResponse{SprintID: sprint.ID, Unit: sprint.Board.EstimateUnit}
+	for _, h := range histories {
+		before, err := h.at(start)
+		if err != nil {
+			return nil, err
+		}
+		after, err := h.at(end)
+		if err != nil {
+			return nil, err
+		}
+
+		committed, finished := before.inSprint(sprint.ID), after.inSprint(sprint.ID)
+		if committed {
+			res.Committed.add(before)
+		}
+		switch {
+		case committed && !finished:
+			res.Removed.add(before)
+		case !committed && finished:
+			res.Added.add(after)
+		}
+		if !finished {
+			continue
+		}
+		if after.done() {
+			res.Completed.add(after)
+		} else {
+			res.Incomplete.add(after)
+		}
+	}
+	return res, nil
+}
+
+// Velocity returns the committed and completed work of the board's last closed sprints.
+func (s *ReportService) Velocity(boardID uuid.UUID, count int) (*VelocityResponse, error) {
+	if count <= 0 {
+		count = defaultVelocitySprints
+	}
+	count = min(count, maxVelocitySprints)
+
+	board, err := s.findBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	sprints, err := s.Repo.ListClosedSprints(boardID, count)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(sprints)
+
+	res := &VelocityResponse{
+		BoardID: board.ID,
+		Unit:    board.EstimateUnit,
+		Sprints: make([]SprintVelocity, 0, len(sprints)),
+	}
+	for i := range sprints {
+		sprint := &sprints[i]
+		sprint.Board = *board
+
+		histories, err := s.sprintHistories(sprint.ID)
+		if err != nil {
+			return nil, err
+		}
+		c, err := commitment(sprint, histories, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		res.Sprints = append(res.Sprints, SprintVelocity{
+			SprintID:  sprint.ID,
+			Name:      sprint.Name,
+			ClosedAt:  sprint.ClosedAt,
+			Committed: c.Committed,
+			Completed: c.Completed,
+		})
+		res.Average.Estimate += c.Completed.Estimate
+		res.Average.Items += float64(c.Completed.Items)
+	}
+
+	if n := len(res.Sprints); n > 0 {
+		res.Average.Estimate /= float64(n)
+		res.Average.Items /= float64(n)
+	}
+	return res, nil
+}
+
+// flowRange fills in the filter's date range, which ends now and spans defaultFlowRange unless
+// given otherwise.
+func flowRange(filter FlowFilter, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if filter.To != nil && filter.To.Before(now) {
+		to = *filter.To
+	}
+	from := to.Add(-defaultFlowRange)
+	if filter.From != nil {
+		from = *filter.From
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, common.BadRequest("Parameter from must be before to!")
+	}
+	if to.Sub(from) > maxFlowRange {
+		return time.Time{}, time.Time{}, common.BadRequest(fmt.Sprintf("The date range can't be longer than %d days!", int(maxFlowRange/day)))
+	}
+	return from, to, nil
+}
+
+func days(d time.Duration) float64 {
+	return d.Hours() / 24
+}
+
+// percentiles uses the nearest-rank method. The values must be sorted.
+func percentiles(sorted []float64) *Percentiles {
+	if len(sorted) == 0 {
+		return nil
+	}
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return &Percentiles{P50: rank(50), P85: rank(85), P95: rank(95)}
+}
+
+// CycleTime returns the cycle and lead times of the board's items completed in the filter's
+// date range. Lead time runs from an item's creation and cycle time from when work on it
+// started, both until it was last moved to done.
+func (s *ReportService) CycleTime(boardID uuid.UUID, filter FlowFilter) (*CycleTimeResponse, error) {
+	board, err := s.findBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := flowRange(filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.Repo.ListBoardItems(board.ID, filter, backlog.Done)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.histories(items, fieldStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CycleTimeResponse{BoardID: board.ID, From: from, To: to, Items: []ItemCycleTime{}}
+	var cycleTimes, leadTimes []float64
+	for _, h := range histories {
+		transitions, err := h.transitions()
+		if err != nil {
+			return nil, err
+		}
+
+		completed := transitions[len(transitions)-1].at
+		if completed.Before(from) || !completed.Before(to) {
+			continue
+		}
+
+		item := ItemCycleTime{
+			ItemID:       h.item.ID,
+			Title:        h.item.Title,
+			CreatedAt:    h.item.CreatedAt,
+			CompletedAt:  completed,
+			LeadTimeDays: days(completed.Sub(h.item.CreatedAt)),
+		}
+		for _, t := range transitions {
+			if slices.Contains(startedStatuses, t.status) {
+				started := t.at
+				cycleTime := days(completed.Sub(started))
+				item.StartedAt, item.CycleTimeDays = &started, &cycleTime
+				cycleTimes = append(cycleTimes, cycleTime)
+				break
+			}
+		}
+		leadTimes = append(leadTimes, item.LeadTimeDays)
+		res.Items = append(res.Items, item)
+	}
+
+	slices.SortFunc(res.Items, func(a, b ItemCycleTime) int {
+		return a.CompletedAt.Compare(b.CompletedAt)
+	})
+	slices.Sort(cycleTimes)
+	slices.Sort(leadTimes)
+	res.CycleTime, res.LeadTime = percentiles(cycleTimes), percentiles(leadTimes)
+	return res, nil
+}
+
+// CumulativeFlow counts the board's items per status at the start of the range and every day
+// after, ending with the end of the range.
+func (s *ReportService) CumulativeFlow(boardID uuid.UUID, filter FlowFilter) (*CumulativeFlowResponse, error) {
+	board, err := s.findBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := flowRange(filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.Repo.ListBoardItems(board.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.histories(items, fieldStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(math.Ceil(days(to.Sub(from))))
+	res := &CumulativeFlowResponse{
+		BoardID:  board.ID,
+		Statuses: flowStatuses,
+		Points:   make([]FlowPoint, 0, n+1),
+	}
+	for i := 0; i <= n; i++ {
+		at := from.Add(time.Duration(i) * day)
+		if at.After(to) {
+			at = to
+		}
+
+		counts := make(map[backlog.ItemStatus]int, len(flowStatuses))
+		for _, status := range flowStatuses {
+			counts[status] = 0
+		}
+		for _, h := range histories {
+			state, err := h.at(at)
+			if err != nil {
+				return nil, err
+			}
+			if state.exists {
+				counts[state.status]++
+			}
+		}
+		res.Points = append(res.Points, FlowPoint{At: at, Counts: counts})
+	}
+	return res, nil
+}
+
+// Aging lists the board's work in progress that has sat in its current status for at least
+// the threshold.
+func (s *ReportService) Aging(boardID uuid.UUID, filter FlowFilter, threshold time.Duration) (*AgingResponse, error) {
+	board, err := s.findBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if threshold <= 0 {
+		threshold = defaultAgingThreshold
+	}
+
+	items, err := s.Repo.ListBoardItems(board.ID, filter, startedStatuses...)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.histories(items, fieldStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	res := &AgingResponse{BoardID: board.ID, ThresholdDays: days(threshold), Items: []AgingItem{}}
+	for _, h := range histories {
+		transitions, err := h.transitions()
+		if err != nil {
+			return nil, err
+		}
+
+		since := transitions[len(transitions)-1].at
+		if age := now.Sub(since); age >= threshold {
+			res.Items = append(res.Items, AgingItem{
+				ItemID:  h.item.ID,
+				Title:   h.item.Title,
+				Status:  h.item.Status,
+				Since:   since,
+				AgeDays: days(age),
+			})
+		}
+	}
+
+	slices.SortFunc(res.Items, func(a, b AgingItem) int {
+		return a.Since.Compare(b.Since)
+	})
+	return res, nil
+}
+
+// throughput counts the items completed on each day of the range.
+func throughput(histories []itemHistory, from, to time.Time) ([]int, error) {
+	counts := make([]int, max(int(math.Ceil(days(to.Sub(from)))), 1))
+	for _, h := range histories {
+		transitions, err := h.transitions()
+		if err != nil {
+			return nil, err
+		}
+
+		completed := transitions[len(transitions)-1].at
+		if completed.Before(from) || !completed.Before(to) {
+			continue
+		}
+		counts[int(completed.Sub(from)/day)]++
+	}
+	return counts, nil
+}
+
+func validateForecast(req *ForecastRequest, now time.Time) error {
+	if (req.Items == nil) == (req.Date == nil) {
+		return common.BadRequest("Exactly one of items and date is required!")
+	}
+	if req.Items != nil && *req.Items <= 0 {
+		return common.BadRequest("Parameter items must be positive!")
+	}
+	if req.Items != nil && *req.Items > maxForecastItems {
+		return common.BadRequest(fmt.Sprintf("Parameter items can't be more than %d!", maxForecastItems))
+	}
+	if req.Date != nil && !req.Date.After(now) {
+		return common.BadRequest("Parameter date must be in the future!")
+	}
+	if req.Date != nil && req.Date.After(now.Add(maxForecastDays*day)) {
+		return common.BadRequest(fmt.Sprintf("Parameter date can't be more than %d days ahead!", maxForecastDays))
+	}
+
+	if req.Trials <= 0 {
+		req.Trials = defaultForecastTrials
+	}
+	if req.Trials > maxForecastTrials {
+		return common.BadRequest(fmt.Sprintf("Parameter trials can't be more than %d!", maxForecastTrials))
+	}
+
+	if len(req.Confidence) == 0 {
+		req.Confidence = defaultForecastConfidence
+	}
+	for _, c := range req.Confidence {
+		if c < 1 || c > 99 {
+			return common.BadRequest("Confidence levels must be between 1 and 99!")
+		}
+	}
+	return nil
+}
+
+// Forecast runs a Monte Carlo simulation over the board's daily throughput in the filter's
+// date range, to tell how many items get done by a date or when a number of items gets done.
+func (s *ReportService) Forecast(boardID uuid.UUID, filter FlowFilter, req ForecastRequest) (*ForecastResponse, error) {
+	board, err := s.findBoard(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := validateForecast(&req, now); err != nil {
+		return nil, err
+	}
+	from, to, err := flowRange(filter, now)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.Repo.ListBoardItems(board.ID, filter, backlog.Done)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.histories(items, fieldStatus)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := throughput(histories, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(daily, func(n int) bool { return n > 0 }) {
+		return nil, common.UnprocessableEntity("No items were completed in the history range, there is nothing to forecast from!")
+	}
+
+	seed := rand.Uint64()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	sim := newSimulation(seed, daily)
+
+	res := &ForecastResponse{
+		BoardID:     board.ID,
+		Throughput:  daily,
+		HistoryFrom: from,
+		HistoryTo:   to,
+		Trials:      req.Trials,
+		Seed:        seed,
+		Items:       req.Items,
+		Date:        req.Date,
+		Results:     make([]ForecastResult, 0, len(req.Confidence)),
+	}
+	if req.Date != nil {
+		outcomes := sim.itemsWithin(int(math.Ceil(days(req.Date.Sub(now)))), req.Trials)
+		for _, c := range req.Confidence {
+			n := atLeast(outcomes, c)
+			res.Results = append(res.Results, ForecastResult{Confidence: c, Items: &n})
+		}
+		return res, nil
+	}
+
+	outcomes := sim.daysFor(*req.Items, req.Trials)
+	for _, c := range req.Confidence {
+		date := now.Add(time.Duration(atMost(outcomes, c)) * day)
+		res.Results = append(res.Results, ForecastResult{Confidence: c, Date: &date})
+	}
+	return res, nil
+}
@@ -0,0 +1,426 @@
+package reports
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/backlog"
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type reportRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *reportRepositoryMock) GetBoard(id uuid.UUID) (*backlog.Board, error) {
+	args := m.Called(id)
+	if b := args.Get(0); b != nil {
+		return b.(*backlog.Board), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *reportRepositoryMock) GetSprint(id uuid.UUID) (*backlog.Sprint, error) {
+	args := m.Called(id)
+	if s := args.Get(0); s != nil {
+		return s.(*backlog.Sprint), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *reportRepositoryMock) ListClosedSprints(boardID uuid.UUID, limit int) ([]backlog.Sprint, error) {
+	args := m.Called(boardID, limit)
+	return args.Get(0).([]backlog.Sprint), args.Error(1)
+}
+
+func (m *reportRepositoryMock) ListSprintItems(sprintID uuid.UUID) ([]backlog.Item, error) {
+	args := m.Called(sprintID)
+	return args.Get(0).([]backlog.Item), args.Error(1)
+}
+
+func (m *reportRepositoryMock) ListBoardItems(boardID uuid.UUID, filter FlowFilter, statuses ...backlog.ItemStatus) ([]backlog.Item, error) {
+	args := m.Called(boardID, filter, statuses)
+	return args.Get(0).([]backlog.Item), args.Error(1)
+}
+
+func (m *reportRepositoryMock) ListActivities(itemIDs []uuid.UUID, fields ...string) ([]backlog.ItemActivity, error) {
+	args := m.Called(itemIDs, fields)
+	return args.Get(0).([]backlog.ItemActivity), args.Error(1)
+}
+
+func estimate(v float64) *float64 {
+	return &v
+}
+
+func activity(itemID uuid.UUID, at time.Time, field, oldValue, newValue string) backlog.ItemActivity {
+	return backlog.ItemActivity{
+		BaseEntity: common.BaseEntity{ID: uuid.New(), CreatedAt: at},
+		ItemID:     itemID,
+		Field:      field,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+	}
+}
+
+// closedSprint sets up a two day sprint that was committed to three items worth 13 points:
+// one got done on the second day, one was re-estimated from 2 to 5 and left open, and one
+// was dropped. A one point item was added and done on the second day.
+func closedSprint(repo *reportRepositoryMock) *backlog.Sprint {
+	board := backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}, EstimateUnit: backlog.StoryPoints}
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	closedAt := start.Add(2 * day)
+	sprint := &backlog.Sprint{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		BoardID:    board.ID,
+		Board:      board,
+		Name:       "Sprint 1",
+		StartDate:  start,
+		EndDate:    closedAt,
+		State:      backlog.SprintClosed,
+		StartedAt:  &start,
+		ClosedAt:   &closedAt,
+	}
+
+	created := common.BaseEntity{CreatedAt: start.Add(-day)}
+	done := backlog.Item{BaseEntity: created, SprintID: &sprint.ID, Status: backlog.Done, Estimate: estimate(3)}
+	open := backlog.Item{BaseEntity: created, SprintID: &sprint.ID, Status: backlog.InProgress, Estimate: estimate(5)}
+	dropped := backlog.Item{BaseEntity: created, Status: backlog.ToDo, Estimate: estimate(8)}
+	added := backlog.Item{BaseEntity: created, SprintID: &sprint.ID, Status: backlog.Done, Estimate: estimate(1)}
+	items := []backlog.Item{done, open, dropped, added}
+	for i := range items {
+		items[i].ID = uuid.New()
+	}
+
+	inSprint := fmt.Sprintf("%q", sprint.ID)
+	activities := []backlog.ItemActivity{
+		activity(items[2].ID, start.Add(6*time.Hour), fieldSprint, inSprint, "null"),
+		activity(items[1].ID, start.Add(12*time.Hour), fieldEstimate, "2", "5"),
+		activity(items[0].ID, start.Add(25*time.Hour), fieldStatus, `"in_progress"`, `"done"`),
+		activity(items[3].ID, start.Add(36*time.Hour), fieldSprint, "null", inSprint),
+		activity(items[3].ID, start.Add(43*time.Hour), fieldStatus, `"to_do"`, `"done"`),
+	}
+
+	repo.On("GetSprint", sprint.ID).Return(sprint, nil).Maybe()
+	repo.On("ListSprintItems", sprint.ID).Return(items, nil)
+	repo.On("ListActivities", mock.Anything, mock.Anything).Return(activities, nil)
+	return sprint
+}
+
+func TestReportService_Burndown(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	sprint := closedSprint(repo)
+
+	resp, err := service.Burndown(sprint.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, backlog.StoryPoints, resp.Unit)
+	assert.Equal(t, Amount{Estimate: 13, Items: 3}, resp.Committed)
+	assert.Len(t, resp.Points, 3)
+	assert.Equal(t, &Amount{Estimate: 13, Items: 3}, resp.Points[0].Remaining)
+	assert.Equal(t, &Amount{Estimate: 8, Items: 2}, resp.Points[1].Remaining)
+	assert.Equal(t, &Amount{Estimate: 5, Items: 1}, resp.Points[2].Remaining)
+	assert.Equal(t, []float64{13, 6.5, 0}, []float64{resp.Points[0].IdealEstimate, resp.Points[1].IdealEstimate, resp.Points[2].IdealEstimate})
+}
+
+func TestReportService_Burndown_ActiveSprintLeavesFutureEmpty(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	start := time.Now().Add(-36 * time.Hour)
+	sprint := &backlog.Sprint{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		StartDate:  start,
+		EndDate:    start.Add(5 * day),
+		State:      backlog.SprintActive,
+		StartedAt:  &start,
+	}
+	item := backlog.Item{BaseEntity: common.BaseEntity{ID: uuid.New(), CreatedAt: start.Add(-day)}, SprintID: &sprint.ID, Status: backlog.ToDo, Estimate: estimate(2)}
+	repo.On("GetSprint", sprint.ID).Return(sprint, nil)
+	repo.On("ListSprintItems", sprint.ID).Return([]backlog.Item{item}, nil)
+	repo.On("ListActivities", mock.Anything, mock.Anything).Return([]backlog.ItemActivity{}, nil)
+
+	resp, err := service.Burndown(sprint.ID)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Points, 6)
+	assert.NotNil(t, resp.Points[0].Remaining)
+	assert.NotNil(t, resp.Points[1].Remaining)
+	// The point for the second day shows where the sprint stands now.
+	assert.NotNil(t, resp.Points[2].Remaining)
+	assert.Nil(t, resp.Points[3].Remaining)
+	assert.Nil(t, resp.Points[5].Remaining)
+}
+
+func TestReportService_Burndown_NotStarted(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	sprint := &backlog.Sprint{BaseEntity: common.BaseEntity{ID: uuid.New()}, State: backlog.SprintPlanned}
+	repo.On("GetSprint", sprint.ID).Return(sprint, nil)
+
+	resp, err := service.Burndown(sprint.ID)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 409, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "ListSprintItems", mock.Anything)
+}
+
+func TestReportService_Commitment(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	sprint := closedSprint(repo)
+
+	resp, err := service.Commitment(sprint.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Amount{Estimate: 13, Items: 3}, resp.Committed)
+	assert.Equal(t, Amount{Estimate: 1, Items: 1}, resp.Added)
+	assert.Equal(t, Amount{Estimate: 8, Items: 1}, resp.Removed)
+	assert.Equal(t, Amount{Estimate: 4, Items: 2}, resp.Completed)
+	assert.Equal(t, Amount{Estimate: 5, Items: 1}, resp.Incomplete)
+}
+
+func TestReportService_Commitment_NotFound(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	id := uuid.New()
+	repo.On("GetSprint", id).Return(nil, gorm.ErrRecordNotFound)
+
+	resp, err := service.Commitment(id)
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 404, err.(*common.ApiError).StatusCode)
+}
+
+func TestReportService_Velocity(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	sprint := closedSprint(repo)
+	repo.On("GetBoard", sprint.BoardID).Return(&sprint.Board, nil)
+	repo.On("ListClosedSprints", sprint.BoardID, defaultVelocitySprints).Return([]backlog.Sprint{*sprint}, nil)
+
+	resp, err := service.Velocity(sprint.BoardID, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Sprints, 1)
+	assert.Equal(t, Amount{Estimate: 13, Items: 3}, resp.Sprints[0].Committed)
+	assert.Equal(t, Amount{Estimate: 4, Items: 2}, resp.Sprints[0].Completed)
+	assert.Equal(t, AverageVelocity{Estimate: 4, Items: 2}, resp.Average)
+}
+
+// kanbanBoard sets up three items created a day before the range starts: one worked on and
+// done within it, one moved straight to done, and one done after the range.
+func kanbanBoard(repo *reportRepositoryMock) (*backlog.Board, FlowFilter) {
+	board := &backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(2 * day)
+	filter := FlowFilter{From: &from, To: &to}
+
+	created := common.BaseEntity{CreatedAt: from.Add(-day)}
+	items := []backlog.Item{
+		{BaseEntity: created, Title: "Worked on", Status: backlog.Done},
+		{BaseEntity: created, Title: "Skipped ahead", Status: backlog.Done},
+		{BaseEntity: created, Title: "Done later", Status: backlog.Done},
+	}
+	for i := range items {
+		items[i].ID = uuid.New()
+	}
+	activities := []backlog.ItemActivity{
+		activity(items[0].ID, from.Add(day), fieldStatus, `"to_do"`, `"in_progress"`),
+		activity(items[1].ID, from.Add(day), fieldStatus, `"to_do"`, `"done"`),
+		activity(items[0].ID, from.Add(36*time.Hour), fieldStatus, `"in_progress"`, `"done"`),
+		activity(items[2].ID, from.Add(4*day), fieldStatus, `"to_do"`, `"in_progress"`),
+		activity(items[2].ID, from.Add(5*day), fieldStatus, `"in_progress"`, `"done"`),
+	}
+
+	repo.On("GetBoard", board.ID).Return(board, nil)
+	repo.On("ListBoardItems", board.ID, filter, mock.Anything).Return(items, nil)
+	repo.On("ListActivities", mock.Anything, []string{fieldStatus}).Return(activities, nil)
+	return board, filter
+}
+
+func TestReportService_CycleTime(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board, filter := kanbanBoard(repo)
+
+	resp, err := service.CycleTime(board.ID, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Items, 2)
+	assert.Equal(t, "Skipped ahead", resp.Items[0].Title)
+	assert.Nil(t, resp.Items[0].CycleTimeDays)
+	assert.Equal(t, 2.0, resp.Items[0].LeadTimeDays)
+	assert.Equal(t, "Worked on", resp.Items[1].Title)
+	assert.Equal(t, 0.5, *resp.Items[1].CycleTimeDays)
+	assert.Equal(t, 2.5, resp.Items[1].LeadTimeDays)
+	assert.Equal(t, &Percentiles{P50: 0.5, P85: 0.5, P95: 0.5}, resp.CycleTime)
+	assert.Equal(t, &Percentiles{P50: 2, P85: 2.5, P95: 2.5}, resp.LeadTime)
+	repo.AssertCalled(t, "ListBoardItems", board.ID, filter, []backlog.ItemStatus{backlog.Done})
+}
+
+func TestReportService_CycleTime_InvalidRange(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board := &backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-day)
+	repo.On("GetBoard", board.ID).Return(board, nil)
+
+	resp, err := service.CycleTime(board.ID, FlowFilter{From: &from, To: &to})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "ListBoardItems", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReportService_CumulativeFlow(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board, filter := kanbanBoard(repo)
+
+	resp, err := service.CumulativeFlow(board.ID, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Points, 3)
+	assert.Equal(t, 3, resp.Points[0].Counts[backlog.ToDo])
+	assert.Equal(t, 0, resp.Points[0].Counts[backlog.Done])
+	assert.Equal(t, map[backlog.ItemStatus]int{
+		backlog.Done: 1, backlog.InReview: 0, backlog.OnHold: 0, backlog.InProgress: 1, backlog.ToDo: 1, backlog.NotPlanned: 0,
+	}, resp.Points[1].Counts)
+	assert.Equal(t, 2, resp.Points[2].Counts[backlog.Done])
+	assert.Equal(t, 1, resp.Points[2].Counts[backlog.ToDo])
+}
+
+func TestReportService_Aging(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board := &backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	now := time.Now()
+	created := common.BaseEntity{CreatedAt: now.Add(-10 * day)}
+	stuck := backlog.Item{BaseEntity: created, Title: "Stuck", Status: backlog.InReview}
+	moving := backlog.Item{BaseEntity: created, Title: "Moving", Status: backlog.InProgress}
+	stuck.ID, moving.ID = uuid.New(), uuid.New()
+	repo.On("GetBoard", board.ID).Return(board, nil)
+	repo.On("ListBoardItems", board.ID, FlowFilter{}, startedStatuses).Return([]backlog.Item{stuck, moving}, nil)
+	repo.On("ListActivities", mock.Anything, []string{fieldStatus}).Return([]backlog.ItemActivity{
+		activity(stuck.ID, now.Add(-5*day), fieldStatus, `"in_progress"`, `"in_review"`),
+		activity(moving.ID, now.Add(-day), fieldStatus, `"to_do"`, `"in_progress"`),
+	}, nil)
+
+	resp, err := service.Aging(board.ID, FlowFilter{}, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, resp.ThresholdDays)
+	assert.Len(t, resp.Items, 1)
+	assert.Equal(t, stuck.ID, resp.Items[0].ItemID)
+	assert.InDelta(t, 5, resp.Items[0].AgeDays, 0.01)
+}
+
+func TestSimulation_ConstantThroughput(t *testing.T) {
+	sim := newSimulation(1, []int{2})
+
+	assert.Equal(t, []int{10, 10, 10}, sim.itemsWithin(5, 3))
+	assert.Equal(t, []int{4, 4, 4}, sim.daysFor(7, 3))
+}
+
+func TestSimulation_ConfidenceLevels(t *testing.T) {
+	outcomes := make([]int, 100)
+	for i := range outcomes {
+		outcomes[i] = i + 1
+	}
+
+	// 85% of the trials finished at least 16 items, and within 85 days.
+	assert.Equal(t, 16, atLeast(outcomes, 85))
+	assert.Equal(t, 85, atMost(outcomes, 85))
+}
+
+func TestReportService_Forecast_SeededIsRepeatable(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board, filter := kanbanBoard(repo)
+	seed := uint64(42)
+	items := 4
+	req := ForecastRequest{Items: &items, Seed: &seed, Trials: 500}
+
+	first, err := service.Forecast(board.ID, filter, req)
+	assert.NoError(t, err)
+	second, err := service.Forecast(board.ID, filter, req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{0, 2}, first.Throughput)
+	assert.Equal(t, seed, first.Seed)
+	assert.Len(t, first.Results, 3)
+	for i, r := range first.Results {
+		assert.Equal(t, r.Confidence, second.Results[i].Confidence)
+		assert.WithinDuration(t, *r.Date, *second.Results[i].Date, time.Second)
+	}
+	assert.False(t, first.Results[2].Date.Before(*first.Results[0].Date))
+}
+
+func TestReportService_Forecast_ItemsByDate(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board, filter := kanbanBoard(repo)
+	seed := uint64(7)
+	date := time.Now().Add(10 * day)
+
+	resp, err := service.Forecast(board.ID, filter, ForecastRequest{Date: &date, Seed: &seed, Confidence: []int{50, 95}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, defaultForecastTrials, resp.Trials)
+	assert.Len(t, resp.Results, 2)
+	// Every day finishes either none or two items, so ten days average ten items.
+	assert.InDelta(t, 10, *resp.Results[0].Items, 2)
+	assert.LessOrEqual(t, *resp.Results[1].Items, *resp.Results[0].Items)
+}
+
+func TestReportService_Forecast_NeedsItemsOrDate(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board := &backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	repo.On("GetBoard", board.ID).Return(board, nil)
+	items := 5
+	date := time.Now().Add(day)
+
+	resp, err := service.Forecast(board.ID, FlowFilter{}, ForecastRequest{Items: &items, Date: &date})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+}
+
+func TestReportService_Forecast_TooFarAhead(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board := &backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	repo.On("GetBoard", board.ID).Return(board, nil)
+	items := maxForecastItems + 1
+	date := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	for _, req := range []ForecastRequest{{Items: &items}, {Date: &date}} {
+		resp, err := service.Forecast(board.ID, FlowFilter{}, req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, 400, err.(*common.ApiError).StatusCode)
+	}
+	repo.AssertNotCalled(t, "ListBoardItems", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReportService_Forecast_NoThroughput(t *testing.T) {
+	repo := &reportRepositoryMock{}
+	service := NewReportService(repo)
+	board := &backlog.Board{BaseEntity: common.BaseEntity{ID: uuid.New()}}
+	repo.On("GetBoard", board.ID).Return(board, nil)
+	repo.On("ListBoardItems", board.ID, FlowFilter{}, []backlog.ItemStatus{backlog.Done}).Return([]backlog.Item{}, nil)
+	repo.On("ListActivities", mock.Anything, []string{fieldStatus}).Return([]backlog.ItemActivity{}, nil)
+	items := 5
+
+	resp, err := service.Forecast(board.ID, FlowFilter{}, ForecastRequest{Items: &items})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, 422, err.(*common.ApiError).StatusCode)
+}
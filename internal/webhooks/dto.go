@@ -0,0 +1,84 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateWebhookRequest struct {
+	TeamID uuid.UUID `json:"teamId" validate:"required"`
+	URL    string    `json:"url" validate:"required,http_url,max=2048"`
+	Events []string  `json:"events" validate:"required,min=1,unique,dive,oneof=member.added member.removed item.created item.updated item.status_changed item.deleted comment.created"`
+}
+
+type WebhookResponse struct {
+	ID        uuid.UUID `json:"id"`
+	TeamID    uuid.UUID `json:"teamId"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreatedWebhookResponse is only returned on creation, the secret can't be read afterwards.
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+func ToWebhookResponse(w *Webhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:        w.ID,
+		TeamID:    w.TeamID,
+		URL:       w.URL,
+		Events:    w.EventList(),
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// Envelope is the JSON body of every delivery. ID is the outbox message, so receivers can
+// drop duplicates when a delivery is retried or redelivered.
+type Envelope struct {
+	ID         uuid.UUID       `json:"id"`
+	Event      string          `json:"event"`
+	TeamID     uuid.UUID       `json:"teamId"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type DeliveryResponse struct {
+	ID             uuid.UUID      `json:"id"`
+	WebhookID      uuid.UUID      `json:"webhookId"`
+	MessageID      uuid.UUID      `json:"messageId"`
+	Event          string         `json:"event"`
+	Status         DeliveryStatus `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  *time.Time     `json:"nextAttemptAt,omitempty"`
+	LastAttemptAt  *time.Time     `json:"lastAttemptAt,omitempty"`
+	ResponseStatus int            `json:"responseStatus,omitempty"`
+	LastError      string         `json:"lastError,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+}
+
+func ToDeliveryResponse(d *Delivery) *DeliveryResponse {
+	resp := &DeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		MessageID:      d.MessageID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastAttemptAt:  d.LastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.Status == DeliveryPending {
+		next := d.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	return resp
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookHandler struct {
+	Service *WebhookService
+}
+
+func NewWebhookHandler(service *WebhookService) *WebhookHandler {
+	return &WebhookHandler{Service: service}
+}
+
+func pagination(r *http.Request) (int, int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if size <= 0 {
+		size = 10
+	}
+	return page, size
+}
+
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteError(w, common.BadRequest(err.Error()))
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.Create(principal, req)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) ListByTeam(w http.ResponseWriter, r *http.Request) {
+	teamID, err := common.ParseUUID(r.URL.Query().Get("teamId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	page, size := pagination(r)
+	resp, err := h.Service.ListByTeamID(principal, teamID, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.GetByID(principal, id)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	if err := h.Service.DeleteByID(principal, id); err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	page, size := pagination(r)
+	resp, err := h.Service.ListDeliveries(principal, id, page, size)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := common.ParseUUID(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	deliveryID, err := common.ParseUUID(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	principal, _ := common.PrincipalFromContext(r.Context())
+	resp, err := h.Service.Redeliver(principal, id, deliveryID)
+	if err != nil {
+		common.WriteError(w, err)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusAccepted, resp)
+}
@@ -0,0 +1,55 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/google/uuid"
+)
+
+type Webhook struct {
+	common.BaseEntity
+	TeamID uuid.UUID `gorm:"type:uuid;not null;index"`
+	URL    string    `gorm:"type:varchar(2048);not null"`
+	// Secret signs the deliveries, so unlike tokens it has to be stored as is.
+	Secret      string     `gorm:"type:varchar(64);not null"`
+	Events      string     `gorm:"type:jsonb;not null"`
+	Active      bool       `gorm:"not null;default:true"`
+	CreatedByID *uuid.UUID `gorm:"type:uuid"`
+}
+
+func (w *Webhook) EventList() []string {
+	var events []string
+	_ = json.Unmarshal([]byte(w.Events), &events)
+	return events
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one attempt chain of sending an outbox message to a webhook. Body is kept
+// byte for byte, so retries and redeliveries carry exactly what was first signed.
+type Delivery struct {
+	common.BaseEntity
+	WebhookID      uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Webhook        Webhook        `gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE;"`
+	MessageID      uuid.UUID      `gorm:"type:uuid;not null"`
+	Event          string         `gorm:"type:varchar(50);not null"`
+	Body           string         `gorm:"type:text;not null"`
+	Status         DeliveryStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts       int            `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time      `gorm:"not null"`
+	LastAttemptAt  *time.Time
+	ResponseStatus int    `gorm:"not null;default:0"`
+	LastError      string `gorm:"type:text"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	Create(webhook *Webhook) error
+	GetByID(id uuid.UUID) (*Webhook, error)
+	DeleteByID(id uuid.UUID) error
+	ListByTeamID(teamID uuid.UUID, offset, limit int) ([]Webhook, int, error)
+	Dispatch(limit int, fanOut func(message *outbox.Message, hooks []Webhook) ([]Delivery, error)) (int, error)
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+	SaveAttempt(delivery *Delivery) error
+	CreateDelivery(delivery *Delivery) error
+	GetDelivery(webhookID, id uuid.UUID) (*Delivery, error)
+	ListDeliveries(webhookID uuid.UUID, offset, limit int) ([]Delivery, int, error)
+}
+
+type webhookRepository struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{DB: db}
+}
+
+func (r *webhookRepository) Create(webhook *Webhook) error {
+	return r.DB.Create(webhook).Error
+}
+
+func (r *webhookRepository) GetByID(id uuid.UUID) (*Webhook, error) {
+	var webhook Webhook
+	if err := r.DB.First(&webhook, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) DeleteByID(id uuid.UUID) error {
+	return r.DB.Delete(&Webhook{}, "id = ?", id).Error
+}
+
+func (r *webhookRepository) ListByTeamID(teamID uuid.UUID, offset, limit int) ([]Webhook, int, error) {
+	var webhooks []Webhook
+	var total int64
+
+	query := r.DB.Model(&Webhook{}).Where("team_id = ?", teamID)
+	query.Count(&total)
+	if err := query.Order("created_at").Offset(offset).Limit(limit).Find(&webhooks).Error; err != nil {
+		return nil, 0, err
+	}
+	return webhooks, int(total), nil
+}
+
+// Dispatch turns undispatched outbox messages into deliveries for every subscribed webhook.
+// SKIP LOCKED lets both replicas run the job without fanning out a message twice.
+func (r *webhookRepository) Dispatch(limit int, fanOut func(message *outbox.Message, hooks []Webhook) ([]Delivery, error)) (int, error) {
+	var messages []outbox.Message
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").
+			Order("created_at").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range messages {
+			m := &messages[i]
+
+			var hooks []Webhook
+			if err := tx.Where("team_id = ? AND active AND events @> ?::jsonb", m.TeamID, `["`+m.Event+`"]`).
+				Find(&hooks).Error; err != nil {
+				return err
+			}
+
+			deliveries, err := fanOut(m, hooks)
+			if err != nil {
+				return err
+			}
+			if len(deliveries) > 0 {
+				if err := tx.Omit(clause.Associations).Create(&deliveries).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Model(m).Update("dispatched_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(messages), err
+}
+
+// ClaimDue leases pending deliveries by pushing their next attempt past the lease, so other
+// workers skip them while the request is in flight. A crashed worker's lease simply runs out.
+func (r *webhookRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(deliveries))
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+		}
+		if err := tx.Model(&Delivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Webhook").Where("id IN ?", ids).Find(&deliveries).Error
+	})
+	return deliveries, err
+}
+
+func (r *webhookRepository) SaveAttempt(delivery *Delivery) error {
+	return r.DB.Model(&Delivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]any{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"last_attempt_at": delivery.LastAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"last_error":      delivery.LastError,
+		}).Error
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *Delivery) error {
+	return r.DB.Omit(clause.Associations).Create(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(webhookID, id uuid.UUID) (*Delivery, error) {
+	var delivery Delivery
+	if err := r.DB.First(&delivery, "id = ? AND webhook_id = ?", id, webhookID).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(webhookID uuid.UUID, offset, limit int) ([]Delivery, int, error) {
+	var deliveries []Delivery
+	var total int64
+
+	query := r.DB.Model(&Delivery{}).Where("webhook_id = ?", webhookID)
+	query.Count(&total)
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, int(total), nil
+}
@@ -0,0 +1,26 @@
+package webhooks
+
+import (
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/go-chi/chi/v5"
+)
+
+func WebhookRoutes(r chi.Router, handler *WebhookHandler) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(common.RequireAuth)
+
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireScope(common.ScopeTeamsRead))
+			r.Get("/", handler.ListByTeam)
+			r.Get("/{webhookId}", handler.GetByID)
+			r.Get("/{webhookId}/deliveries", handler.ListDeliveries)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(common.RequireScope(common.ScopeTeamsWrite))
+			r.Post("/", handler.Create)
+			r.Delete("/{webhookId}", handler.DeleteByID)
+			r.Post("/{webhookId}/deliveries/{deliveryId}/redeliver", handler.Redeliver)
+		})
+	})
+}
@@ -0,0 +1,364 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SignatureHeader = "X-Gollab-Signature"
+	TimestampHeader = "X-Gollab-Timestamp"
+	EventHeader     = "X-Gollab-Event"
+	DeliveryHeader  = "X-Gollab-Delivery"
+)
+
+// batchSize bounds how many messages and deliveries a single worker run handles.
+const batchSize = 100
+
+// maxErrorLength keeps stored response bodies of failing receivers short.
+const maxErrorLength = 1000
+
+type DeliveryPolicy struct {
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Timeout     time.Duration
+}
+
+// Backoff is the delay before the next attempt, doubling after every failed one.
+func (p DeliveryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BackoffBase
+	for i := 1; i < attempts && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, p.BackoffMax)
+}
+
+// Sign computes the signature receivers verify: hex HMAC-SHA256 of "<timestamp>.<body>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type WebhookService struct {
+	Repo        WebhookRepository
+	TeamService *org.TeamService
+	Validator   *validator.Validate
+	Client      *http.Client
+	Policy      DeliveryPolicy
+}
+
+func NewWebhookService(
+	repo WebhookRepository,
+	teamService *org.TeamService,
+	validator *validator.Validate,
+	policy DeliveryPolicy,
+) *WebhookService {
+	return &WebhookService{
+		Repo:        repo,
+		TeamService: teamService,
+		Validator:   validator,
+		Client:      &http.Client{Timeout: policy.Timeout},
+		Policy:      policy,
+	}
+}
+
+// authorize lets admins and project managers of the team manage its webhooks.
+func (s *WebhookService) authorize(p *common.Principal, teamID uuid.UUID) error {
+	if p.IsAdmin && p.HasScope(common.ScopeAdmin) {
+		return nil
+	}
+	manager, err := s.TeamService.IsManager(teamID, p.UserID)
+	if err != nil {
+		return err
+	}
+	if !manager {
+		return common.Forbidden("Only project managers can manage webhooks of the team!")
+	}
+	return nil
+}
+
+func (s *WebhookService) Create(p *common.Principal, req CreateWebhookRequest) (*CreatedWebhookResponse, error) {
+	if err := s.Validator.Struct(req); err != nil {
+		return nil, common.BadRequest(err.Error())
+	}
+
+	if _, err := s.TeamService.GetByID(req.TeamID); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(p, req.TeamID); err != nil {
+		return nil, err
+	}
+
+	secret, err := common.NewRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := json.Marshal(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &Webhook{
+		TeamID:      req.TeamID,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      string(events),
+		Active:      true,
+		CreatedByID: &p.UserID,
+	}
+	if err := s.Repo.Create(webhook); err != nil {
+		return nil, err
+	}
+
+	return &CreatedWebhookResponse{
+		WebhookResponse: *ToWebhookResponse(webhook),
+		Secret:          secret,
+	}, nil
+}
+
+func (s *WebhookService) ListByTeamID(p *common.Principal, teamID uuid.UUID, page, size int) (*common.PaginatedResponse[WebhookResponse], error) {
+	if err := s.authorize(p, teamID); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * size
+	webhooks, total, err := s.Repo.ListByTeamID(teamID, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]WebhookResponse, 0, len(webhooks))
+	for _, w := range webhooks {
+		res = append(res, *ToWebhookResponse(&w))
+	}
+
+	return &common.PaginatedResponse[WebhookResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+func (s *WebhookService) GetByID(p *common.Principal, id uuid.UUID) (*WebhookResponse, error) {
+	webhook, err := s.findByID(p, id)
+	if err != nil {
+		return nil, err
+	}
+	return ToWebhookResponse(webhook), nil
+}
+
+func (s *WebhookService) DeleteByID(p *common.Principal, id uuid.UUID) error {
+	if _, err := s.findByID(p, id); err != nil {
+		return err
+	}
+	return s.Repo.DeleteByID(id)
+}
+
+func (s *WebhookService) findByID(p *common.Principal, id uuid.UUID) (*Webhook, error) {
+	webhook, err := s.Repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Webhook with id %s was not found!", id))
+		}
+		return nil, err
+	}
+
+	if err := s.authorize(p, webhook.TeamID); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) ListDeliveries(p *common.Principal, webhookID uuid.UUID, page, size int) (*common.PaginatedResponse[DeliveryResponse], error) {
+	if _, err := s.findByID(p, webhookID); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * size
+	deliveries, total, err := s.Repo.ListDeliveries(webhookID, offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		res = append(res, *ToDeliveryResponse(&d))
+	}
+
+	return &common.PaginatedResponse[DeliveryResponse]{
+		Items: res,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}, nil
+}
+
+// Redeliver queues a new delivery with the same body, so the log keeps the original attempts.
+func (s *WebhookService) Redeliver(p *common.Principal, webhookID, deliveryID uuid.UUID) (*DeliveryResponse, error) {
+	if _, err := s.findByID(p, webhookID); err != nil {
+		return nil, err
+	}
+
+	original, err := s.Repo.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.NotFound(fmt.Sprintf("Delivery with id %s was not found!", deliveryID))
+		}
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		WebhookID:     webhookID,
+		MessageID:     original.MessageID,
+		Event:         original.Event,
+		Body:          original.Body,
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.Repo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return ToDeliveryResponse(delivery), nil
+}
+
+// ProcessOutbox fans new outbox messages out to webhooks and sends the deliveries that are due.
+func (s *WebhookService) ProcessOutbox() error {
+	if err := s.DispatchOutbox(); err != nil {
+		return err
+	}
+	return s.DeliverPending()
+}
+
+func (s *WebhookService) DispatchOutbox() error {
+	for {
+		n, err := s.Repo.Dispatch(batchSize, fanOut)
+		if err != nil || n < batchSize {
+			return err
+		}
+	}
+}
+
+func fanOut(message *outbox.Message, hooks []Webhook) ([]Delivery, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(Envelope{
+		ID:         message.ID,
+		Event:      message.Event,
+		TeamID:     message.TeamID,
+		OccurredAt: message.CreatedAt,
+		Data:       json.RawMessage(message.Payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(hooks))
+	for _, h := range hooks {
+		deliveries = append(deliveries, Delivery{
+			BaseEntity:    common.BaseEntity{ID: uuid.New()},
+			WebhookID:     h.ID,
+			MessageID:     message.ID,
+			Event:         message.Event,
+			Body:          string(body),
+			Status:        DeliveryPending,
+			NextAttemptAt: message.CreatedAt,
+		})
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookService) DeliverPending() error {
+	for range batchSize {
+		// Deliveries are claimed one at a time with a lease that outlasts the request timeout, so
+		// no other worker picks the delivery up while it is in flight.
+		deliveries, err := s.Repo.ClaimDue(time.Now(), 2*s.Policy.Timeout, 1)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		s.attempt(&deliveries[0])
+		if err := s.Repo.SaveAttempt(&deliveries[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) attempt(d *Delivery) {
+	now := time.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = 0
+	d.LastError = ""
+
+	err := s.send(d, now)
+	if err == nil {
+		d.Status = DeliverySucceeded
+		return
+	}
+
+	d.LastError = err.Error()
+	if len(d.LastError) > maxErrorLength {
+		d.LastError = d.LastError[:maxErrorLength]
+	}
+
+	if d.Attempts >= s.Policy.MaxAttempts {
+		d.Status = DeliveryFailed
+		return
+	}
+	d.NextAttemptAt = now.Add(s.Policy.Backoff(d.Attempts))
+}
+
+func (s *WebhookService) send(d *Delivery, now time.Time) error {
+	body := []byte(d.Body)
+	req, err := http.NewRequest(http.MethodPost, d.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Gollab-Webhooks")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, d.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(d.Webhook.Secret, timestamp, body))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	d.ResponseStatus = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	return fmt.Errorf("receiver responded with %d: %s", resp.StatusCode, snippet)
+}
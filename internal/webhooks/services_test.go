@@ -0,0 +1,292 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/StefanShivarov/gollab-backend/internal/common"
+	"github.com/StefanShivarov/gollab-backend/internal/org"
+	"github.com/StefanShivarov/gollab-backend/internal/outbox"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type webhookRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *webhookRepositoryMock) Create(webhook *Webhook) error {
+	return m.Called(webhook).Error(0)
+}
+
+func (m *webhookRepositoryMock) GetByID(id uuid.UUID) (*Webhook, error) {
+	args := m.Called(id)
+	if w := args.Get(0); w != nil {
+		return w.(*Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *webhookRepositoryMock) DeleteByID(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *webhookRepositoryMock) ListByTeamID(teamID uuid.UUID, offset, limit int) ([]Webhook, int, error) {
+	args := m.Called(teamID, offset, limit)
+	return args.Get(0).([]Webhook), args.Int(1), args.Error(2)
+}
+
+func (m *webhookRepositoryMock) Dispatch(limit int, fanOut func(message *outbox.Message, hooks []Webhook) ([]Delivery, error)) (int, error) {
+	args := m.Called(limit, fanOut)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *webhookRepositoryMock) ClaimDue(now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	args := m.Called(now, lease, limit)
+	return args.Get(0).([]Delivery), args.Error(1)
+}
+
+func (m *webhookRepositoryMock) SaveAttempt(delivery *Delivery) error {
+	return m.Called(delivery).Error(0)
+}
+
+func (m *webhookRepositoryMock) CreateDelivery(delivery *Delivery) error {
+	return m.Called(delivery).Error(0)
+}
+
+func (m *webhookRepositoryMock) GetDelivery(webhookID, id uuid.UUID) (*Delivery, error) {
+	args := m.Called(webhookID, id)
+	if d := args.Get(0); d != nil {
+		return d.(*Delivery), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *webhookRepositoryMock) ListDeliveries(webhookID uuid.UUID, offset, limit int) ([]Delivery, int, error) {
+	args := m.Called(webhookID, offset, limit)
+	return args.Get(0).([]Delivery), args.Int(1), args.Error(2)
+}
+
+type teamRepositoryMock struct {
+	mock.Mock
+	org.TeamRepository
+}
+
+func (m *teamRepositoryMock) GetByID(id uuid.UUID) (*org.Team, error) {
+	args := m.Called(id)
+	if t := args.Get(0); t != nil {
+		return t.(*org.Team), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *teamRepositoryMock) HasRole(teamID, userID uuid.UUID, role org.TeamRole) (bool, error) {
+	args := m.Called(teamID, userID, role)
+	return args.Bool(0), args.Error(1)
+}
+
+var testPolicy = DeliveryPolicy{
+	MaxAttempts: 3,
+	BackoffBase: time.Minute,
+	BackoffMax:  10 * time.Minute,
+	Timeout:     time.Second,
+}
+
+func setupWebhookTest() (*WebhookService, *webhookRepositoryMock, *teamRepositoryMock) {
+	repo := new(webhookRepositoryMock)
+	teamRepo := new(teamRepositoryMock)
+	v := validator.New()
+	teamService := org.NewTeamService(teamRepo, nil, nil, v)
+	return NewWebhookService(repo, teamService, v, testPolicy), repo, teamRepo
+}
+
+func pendingDelivery(url string) Delivery {
+	return Delivery{
+		BaseEntity: common.BaseEntity{ID: uuid.New()},
+		Webhook:    Webhook{BaseEntity: common.BaseEntity{ID: uuid.New()}, URL: url, Secret: "secret", Active: true},
+		MessageID:  uuid.New(),
+		Event:      outbox.ItemCreated,
+		Body:       `{"event":"item.created"}`,
+		Status:     DeliveryPending,
+	}
+}
+
+func TestWebhookService_Create_ReturnsSecret(t *testing.T) {
+	service, repo, teamRepo := setupWebhookTest()
+	teamID := uuid.New()
+	p := &common.Principal{UserID: uuid.New()}
+	teamRepo.On("GetByID", teamID).Return(&org.Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
+	teamRepo.On("HasRole", teamID, p.UserID, org.ProjectManager).Return(true, nil)
+	repo.On("Create", mock.AnythingOfType("*webhooks.Webhook")).Return(nil)
+
+	resp, err := service.Create(p, CreateWebhookRequest{
+		TeamID: teamID,
+		URL:    "https://example.com/hooks",
+		Events: []string{outbox.ItemCreated, outbox.CommentCreated},
+	})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Secret)
+	assert.Equal(t, []string{outbox.ItemCreated, outbox.CommentCreated}, resp.Events)
+}
+
+func TestWebhookService_Create_RequiresProjectManager(t *testing.T) {
+	service, repo, teamRepo := setupWebhookTest()
+	teamID := uuid.New()
+	p := &common.Principal{UserID: uuid.New()}
+	teamRepo.On("GetByID", teamID).Return(&org.Team{BaseEntity: common.BaseEntity{ID: teamID}}, nil)
+	teamRepo.On("HasRole", teamID, p.UserID, org.ProjectManager).Return(false, nil)
+
+	resp, err := service.Create(p, CreateWebhookRequest{
+		TeamID: teamID,
+		URL:    "https://example.com/hooks",
+		Events: []string{outbox.ItemCreated},
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, http.StatusForbidden, err.(*common.ApiError).StatusCode)
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWebhookService_Create_RejectsUnknownEvent(t *testing.T) {
+	service, _, _ := setupWebhookTest()
+
+	resp, err := service.Create(&common.Principal{IsAdmin: true}, CreateWebhookRequest{
+		TeamID: uuid.New(),
+		URL:    "https://example.com/hooks",
+		Events: []string{"item.archived"},
+	})
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+}
+
+// claimOnce makes ClaimDue hand out the delivery and then nothing.
+func claimOnce(repo *webhookRepositoryMock, delivery Delivery) {
+	repo.On("ClaimDue", mock.Anything, 2*testPolicy.Timeout, 1).Return([]Delivery{delivery}, nil).Once()
+	repo.On("ClaimDue", mock.Anything, 2*testPolicy.Timeout, 1).Return([]Delivery{}, nil)
+}
+
+func TestWebhookService_DeliverPending_SignsRequest(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	service, repo, _ := setupWebhookTest()
+	delivery := pendingDelivery(receiver.URL)
+	claimOnce(repo, delivery)
+	var saved *Delivery
+	repo.On("SaveAttempt", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*Delivery)
+	}).Return(nil)
+
+	err := service.DeliverPending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, delivery.Body, string(body))
+	assert.Equal(t, outbox.ItemCreated, received.Header.Get(EventHeader))
+	assert.Equal(t, delivery.ID.String(), received.Header.Get(DeliveryHeader))
+	timestamp, _ := strconv.ParseInt(received.Header.Get(TimestampHeader), 10, 64)
+	assert.Equal(t, Sign("secret", timestamp, body), received.Header.Get(SignatureHeader))
+	assert.Equal(t, DeliverySucceeded, saved.Status)
+	assert.Equal(t, 1, saved.Attempts)
+	assert.Equal(t, http.StatusNoContent, saved.ResponseStatus)
+}
+
+func TestWebhookService_DeliverPending_RetriesWithBackoff(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	service, repo, _ := setupWebhookTest()
+	delivery := pendingDelivery(receiver.URL)
+	delivery.Attempts = 1
+	claimOnce(repo, delivery)
+	var saved *Delivery
+	repo.On("SaveAttempt", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*Delivery)
+	}).Return(nil)
+
+	start := time.Now()
+	err := service.DeliverPending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryPending, saved.Status)
+	assert.Equal(t, 2, saved.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, saved.ResponseStatus)
+	assert.Contains(t, saved.LastError, "unavailable")
+	assert.WithinDuration(t, start.Add(2*time.Minute), saved.NextAttemptAt, time.Second)
+}
+
+func TestWebhookService_DeliverPending_GivesUpAfterMaxAttempts(t *testing.T) {
+	service, repo, _ := setupWebhookTest()
+	delivery := pendingDelivery("http://127.0.0.1:1/unreachable")
+	delivery.Attempts = testPolicy.MaxAttempts - 1
+	claimOnce(repo, delivery)
+	var saved *Delivery
+	repo.On("SaveAttempt", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*Delivery)
+	}).Return(nil)
+
+	err := service.DeliverPending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryFailed, saved.Status)
+	assert.NotEmpty(t, saved.LastError)
+}
+
+func TestDeliveryPolicy_Backoff(t *testing.T) {
+	assert.Equal(t, time.Minute, testPolicy.Backoff(1))
+	assert.Equal(t, 2*time.Minute, testPolicy.Backoff(2))
+	assert.Equal(t, 8*time.Minute, testPolicy.Backoff(4))
+	assert.Equal(t, 10*time.Minute, testPolicy.Backoff(20))
+}
+
+func TestFanOut_WrapsPayloadInEnvelope(t *testing.T) {
+	message, _ := outbox.NewMessage(uuid.New(), outbox.CommentCreated, map[string]string{"content": "hi"})
+	hooks := []Webhook{{BaseEntity: common.BaseEntity{ID: uuid.New()}}, {BaseEntity: common.BaseEntity{ID: uuid.New()}}}
+
+	deliveries, err := fanOut(message, hooks)
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, hooks[1].ID, deliveries[1].WebhookID)
+
+	var envelope Envelope
+	assert.NoError(t, json.Unmarshal([]byte(deliveries[0].Body), &envelope))
+	assert.Equal(t, message.ID, envelope.ID)
+	assert.Equal(t, outbox.CommentCreated, envelope.Event)
+	assert.JSONEq(t, `{"content":"hi"}`, string(envelope.Data))
+}
+
+func TestWebhookService_Redeliver_QueuesCopy(t *testing.T) {
+	service, repo, _ := setupWebhookTest()
+	webhook := &Webhook{BaseEntity: common.BaseEntity{ID: uuid.New()}, TeamID: uuid.New()}
+	original := pendingDelivery("")
+	original.WebhookID = webhook.ID
+	original.Status = DeliveryFailed
+	original.Attempts = testPolicy.MaxAttempts
+	repo.On("GetByID", webhook.ID).Return(webhook, nil)
+	repo.On("GetDelivery", webhook.ID, original.ID).Return(&original, nil)
+	repo.On("CreateDelivery", mock.AnythingOfType("*webhooks.Delivery")).Return(nil)
+
+	resp, err := service.Redeliver(&common.Principal{IsAdmin: true}, webhook.ID, original.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryPending, resp.Status)
+	assert.Equal(t, 0, resp.Attempts)
+	assert.Equal(t, original.MessageID, resp.MessageID)
+}